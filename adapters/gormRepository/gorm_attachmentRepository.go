@@ -0,0 +1,112 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormAttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAttachmentRepository(db *gorm.DB) *GormAttachmentRepository {
+	return &GormAttachmentRepository{db: db}
+}
+
+func (r *GormAttachmentRepository) CreateAttachment(attachment *entities.Attachment, quotaBytes int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// ล็อกแถวของผู้ใช้เพื่อให้การอัปโหลดพร้อมกันตรวจ quota ทีละรายการ
+		var user entities.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("user_id").
+			First(&user, attachment.UserID).Error; err != nil {
+			return fmt.Errorf("failed to lock user: %v", err)
+		}
+
+		if quotaBytes > 0 {
+			var used int64
+			if err := tx.Model(&entities.Attachment{}).
+				Select("COALESCE(SUM(size), 0)").
+				Where("user_id = ?", attachment.UserID).
+				Scan(&used).Error; err != nil {
+				return fmt.Errorf("failed to calculate storage used: %v", err)
+			}
+			if used+attachment.Size > quotaBytes {
+				return fmt.Errorf("storage quota exceeded")
+			}
+		}
+
+		if err := tx.Create(attachment).Error; err != nil {
+			return fmt.Errorf("failed to create attachment: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *GormAttachmentRepository) GetAttachmentById(attachmentID uint) (*entities.Attachment, error) {
+	var attachment entities.Attachment
+	if err := r.db.First(&attachment, attachmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to fetch attachment: %v", err)
+	}
+	return &attachment, nil
+}
+
+func (r *GormAttachmentRepository) GetAttachmentsByNote(noteID uint) ([]entities.Attachment, error) {
+	var attachments []entities.Attachment
+	if err := r.db.Where("note_id = ?", noteID).
+		Order("created_at").
+		Order("attachment_id").
+		Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %v", err)
+	}
+	return attachments, nil
+}
+
+// GetStorageUsed ขนาดรวมของไฟล์แนบทั้งหมดของผู้ใช้ (byte)
+func (r *GormAttachmentRepository) GetStorageUsed(userID uint) (int64, error) {
+	var used int64
+	if err := r.db.Model(&entities.Attachment{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("user_id = ?", userID).
+		Scan(&used).Error; err != nil {
+		return 0, fmt.Errorf("failed to calculate storage used: %v", err)
+	}
+	return used, nil
+}
+
+func (r *GormAttachmentRepository) DeleteAttachment(attachmentID uint) error {
+	if err := r.db.Delete(&entities.Attachment{}, attachmentID).Error; err != nil {
+		return fmt.Errorf("failed to delete attachment: %v", err)
+	}
+	return nil
+}
+
+func (r *GormAttachmentRepository) UpdateThumbnails(attachmentID uint, status string, thumbnails []entities.AttachmentThumbnail) error {
+	result := r.db.Model(&entities.Attachment{AttachmentID: attachmentID}).
+		Select("thumbnail_status", "thumbnails").
+		Updates(&entities.Attachment{ThumbnailStatus: status, Thumbnails: thumbnails})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update thumbnails: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("attachment not found")
+	}
+	return nil
+}
+
+func (r *GormAttachmentRepository) GetPendingThumbnails() ([]entities.Attachment, error) {
+	var attachments []entities.Attachment
+	if err := r.db.Where("thumbnail_status = ?", entities.ThumbnailStatusPending).
+		Order("attachment_id").
+		Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch pending thumbnails: %v", err)
+	}
+	return attachments, nil
+}
@@ -0,0 +1,57 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormAutoTagRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAutoTagRuleRepository(db *gorm.DB) *GormAutoTagRuleRepository {
+	return &GormAutoTagRuleRepository{db: db}
+}
+
+func (r *GormAutoTagRuleRepository) CreateRule(rule *entities.AutoTagRule) error {
+	if err := r.db.Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create rule: %v", err)
+	}
+	return nil
+}
+
+func (r *GormAutoTagRuleRepository) GetRulesByUser(userID uint) ([]entities.AutoTagRule, error) {
+	var rules []entities.AutoTagRule
+	if err := r.db.Where("user_id = ?", userID).Order("rule_id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch rules: %v", err)
+	}
+	return rules, nil
+}
+
+func (r *GormAutoTagRuleRepository) GetRuleById(ruleID uint) (*entities.AutoTagRule, error) {
+	var rule entities.AutoTagRule
+	if err := r.db.First(&rule, ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("rule not found")
+		}
+		return nil, fmt.Errorf("failed to fetch rule: %v", err)
+	}
+	return &rule, nil
+}
+
+func (r *GormAutoTagRuleRepository) UpdateRule(rule *entities.AutoTagRule) error {
+	if err := r.db.Save(rule).Error; err != nil {
+		return fmt.Errorf("failed to update rule: %v", err)
+	}
+	return nil
+}
+
+func (r *GormAutoTagRuleRepository) DeleteRule(ruleID uint) error {
+	if err := r.db.Delete(&entities.AutoTagRule{}, ruleID).Error; err != nil {
+		return fmt.Errorf("failed to delete rule: %v", err)
+	}
+	return nil
+}
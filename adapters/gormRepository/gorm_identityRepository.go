@@ -0,0 +1,31 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewGormIdentityRepository(db *gorm.DB) *GormIdentityRepository {
+	return &GormIdentityRepository{db: db}
+}
+
+func (r *GormIdentityRepository) CreateIdentity(identity *entities.UserIdentity) error {
+	if err := r.db.Create(identity).Error; err != nil {
+		return fmt.Errorf("failed to link identity: %v", err)
+	}
+	return nil
+}
+
+func (r *GormIdentityRepository) GetIdentity(provider string, subject string) (*entities.UserIdentity, error) {
+	var identity entities.UserIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
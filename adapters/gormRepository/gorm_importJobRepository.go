@@ -0,0 +1,91 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormImportJobRepository struct {
+	db *gorm.DB
+}
+
+func NewGormImportJobRepository(db *gorm.DB) *GormImportJobRepository {
+	return &GormImportJobRepository{db: db}
+}
+
+var unfinishedImportStatuses = []string{entities.ImportStatusPending, entities.ImportStatusRunning}
+
+func (r *GormImportJobRepository) CreateImportJob(job *entities.ImportJob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// ล็อกแถวของผู้ใช้เพื่อให้การสั่งนำเข้าพร้อมกันตรวจงานที่ค้างอยู่ทีละรายการ
+		var user entities.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("user_id").
+			First(&user, job.UserID).Error; err != nil {
+			return fmt.Errorf("failed to lock user: %v", err)
+		}
+
+		var running int64
+		if err := tx.Model(&entities.ImportJob{}).
+			Where("user_id = ? AND status IN ?", job.UserID, unfinishedImportStatuses).
+			Count(&running).Error; err != nil {
+			return fmt.Errorf("failed to check import jobs: %v", err)
+		}
+		if running > 0 {
+			return fmt.Errorf("an import is already in progress")
+		}
+
+		if err := tx.Create(job).Error; err != nil {
+			return fmt.Errorf("failed to create import job: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *GormImportJobRepository) GetImportJobById(jobID uint) (*entities.ImportJob, error) {
+	var job entities.ImportJob
+	if err := r.db.First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("import job not found")
+		}
+		return nil, fmt.Errorf("failed to fetch import job: %v", err)
+	}
+	return &job, nil
+}
+
+func (r *GormImportJobRepository) GetImportJobsByUser(userID uint) ([]entities.ImportJob, error) {
+	var jobs []entities.ImportJob
+	if err := r.db.Where("user_id = ?", userID).
+		Order("job_id DESC").
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch import jobs: %v", err)
+	}
+	return jobs, nil
+}
+
+func (r *GormImportJobRepository) UpdateImportJob(job *entities.ImportJob) error {
+	result := r.db.Model(&entities.ImportJob{JobID: job.JobID}).
+		Select("storage_key", "status", "total", "processed", "imported", "failed", "errors", "error", "started_at", "finished_at").
+		Updates(job)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update import job: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("import job not found")
+	}
+	return nil
+}
+
+func (r *GormImportJobRepository) GetUnfinishedImportJobs() ([]entities.ImportJob, error) {
+	var jobs []entities.ImportJob
+	if err := r.db.Where("status IN ?", unfinishedImportStatuses).
+		Order("job_id").
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch unfinished import jobs: %v", err)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,104 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type GormNoteLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewGormNoteLinkRepository(db *gorm.DB) *GormNoteLinkRepository {
+	return &GormNoteLinkRepository{db: db}
+}
+
+func (r *GormNoteLinkRepository) ReplaceLinks(sourceNoteID uint, links []entities.NoteLink) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_note_id = ?", sourceNoteID).Delete(&entities.NoteLink{}).Error; err != nil {
+			return fmt.Errorf("failed to delete old links: %v", err)
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		for i := range links {
+			links[i].LinkID = 0
+			links[i].SourceNoteID = sourceNoteID
+		}
+		if err := tx.Create(&links).Error; err != nil {
+			return fmt.Errorf("failed to create links: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *GormNoteLinkRepository) GetLinksToNote(targetNoteID uint) ([]entities.NoteLink, error) {
+	var links []entities.NoteLink
+	if err := r.db.Where("target_note_id = ?", targetNoteID).
+		Order("link_id").
+		Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch links: %v", err)
+	}
+	return links, nil
+}
+
+func (r *GormNoteLinkRepository) GetBacklinks(targetNoteID uint) ([]entities.Note, error) {
+	var notes []entities.Note
+	if err := r.db.Where("deleted_at = ?", "").
+		Where("note_id IN (?)", r.db.Model(&entities.NoteLink{}).
+			Select("source_note_id").
+			Where("target_note_id = ?", targetNoteID)).
+		Order("updated_at DESC").
+		Preload("Tags", func(db *gorm.DB) *gorm.DB {
+			return db.Select("tag_id, tag_name")
+		}).
+		Preload("Reminder").
+		Preload("Event").
+		Preload("TodoItems").
+		Preload("Attachments", orderAttachments).
+		Find(&notes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch backlinks: %v", err)
+	}
+	return notes, nil
+}
+
+func (r *GormNoteLinkRepository) UpdateLinkTarget(linkID uint, targetNoteID *uint, targetTitle string) error {
+	if err := r.db.Model(&entities.NoteLink{}).
+		Where("link_id = ?", linkID).
+		Updates(map[string]interface{}{
+			"target_note_id": targetNoteID,
+			"target_title":   targetTitle,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to update link: %v", err)
+	}
+	return nil
+}
+
+func (r *GormNoteLinkRepository) ResolveLinksByTitle(userID uint, title string, targetNoteID uint) error {
+	if err := r.db.Model(&entities.NoteLink{}).
+		Where("user_id = ? AND target_note_id IS NULL AND target_title <> ? AND LOWER(target_title) = ?", userID, "", strings.ToLower(strings.TrimSpace(title))).
+		Where("source_note_id <> ?", targetNoteID).
+		Update("target_note_id", targetNoteID).Error; err != nil {
+		return fmt.Errorf("failed to resolve links: %v", err)
+	}
+	return nil
+}
+
+func (r *GormNoteLinkRepository) FindNoteIDByTitle(userID uint, title string) (*uint, error) {
+	var note entities.Note
+	err := r.db.Select("note_id").
+		Where("user_id = ? AND deleted_at = ? AND LOWER(TRIM(title)) = ?", userID, "", strings.ToLower(strings.TrimSpace(title))).
+		Order("note_id").
+		First(&note).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find note by title: %v", err)
+	}
+	return &note.NoteID, nil
+}
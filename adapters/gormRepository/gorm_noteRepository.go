@@ -3,8 +3,11 @@ package gormRepository
 import (
 	"fmt"
 	"miw/entities"
+	"miw/usecases/repository"
+	"strings"
 	"time"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormNoteRepository struct {
@@ -15,11 +18,16 @@ func NewGormNoteRepository(db *gorm.DB) *GormNoteRepository {
 	return &GormNoteRepository{db: db}
 }
 
+// orderAttachments เรียงไฟล์แนบของโน้ตตามลำดับที่อัปโหลด
+func orderAttachments(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at").Order("attachment_id")
+}
+
 func (r *GormNoteRepository) CreateNote(note *entities.Note) error {
 	// ใช้ transaction เพื่อความปลอดภัย
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// สร้าง Note
-		if err := tx.Create(note).Error; err != nil {
+		// สร้าง Note (ไฟล์แนบต้องมี blob จริง จึงไม่สร้างตาม association)
+		if err := tx.Omit("Attachments").Create(note).Error; err != nil {
 			return fmt.Errorf("failed to create note: %v", err)
 		}
 
@@ -37,15 +45,172 @@ func (r *GormNoteRepository) CreateNote(note *entities.Note) error {
 	})
 }
 
-func (r *GormNoteRepository) GetAllNoteByUserId(userID uint) ([]entities.Note, error) {
+// GetAllNoteByUserId ดึงโน้ตทั้งหมดของผู้ใช้ หรือเฉพาะในสมุดโน้ตที่ระบุ (notebookID = nil คือทุกสมุด)
+// ไม่รวมโน้ตที่เก็บเข้าคลัง และแสดงโน้ตที่ปักหมุดไว้ก่อน
+func (r *GormNoteRepository) GetAllNoteByUserId(userID uint, notebookID *uint) ([]entities.Note, error) {
 	var notes []entities.Note
-	if err := r.db.Where("user_id = ? AND deleted_at = ?", userID, "").
+	query := r.db.Where("user_id = ? AND deleted_at = ? AND archived_at = ?", userID, "", "")
+	if notebookID != nil {
+		query = query.Where("notebook_id = ?", *notebookID)
+	}
+	if err := query.
+		Order("is_pinned DESC").
+		Order("position").
+		Order("updated_at DESC").
 		Preload("Tags", func(db *gorm.DB) *gorm.DB {
 			return db.Select("tag_id, tag_name") // ไม่ดึง Notes ใน Tags
 		}).
 		Preload("Reminder").
 		Preload("Event").
 		Preload("TodoItems"). // เพิ่มการโหลด TodoItems
+		Preload("Attachments", orderAttachments).
+		Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *GormNoteRepository) GetNotesByTag(userID uint, tagID uint, includeDescendants bool) ([]entities.Note, error) {
+	tagIDs := []uint{tagID}
+	if includeDescendants {
+		var err error
+		if tagIDs, err = r.descendantTagIDs(userID, tagIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	var notes []entities.Note
+	if err := r.db.Where("user_id = ? AND deleted_at = ? AND archived_at = ?", userID, "", "").
+		Where("note_id IN (?)", r.db.Table("note_tags").Select("note_id").Where("tag_id IN ?", tagIDs)).
+		Order("is_pinned DESC").
+		Order("position").
+		Order("updated_at DESC").
+		Preload("Tags", func(db *gorm.DB) *gorm.DB {
+			return db.Select("tag_id, tag_name") // ไม่ดึง Notes ใน Tags
+		}).
+		Preload("Reminder").
+		Preload("Event").
+		Preload("TodoItems").
+		Preload("Attachments", orderAttachments).
+		Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// GetArchivedNotesByUserId ดึงโน้ตที่เก็บเข้าคลังแล้ว (ไม่รวมโน้ตในถังขยะ) เรียงตามเวลาที่เก็บล่าสุด
+func (r *GormNoteRepository) GetArchivedNotesByUserId(userID uint) ([]entities.Note, error) {
+	var notes []entities.Note
+	if err := r.db.Where("user_id = ? AND deleted_at = ? AND archived_at <> ?", userID, "", "").
+		Order("archived_at DESC").
+		Preload("Tags", func(db *gorm.DB) *gorm.DB {
+			return db.Select("tag_id, tag_name") // ไม่ดึง Notes ใน Tags
+		}).
+		Preload("Reminder").
+		Preload("Event").
+		Preload("TodoItems").
+		Preload("Attachments", orderAttachments).
+		Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// descendantTagIDs ดึง tag ที่ระบุพร้อมลูกหลานทั้งหมดด้วย recursive CTE (UNION ป้องกันการวนซ้ำไม่รู้จบ)
+func (r *GormNoteRepository) descendantTagIDs(userID uint, tagIDs []uint) ([]uint, error) {
+	var result []uint
+	if err := r.db.Raw(`WITH RECURSIVE tag_tree AS (
+			SELECT tag_id FROM tags WHERE tag_id IN ? AND user_id = ?
+			UNION
+			SELECT tags.tag_id FROM tags JOIN tag_tree ON tags.parent_tag_id = tag_tree.tag_id
+		) SELECT tag_id FROM tag_tree`, tagIDs, userID).Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SearchNotes ค้นหาโน้ตที่ยังไม่ถูกลบตามเงื่อนไขใน filter (ค่าใน filter ต้องผ่านการตรวจสอบจาก service แล้ว)
+func (r *GormNoteRepository) SearchNotes(userID uint, filter entities.NoteFilter, sortBy string, sortOrder string) ([]entities.Note, error) {
+	query := r.db.Where("user_id = ? AND deleted_at = ?", userID, "")
+	if !filter.IncludeArchived {
+		query = query.Where("archived_at = ?", "")
+	}
+
+	if filter.Query != "" {
+		// escape อักขระพิเศษของ LIKE เพื่อค้นหาแบบข้อความตรงตัว (ระบุ ESCAPE เองเพราะ SQLite ไม่มี escape character เริ่มต้น)
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(filter.Query)) + "%"
+		query = query.Where(`(LOWER(title) LIKE ? ESCAPE '\' OR LOWER(content) LIKE ? ESCAPE '\' OR note_id IN (?))`,
+			pattern, pattern,
+			r.db.Model(&entities.ToDo{}).Select("note_id").Where(`LOWER(content) LIKE ? ESCAPE '\'`, pattern))
+	}
+
+	if len(filter.TagIDs) > 0 {
+		tagIDs := filter.TagIDs
+		if filter.IncludeDescendants {
+			var err error
+			if tagIDs, err = r.descendantTagIDs(userID, tagIDs); err != nil {
+				return nil, err
+			}
+		}
+		query = query.Where("note_id IN (?)", r.db.Table("note_tags").Select("note_id").Where("tag_id IN ?", tagIDs))
+	}
+
+	if filter.Color != "" {
+		query = query.Where("LOWER(color) = ?", strings.ToLower(filter.Color))
+	}
+	if filter.MinPriority != nil {
+		query = query.Where("priority >= ?", *filter.MinPriority)
+	}
+	if filter.MaxPriority != nil {
+		query = query.Where("priority <= ?", *filter.MaxPriority)
+	}
+	if filter.IsTodo != nil {
+		query = query.Where("is_todo = ?", *filter.IsTodo)
+	}
+	if filter.IsAllDone != nil {
+		query = query.Where("is_all_done = ?", *filter.IsAllDone)
+	}
+
+	// วันที่เก็บเป็น string รูปแบบ "2006-01-02 15:04:05" จึงเปรียบเทียบแบบข้อความได้
+	if filter.CreatedFrom != "" {
+		query = query.Where("created_at >= ?", filter.CreatedFrom+" 00:00:00")
+	}
+	if filter.CreatedTo != "" {
+		query = query.Where("created_at <= ?", filter.CreatedTo+" 23:59:59")
+	}
+	if filter.UpdatedFrom != "" {
+		query = query.Where("updated_at >= ?", filter.UpdatedFrom+" 00:00:00")
+	}
+	if filter.UpdatedTo != "" {
+		query = query.Where("updated_at <= ?", filter.UpdatedTo+" 23:59:59")
+	}
+
+	orderColumn := map[string]string{
+		"updated_at": "updated_at",
+		"created_at": "created_at",
+		"title":      "LOWER(title)",
+		"priority":   "priority",
+		"position":   "position",
+	}[sortBy]
+	if orderColumn == "" {
+		orderColumn = "updated_at"
+	}
+	direction := "DESC"
+	if sortOrder == "asc" {
+		direction = "ASC"
+	}
+
+	var notes []entities.Note
+	if err := query.
+		Order(orderColumn + " " + direction).
+		Order("note_id " + direction).
+		Preload("Tags", func(db *gorm.DB) *gorm.DB {
+			return db.Select("tag_id, tag_name") // ไม่ดึง Notes ใน Tags
+		}).
+		Preload("Reminder").
+		Preload("Event").
+		Preload("TodoItems").
+		Preload("Attachments", orderAttachments).
 		Find(&notes).Error; err != nil {
 		return nil, err
 	}
@@ -61,6 +226,7 @@ func (r *GormNoteRepository) GetNoteById(noteID uint) (*entities.Note, error) {
 		Preload("Reminder").
 		Preload("Event").
 		Preload("TodoItems"). // เพิ่มการโหลด TodoItems
+		Preload("Attachments", orderAttachments).
 		First(&note, noteID).Error; err != nil {
 		return nil, err
 	}
@@ -153,6 +319,103 @@ func (r *GormNoteRepository) UpdateNoteStatus(noteID uint, userID uint, isTodo *
 	return r.db.Model(&entities.Note{}).Where("note_id = ? AND user_id = ?", noteID, userID).Updates(updates).Error
 }
 
+func (r *GormNoteRepository) UpdateNotePinned(noteID uint, userID uint, isPinned bool) error {
+	result := r.db.Model(&entities.Note{}).
+		Where("note_id = ? AND user_id = ? AND deleted_at = ?", noteID, userID, "").
+		Updates(map[string]interface{}{
+			"is_pinned":  isPinned,
+			"updated_at": time.Now().Format("2006-01-02 15:04:05"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	return nil
+}
+
+// UpdateNoteArchived เก็บโน้ตเข้าคลัง (archivedAt = เวลา) หรือนำออกจากคลัง (archivedAt = "")
+// โน้ตที่ถูกเก็บเข้าคลังจะถูกเลิกปักหมุด
+func (r *GormNoteRepository) UpdateNoteArchived(noteID uint, userID uint, archivedAt string) error {
+	updates := map[string]interface{}{
+		"archived_at": archivedAt,
+		"updated_at":  time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if archivedAt != "" {
+		updates["is_pinned"] = false
+	}
+
+	result := r.db.Model(&entities.Note{}).
+		Where("note_id = ? AND user_id = ? AND deleted_at = ?", noteID, userID, "").
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	return nil
+}
+
+// GetNotePositions ดึงเฉพาะ note_id และ position ของโน้ตในสมุดโน้ตหนึ่ง เรียงตามลำดับที่แสดงผล
+func (r *GormNoteRepository) GetNotePositions(userID uint, notebookID *uint) ([]entities.Note, error) {
+	var notes []entities.Note
+	query := r.db.Select("note_id, notebook_id, position").Where("user_id = ?", userID)
+	if notebookID != nil {
+		query = query.Where("notebook_id = ?", *notebookID)
+	} else {
+		query = query.Where("notebook_id IS NULL")
+	}
+	if err := query.
+		Order("position").
+		Order("updated_at DESC").
+		Order("note_id").
+		Find(&notes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch note positions: %v", err)
+	}
+	return notes, nil
+}
+
+// LockNotePositions ล็อกแถวของผู้ใช้ด้วย SELECT ... FOR UPDATE เพื่อให้การอ่านคีย์แล้วเขียนโน้ตใหม่หรือคีย์ใหม่
+// จากหลาย request (รวมถึงงานนำเข้า) ทำทีละรายการ และไม่ได้คีย์ซ้ำกัน
+func (r *GormNoteRepository) LockNotePositions(userID uint) error {
+	var user entities.User
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("user_id").
+		Where("user_id = ?", userID).
+		First(&user).Error; err != nil {
+		return fmt.Errorf("failed to lock note positions: %v", err)
+	}
+	return nil
+}
+
+func (r *GormNoteRepository) UpdateNotePosition(noteID uint, userID uint, position string) error {
+	result := r.db.Model(&entities.Note{}).
+		Where("note_id = ? AND user_id = ?", noteID, userID).
+		Update("position", position)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	return nil
+}
+
+// UpdateNotePositions บันทึกคีย์ตำแหน่งใหม่ของโน้ตหลายรายการภายใน transaction เดียว (ใช้ตอน rebalance)
+func (r *GormNoteRepository) UpdateNotePositions(userID uint, positions map[uint]string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for noteID, position := range positions {
+			if err := tx.Model(&entities.Note{}).
+				Where("note_id = ? AND user_id = ?", noteID, userID).
+				Update("position", position).Error; err != nil {
+				return fmt.Errorf("failed to update position of note %d: %v", noteID, err)
+			}
+		}
+		return nil
+	})
+}
 
 func (r *GormNoteRepository) DeleteNoteById(noteID uint) error {
 	// ใช้เวลาปัจจุบันในรูปแบบ string
@@ -191,6 +454,53 @@ func (r *GormNoteRepository) RestoreNoteById(noteID uint) error {
 	return nil
 }
 
+func (r *GormNoteRepository) PurgeNote(noteID uint) ([]string, error) {
+	var storageKeys []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var attachments []entities.Attachment
+		if err := tx.Where("note_id = ?", noteID).Find(&attachments).Error; err != nil {
+			return fmt.Errorf("failed to fetch attachments: %v", err)
+		}
+		for i := range attachments {
+			storageKeys = append(storageKeys, attachments[i].BlobKeys()...)
+		}
+
+		if err := tx.Exec("DELETE FROM note_tags WHERE note_id = ?", noteID).Error; err != nil {
+			return fmt.Errorf("failed to delete note tags: %v", err)
+		}
+		if err := tx.Where("note_id = ?", noteID).Delete(&entities.ToDo{}).Error; err != nil {
+			return fmt.Errorf("failed to delete todo items: %v", err)
+		}
+		if err := tx.Where("note_id = ?", noteID).Delete(&entities.Reminder{}).Error; err != nil {
+			return fmt.Errorf("failed to delete reminders: %v", err)
+		}
+		if err := tx.Where("note_id = ?", noteID).Delete(&entities.Event{}).Error; err != nil {
+			return fmt.Errorf("failed to delete event: %v", err)
+		}
+		if err := tx.Where("note_id = ?", noteID).Delete(&entities.ShareNote{}).Error; err != nil {
+			return fmt.Errorf("failed to revoke shares: %v", err)
+		}
+		if err := tx.Where("note_id = ?", noteID).Delete(&entities.Attachment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete attachments: %v", err)
+		}
+		// ลิงก์จากโน้ตนี้ถูกลบ ส่วนลิงก์ที่ชี้มายังโน้ตนี้กลายเป็นลิงก์ที่ยังไม่มีปลายทาง
+		if err := tx.Where("source_note_id = ?", noteID).Delete(&entities.NoteLink{}).Error; err != nil {
+			return fmt.Errorf("failed to delete note links: %v", err)
+		}
+		if err := tx.Model(&entities.NoteLink{}).Where("target_note_id = ?", noteID).Update("target_note_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to unlink note: %v", err)
+		}
+		if err := tx.Delete(&entities.Note{}, noteID).Error; err != nil {
+			return fmt.Errorf("failed to delete note: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storageKeys, nil
+}
+
 func (r *GormNoteRepository) AddTagToNote(noteID uint, tagID uint, userID uint) error {
 	// ตรวจสอบว่า Note เป็นของ User หรือไม่
 	var note entities.Note
@@ -240,6 +550,7 @@ func (r *GormNoteRepository) GetNoteByIdAndUser(noteID uint, userID uint) (*enti
 		Preload("Reminder").
 		Preload("Event").
 		Preload("TodoItems").
+		Preload("Attachments", orderAttachments).
 		First(&note).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("note not found or does not belong to the user")
@@ -249,6 +560,95 @@ func (r *GormNoteRepository) GetNoteByIdAndUser(noteID uint, userID uint) (*enti
 	return &note, nil
 }
 
+// UpdateNoteFields อัปเดตหลายคอลัมน์ของโน้ตในคำสั่งเดียว
+func (r *GormNoteRepository) UpdateNoteFields(noteID uint, userID uint, updates map[string]interface{}) error {
+	result := r.db.Model(&entities.Note{}).
+		Where("note_id = ? AND user_id = ?", noteID, userID).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update note: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	return nil
+}
 
+// ReplaceTodoItems ลบ TodoItems เดิมทั้งหมดแล้วเพิ่มรายการใหม่ (รายการว่าง = ลบทั้งหมด)
+func (r *GormNoteRepository) ReplaceTodoItems(noteID uint, todoItems []entities.ToDo) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("note_id = ?", noteID).Delete(&entities.ToDo{}).Error; err != nil {
+			return fmt.Errorf("failed to delete old todo items: %v", err)
+		}
+		if len(todoItems) == 0 {
+			return nil
+		}
 
+		// ตั้งค่า NoteID และรีเซ็ต ID เป็น 0 สำหรับการเพิ่มใหม่
+		for i := range todoItems {
+			todoItems[i].ID = 0
+			todoItems[i].NoteID = noteID
+		}
+		if err := tx.Create(&todoItems).Error; err != nil {
+			return fmt.Errorf("failed to create new todo items: %v", err)
+		}
+		return nil
+	})
+}
 
+// ReplaceNoteTags แทนที่แท็กทั้งหมดของโน้ต แท็กทุกตัวต้องเป็นของ User คนเดียวกัน
+func (r *GormNoteRepository) ReplaceNoteTags(noteID uint, userID uint, tagIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var note entities.Note
+		if err := tx.Where("note_id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+			return fmt.Errorf("note not found or does not belong to the user")
+		}
+
+		var tags []entities.Tag
+		if len(tagIDs) > 0 {
+			if err := tx.Where("tag_id IN ? AND user_id = ?", tagIDs, userID).Find(&tags).Error; err != nil {
+				return fmt.Errorf("failed to fetch tags: %v", err)
+			}
+			if len(tags) != len(tagIDs) {
+				return fmt.Errorf("tag not found or does not belong to the user")
+			}
+		}
+
+		if err := tx.Model(&note).Association("Tags").Replace(tags); err != nil {
+			return fmt.Errorf("failed to replace note tags: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *GormNoteRepository) FindNotesInBatches(userID uint, batchSize int, fn func(notes []entities.Note) error) error {
+	var notes []entities.Note
+	var fnErr error
+	result := r.db.Where("user_id = ? AND deleted_at = ?", userID, "").
+		Preload("Tags", func(db *gorm.DB) *gorm.DB {
+			return db.Select("tag_id, tag_name")
+		}).
+		Preload("Reminder").
+		Preload("Event").
+		Preload("TodoItems", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		FindInBatches(&notes, batchSize, func(tx *gorm.DB, batch int) error {
+			fnErr = fn(notes)
+			return fnErr
+		})
+	// error จาก fn (เช่น เขียนไฟล์ไม่สำเร็จ) ส่งกลับตามเดิม
+	if fnErr != nil {
+		return fnErr
+	}
+	if result.Error != nil {
+		return fmt.Errorf("failed to fetch notes: %v", result.Error)
+	}
+	return nil
+}
+
+func (r *GormNoteRepository) WithTransaction(fn func(repo repository.NoteRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&GormNoteRepository{db: tx})
+	})
+}
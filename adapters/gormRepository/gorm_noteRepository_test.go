@@ -0,0 +1,90 @@
+package gormRepository
+
+import (
+	"encoding/json"
+	"fmt"
+	"miw/entities"
+	"sort"
+	"testing"
+)
+
+// TestCreateNoteIgnoresAttachments ไฟล์แนบที่ส่งมากับ body ของโน้ตต้องไม่ถูกสร้าง (ไม่มี blob จริงและข้ามโควตาได้)
+func TestCreateNoteIgnoresAttachments(t *testing.T) {
+	db := newTestDB(t, &entities.Note{}, &entities.ToDo{}, &entities.Tag{}, &entities.Reminder{}, &entities.Event{}, &entities.Attachment{})
+	repo := NewGormNoteRepository(db)
+
+	for i := 0; i < 2; i++ {
+		note := new(entities.Note)
+		body := `{"title":"x","content":"y","attachments":[{"user_id":2,"size":-1000000000}]}`
+		if err := json.Unmarshal([]byte(body), note); err != nil {
+			t.Fatal(err)
+		}
+		note.UserID = 1
+		// จำลองกรณีที่ service ไม่ได้ล้างค่า ชั้น repository ต้องไม่สร้างไฟล์แนบเอง
+		note.Attachments = []entities.Attachment{{UserID: 2, Size: -1000000000}}
+		if err := repo.CreateNote(note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count int64
+	db.Model(&entities.Attachment{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("CreateNote inserted %d attachment rows", count)
+	}
+}
+
+func TestNoteJSONIgnoresAttachments(t *testing.T) {
+	var note entities.Note
+	if err := json.Unmarshal([]byte(`{"attachments":[{"size":1}]}`), &note); err != nil {
+		t.Fatal(err)
+	}
+	if len(note.Attachments) != 0 {
+		t.Fatalf("attachments were bound from JSON: %+v", note.Attachments)
+	}
+}
+
+func TestSearchNotes(t *testing.T) {
+	db := newTestDB(t, &entities.Note{}, &entities.ToDo{}, &entities.Tag{}, &entities.Reminder{}, &entities.Event{}, &entities.Attachment{})
+	repo := NewGormNoteRepository(db)
+	notes := []entities.Note{
+		{NoteID: 1, UserID: 1, Title: "100% done", CreatedAt: "2024-03-01 00:00:00", UpdatedAt: "2024-03-01 00:00:00"},
+		{NoteID: 2, UserID: 1, Title: "1000 done", CreatedAt: "2024-03-01 23:59:59", UpdatedAt: "2024-03-05 12:00:00"},
+		{NoteID: 3, UserID: 1, Title: "file_name", CreatedAt: "2024-03-02 00:00:00", UpdatedAt: "2024-03-02 00:00:00"},
+		{NoteID: 4, UserID: 1, Title: "filename", CreatedAt: "2024-02-29 23:59:59", UpdatedAt: "2024-02-29 23:59:59"},
+		{NoteID: 5, UserID: 1, Title: `C:\temp`, CreatedAt: "2024-03-03 00:00:00", UpdatedAt: "2024-03-03 00:00:00"},
+	}
+	if err := db.Create(&notes).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter entities.NoteFilter
+		want   []uint
+	}{
+		{"percent is literal", entities.NoteFilter{Query: "100%"}, []uint{1}},
+		{"underscore is literal", entities.NoteFilter{Query: "file_"}, []uint{3}},
+		{"backslash is literal", entities.NoteFilter{Query: `c:\t`}, []uint{5}},
+		{"created on one day", entities.NoteFilter{CreatedFrom: "2024-03-01", CreatedTo: "2024-03-01"}, []uint{1, 2}},
+		{"created from", entities.NoteFilter{CreatedFrom: "2024-03-02"}, []uint{3, 5}},
+		{"created to", entities.NoteFilter{CreatedTo: "2024-02-29"}, []uint{4}},
+		{"updated range", entities.NoteFilter{UpdatedFrom: "2024-03-02", UpdatedTo: "2024-03-05"}, []uint{2, 3, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := repo.SearchNotes(1, tt.filter, "created_at", "asc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []uint
+			for _, note := range found {
+				ids = append(ids, note.NoteID)
+			}
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Fatalf("notes = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
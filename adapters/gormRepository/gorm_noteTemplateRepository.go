@@ -0,0 +1,59 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormNoteTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormNoteTemplateRepository(db *gorm.DB) *GormNoteTemplateRepository {
+	return &GormNoteTemplateRepository{db: db}
+}
+
+func (r *GormNoteTemplateRepository) CreateTemplate(template *entities.NoteTemplate) error {
+	if err := r.db.Create(template).Error; err != nil {
+		return fmt.Errorf("failed to create template: %v", err)
+	}
+	return nil
+}
+
+func (r *GormNoteTemplateRepository) GetTemplatesByUser(userID uint) ([]entities.NoteTemplate, error) {
+	var templates []entities.NoteTemplate
+	if err := r.db.Where("user_id = ?", userID).
+		Order("LOWER(name)").
+		Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch templates: %v", err)
+	}
+	return templates, nil
+}
+
+func (r *GormNoteTemplateRepository) GetTemplateById(templateID uint) (*entities.NoteTemplate, error) {
+	var template entities.NoteTemplate
+	if err := r.db.First(&template, templateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("template not found")
+		}
+		return nil, fmt.Errorf("failed to fetch template: %v", err)
+	}
+	return &template, nil
+}
+
+func (r *GormNoteTemplateRepository) UpdateTemplate(template *entities.NoteTemplate) error {
+	if err := r.db.Save(template).Error; err != nil {
+		return fmt.Errorf("failed to update template: %v", err)
+	}
+	return nil
+}
+
+func (r *GormNoteTemplateRepository) DeleteTemplate(templateID uint) error {
+	if err := r.db.Delete(&entities.NoteTemplate{}, templateID).Error; err != nil {
+		return fmt.Errorf("failed to delete template: %v", err)
+	}
+	return nil
+}
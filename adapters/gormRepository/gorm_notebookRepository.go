@@ -0,0 +1,132 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GormNotebookRepository struct {
+	db *gorm.DB
+}
+
+func NewGormNotebookRepository(db *gorm.DB) *GormNotebookRepository {
+	return &GormNotebookRepository{db: db}
+}
+
+func (r *GormNotebookRepository) CreateNotebook(notebook *entities.Notebook) error {
+	if err := r.db.Create(notebook).Error; err != nil {
+		return fmt.Errorf("failed to create notebook: %v", err)
+	}
+	return nil
+}
+
+func (r *GormNotebookRepository) GetNotebooksByUser(userID uint) ([]entities.Notebook, error) {
+	var notebooks []entities.Notebook
+	// นับจำนวนโน้ตในสมุด (ไม่นับโน้ตในถังขยะ)
+	if err := r.db.Model(&entities.Notebook{}).
+		Select("notebooks.*, COUNT(notes.note_id) AS note_count").
+		Joins("LEFT JOIN notes ON notes.notebook_id = notebooks.notebook_id AND notes.deleted_at = ?", "").
+		Where("notebooks.user_id = ?", userID).
+		Group("notebooks.notebook_id").
+		Order("notebooks.position").
+		Order("LOWER(notebooks.name)").
+		Find(&notebooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notebooks: %v", err)
+	}
+	return notebooks, nil
+}
+
+func (r *GormNotebookRepository) GetNotebookById(notebookID uint) (*entities.Notebook, error) {
+	var notebook entities.Notebook
+	if err := r.db.First(&notebook, notebookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("notebook not found")
+		}
+		return nil, fmt.Errorf("failed to fetch notebook: %v", err)
+	}
+	return &notebook, nil
+}
+
+// GetDefaultNotebook ดึงสมุด Inbox ของผู้ใช้ ถ้ายังไม่มี (บัญชีที่สร้างก่อนมีสมุดโน้ต) จะสร้างให้
+func (r *GormNotebookRepository) GetDefaultNotebook(userID uint) (*entities.Notebook, error) {
+	var notebook entities.Notebook
+	err := r.db.Where("user_id = ? AND is_default = ?", userID, true).First(&notebook).Error
+	if err == nil {
+		return &notebook, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch default notebook: %v", err)
+	}
+
+	inbox := newDefaultNotebook(userID)
+	if err := r.db.Create(inbox).Error; err != nil {
+		return nil, fmt.Errorf("failed to create default notebook: %v", err)
+	}
+	return inbox, nil
+}
+
+func (r *GormNotebookRepository) UpdateNotebook(notebook *entities.Notebook) error {
+	if err := r.db.Save(notebook).Error; err != nil {
+		return fmt.Errorf("failed to update notebook: %v", err)
+	}
+	return nil
+}
+
+// DeleteNotebook ลบสมุดโน้ต ย้ายโน้ตในสมุดไปยัง moveNotesTo และย้ายสมุดลูกขึ้นไปอยู่ใต้สมุดแม่
+func (r *GormNotebookRepository) DeleteNotebook(notebookID uint, userID uint, moveNotesTo uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var notebook entities.Notebook
+		if err := tx.Where("notebook_id = ? AND user_id = ?", notebookID, userID).First(&notebook).Error; err != nil {
+			return fmt.Errorf("notebook not found")
+		}
+
+		// โน้ตที่ย้ายไม่มีคีย์ตำแหน่งในสมุดปลายทาง จะอยู่บนสุดจนกว่าจะ rebalance
+		if err := tx.Model(&entities.Note{}).
+			Where("notebook_id = ? AND user_id = ?", notebookID, userID).
+			Updates(map[string]interface{}{"notebook_id": moveNotesTo, "position": ""}).Error; err != nil {
+			return fmt.Errorf("failed to move notes: %v", err)
+		}
+
+		if err := tx.Model(&entities.Notebook{}).
+			Where("parent_notebook_id = ? AND user_id = ?", notebookID, userID).
+			Update("parent_notebook_id", notebook.ParentNotebookID).Error; err != nil {
+			return fmt.Errorf("failed to reparent child notebooks: %v", err)
+		}
+
+		if err := tx.Delete(&notebook).Error; err != nil {
+			return fmt.Errorf("failed to delete notebook: %v", err)
+		}
+		return nil
+	})
+}
+
+// MoveNotes ย้ายโน้ตหลายรายการของผู้ใช้ไปยังสมุดโน้ต (nil = ไม่อยู่ในสมุดใด) คืนจำนวนโน้ตที่ถูกย้าย
+// โน้ตที่เปลี่ยนสมุดจะล้างคีย์ตำแหน่งเพื่อไปอยู่บนสุดของสมุดปลายทาง
+func (r *GormNotebookRepository) MoveNotes(userID uint, noteIDs []uint, notebookID *uint) (int64, error) {
+	result := r.db.Model(&entities.Note{}).
+		Where("note_id IN ? AND user_id = ?", noteIDs, userID).
+		Updates(map[string]interface{}{
+			"notebook_id": notebookID,
+			"position":    gorm.Expr("CASE WHEN notebook_id IS NOT DISTINCT FROM ? THEN position ELSE '' END", notebookID),
+			"updated_at":  time.Now().Format("2006-01-02 15:04:05"),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to move notes: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func newDefaultNotebook(userID uint) *entities.Notebook {
+	now := time.Now().Format("2006-01-02 15:04:05")
+	return &entities.Notebook{
+		UserID:    userID,
+		Name:      entities.DefaultNotebookName,
+		IsDefault: true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -0,0 +1,57 @@
+package gormRepository
+
+import (
+	"miw/entities"
+	"testing"
+)
+
+func TestDeleteNotebookReparentsChildren(t *testing.T) {
+	db := newTestDB(t, &entities.Notebook{}, &entities.Note{})
+	repo := NewGormNotebookRepository(db)
+	work := uint(2)
+	projects := uint(3)
+	notebooks := []entities.Notebook{
+		{NotebookID: 1, UserID: 1, Name: "Inbox", IsDefault: true},
+		{NotebookID: 2, UserID: 1, Name: "Work"},
+		{NotebookID: 3, UserID: 1, Name: "Projects", ParentNotebookID: &work},
+		{NotebookID: 4, UserID: 1, Name: "Archive", ParentNotebookID: &projects},
+		{NotebookID: 5, UserID: 1, Name: "Drafts", ParentNotebookID: &projects},
+	}
+	if err := db.Create(&notebooks).Error; err != nil {
+		t.Fatal(err)
+	}
+	notes := []entities.Note{
+		{NoteID: 1, UserID: 1, NotebookID: &projects, Position: "a0"},
+		{NoteID: 2, UserID: 1, NotebookID: &work, Position: "a0"},
+	}
+	if err := db.Create(&notes).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.DeleteNotebook(3, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetNotebookById(3); err == nil {
+		t.Fatal("notebook 3 still exists")
+	}
+	for _, id := range []uint{4, 5} {
+		child, err := repo.GetNotebookById(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if child.ParentNotebookID == nil || *child.ParentNotebookID != work {
+			t.Fatalf("notebook %d parent = %v, want %d", id, child.ParentNotebookID, work)
+		}
+	}
+
+	var moved, untouched entities.Note
+	db.First(&moved, 1)
+	db.First(&untouched, 2)
+	if moved.NotebookID == nil || *moved.NotebookID != 1 || moved.Position != "" {
+		t.Fatalf("note 1 = notebook %v position %q, want Inbox with no position", moved.NotebookID, moved.Position)
+	}
+	if untouched.NotebookID == nil || *untouched.NotebookID != work || untouched.Position != "a0" {
+		t.Fatalf("note 2 was moved: notebook %v position %q", untouched.NotebookID, untouched.Position)
+	}
+}
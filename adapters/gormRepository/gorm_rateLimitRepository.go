@@ -0,0 +1,103 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormRateLimitRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRateLimitRepository(db *gorm.DB) *GormRateLimitRepository {
+	return &GormRateLimitRepository{db: db}
+}
+
+func (r *GormRateLimitRepository) GetCounter(key string) (*entities.RateLimitCounter, error) {
+	var counter entities.RateLimitCounter
+	if err := r.db.Where("key = ?", key).First(&counter).Error; err != nil {
+		// ไม่พบตัวนับ ถือว่ายังไม่เคยถูกเรียก
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch rate limit counter: %v", err)
+	}
+	return &counter, nil
+}
+
+func (r *GormRateLimitRepository) Increment(key string, window time.Duration, lockoutDecay time.Duration) (*entities.RateLimitCounter, error) {
+	var counter entities.RateLimitCounter
+
+	// ใช้ transaction + SELECT ... FOR UPDATE เพื่อป้องกันการนับซ้อนจากหลาย request พร้อมกัน
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// สร้างแถวไว้ก่อนถ้ายังไม่มี
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&entities.RateLimitCounter{Key: key, UpdatedAt: now.Format("2006-01-02 15:04:05")}).Error; err != nil {
+			return fmt.Errorf("failed to create rate limit counter: %v", err)
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&counter).Error; err != nil {
+			return fmt.Errorf("failed to lock rate limit counter: %v", err)
+		}
+
+		// เริ่มหน้าต่างเวลาใหม่ถ้าหน้าต่างเดิมหมดอายุแล้ว
+		windowStart, err := time.ParseInLocation("2006-01-02 15:04:05", counter.WindowStart, time.Local)
+		if err != nil || now.Sub(windowStart) > window {
+			counter.Count = 0
+			counter.WindowStart = now.Format("2006-01-02 15:04:05")
+		}
+
+		// ล้างประวัติการล็อกเมื่อเงียบไปนานพอ เพื่อไม่ให้การล็อกสะสมไปตลอด
+		if lockedUntil, err := time.ParseInLocation("2006-01-02 15:04:05", counter.LockedUntil, time.Local); err == nil && now.Sub(lockedUntil) > lockoutDecay {
+			counter.LockoutCount = 0
+			counter.LockedUntil = ""
+		}
+
+		counter.Count++
+		counter.UpdatedAt = now.Format("2006-01-02 15:04:05")
+
+		return tx.Save(&counter).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &counter, nil
+}
+
+func (r *GormRateLimitRepository) Lock(key string, until time.Time) error {
+	return r.db.Model(&entities.RateLimitCounter{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{
+			"count":         0,
+			"window_start":  "",
+			"locked_until":  until.Format("2006-01-02 15:04:05"),
+			"lockout_count": gorm.Expr("lockout_count + 1"),
+			"updated_at":    time.Now().Format("2006-01-02 15:04:05"),
+		}).Error
+}
+
+func (r *GormRateLimitRepository) Reset(key string) error {
+	return r.db.Where("key = ?", key).Delete(&entities.RateLimitCounter{}).Error
+}
+
+type GormLockoutEventRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLockoutEventRepository(db *gorm.DB) *GormLockoutEventRepository {
+	return &GormLockoutEventRepository{db: db}
+}
+
+func (r *GormLockoutEventRepository) CreateLockoutEvent(event *entities.LockoutEvent) error {
+	if err := r.db.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to record lockout event: %v", err)
+	}
+	return nil
+}
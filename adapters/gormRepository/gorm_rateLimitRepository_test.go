@@ -0,0 +1,54 @@
+package gormRepository
+
+import (
+	"miw/entities"
+	"testing"
+	"time"
+)
+
+func TestGormRateLimitRepository(t *testing.T) {
+	db := newTestDB(t, &entities.RateLimitCounter{})
+	repo := NewGormRateLimitRepository(db)
+
+	if counter, err := repo.GetCounter("login:ip:1"); err != nil || counter != nil {
+		t.Fatalf("GetCounter before any hit = %+v, %v", counter, err)
+	}
+	for i := 1; i <= 3; i++ {
+		counter, err := repo.Increment("login:ip:1", time.Minute, time.Hour)
+		if err != nil || counter.Count != i {
+			t.Fatalf("Increment #%d = %+v, %v", i, counter, err)
+		}
+	}
+
+	// หน้าต่างเดิมหมดอายุ ตัวนับเริ่มใหม่
+	db.Model(&entities.RateLimitCounter{}).Where("key = ?", "login:ip:1").
+		Update("window_start", time.Now().Add(-2*time.Minute).Format("2006-01-02 15:04:05"))
+	if counter, _ := repo.Increment("login:ip:1", time.Minute, time.Hour); counter.Count != 1 {
+		t.Fatalf("count after the window expired = %d, want 1", counter.Count)
+	}
+
+	// Lock ล้างตัวนับและนับจำนวนครั้งที่ถูกล็อก
+	until := time.Now().Add(time.Minute)
+	repo.Lock("login:ip:1", until)
+	repo.Lock("login:ip:1", until)
+	counter, _ := repo.GetCounter("login:ip:1")
+	if counter.Count != 0 || counter.LockoutCount != 2 || counter.LockedUntil != until.Format("2006-01-02 15:04:05") {
+		t.Fatalf("counter after Lock = %+v", counter)
+	}
+	if counter, _ := repo.Increment("login:ip:1", time.Minute, time.Hour); counter.LockoutCount != 2 {
+		t.Fatalf("lockout count during lockout = %d, want 2", counter.LockoutCount)
+	}
+
+	// การล็อกครั้งล่าสุดหมดไปนานกว่า lockoutDecay แล้ว
+	repo.Lock("login:ip:1", time.Now().Add(-2*time.Hour))
+	if counter, _ := repo.Increment("login:ip:1", time.Minute, time.Hour); counter.LockoutCount != 0 || counter.LockedUntil != "" {
+		t.Fatalf("counter after a quiet period = %+v", counter)
+	}
+
+	if err := repo.Reset("login:ip:1"); err != nil {
+		t.Fatal(err)
+	}
+	if counter, _ := repo.GetCounter("login:ip:1"); counter != nil {
+		t.Fatalf("counter after Reset = %+v", counter)
+	}
+}
@@ -0,0 +1,61 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormSavedSearchRepository struct {
+	db *gorm.DB
+}
+
+func NewGormSavedSearchRepository(db *gorm.DB) *GormSavedSearchRepository {
+	return &GormSavedSearchRepository{db: db}
+}
+
+func (r *GormSavedSearchRepository) CreateSavedSearch(search *entities.SavedSearch) error {
+	if err := r.db.Create(search).Error; err != nil {
+		return fmt.Errorf("failed to create saved search: %v", err)
+	}
+	return nil
+}
+
+// GetSavedSearchesByUser เรียงรายการที่ปักหมุดไว้ก่อน แล้วตามชื่อ
+func (r *GormSavedSearchRepository) GetSavedSearchesByUser(userID uint) ([]entities.SavedSearch, error) {
+	var searches []entities.SavedSearch
+	if err := r.db.Where("user_id = ?", userID).
+		Order("is_pinned DESC").
+		Order("LOWER(name)").
+		Find(&searches).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch saved searches: %v", err)
+	}
+	return searches, nil
+}
+
+func (r *GormSavedSearchRepository) GetSavedSearchById(searchID uint) (*entities.SavedSearch, error) {
+	var search entities.SavedSearch
+	if err := r.db.First(&search, searchID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("saved search not found")
+		}
+		return nil, fmt.Errorf("failed to fetch saved search: %v", err)
+	}
+	return &search, nil
+}
+
+func (r *GormSavedSearchRepository) UpdateSavedSearch(search *entities.SavedSearch) error {
+	if err := r.db.Save(search).Error; err != nil {
+		return fmt.Errorf("failed to update saved search: %v", err)
+	}
+	return nil
+}
+
+func (r *GormSavedSearchRepository) DeleteSavedSearch(searchID uint) error {
+	if err := r.db.Delete(&entities.SavedSearch{}, searchID).Error; err != nil {
+		return fmt.Errorf("failed to delete saved search: %v", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"miw/entities"
 	"gorm.io/gorm"
 	"fmt"
+	"sort"
 )
 
 type GormTagRepository struct {
@@ -31,7 +32,14 @@ func (r *GormTagRepository) CreateTag(tag *entities.Tag) error {
 
 func (r *GormTagRepository) GetTagsByUser(userID uint) ([]entities.Tag, error) {
     var tags []entities.Tag
-    if err := r.db.Where("user_id = ?", userID).Find(&tags).Error; err != nil {
+    // นับจำนวนโน้ตที่ใช้แท็กนี้ (ไม่นับโน้ตในถังขยะ)
+    if err := r.db.Model(&entities.Tag{}).
+        Select("tags.*, COUNT(notes.note_id) AS note_count").
+        Joins("LEFT JOIN note_tags ON note_tags.tag_id = tags.tag_id").
+        Joins("LEFT JOIN notes ON notes.note_id = note_tags.note_id AND notes.deleted_at = ?", "").
+        Where("tags.user_id = ?", userID).
+        Group("tags.tag_id").
+        Find(&tags).Error; err != nil {
         return nil, fmt.Errorf("failed to fetch tags: %v", err)
     }
     return tags, nil
@@ -74,11 +82,46 @@ func (r *GormTagRepository) DeleteTag(tagID, userID uint) error {
         return fmt.Errorf("error finding tag: %v", err)
     }
 
-    // ลบแท็ก
-    if err := r.db.Delete(&tag).Error; err != nil {
-        return fmt.Errorf("failed to delete tag: %v", err)
-    }
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        // ย้ายแท็กลูกขึ้นไปอยู่ใต้แท็กแม่ของแท็กที่ถูกลบ
+        if err := tx.Model(&entities.Tag{}).
+            Where("parent_tag_id = ? AND user_id = ?", tag.TagID, userID).
+            Update("parent_tag_id", tag.ParentTagID).Error; err != nil {
+            return fmt.Errorf("failed to reparent child tags: %v", err)
+        }
+
+        // ลบกฎติดแท็กอัตโนมัติที่ชี้มาที่แท็กนี้
+        if err := tx.Where("tag_id = ?", tag.TagID).Delete(&entities.AutoTagRule{}).Error; err != nil {
+            return fmt.Errorf("failed to delete auto-tag rules: %v", err)
+        }
+
+        // เอาแท็กนี้ออกจากเงื่อนไขของการค้นหาที่บันทึกไว้และแม่แบบโน้ต
+        if err := replaceTagInSavedSearches(tx, userID, []uint{tag.TagID}, 0); err != nil {
+            return err
+        }
+        if err := replaceTagInTemplates(tx, userID, []uint{tag.TagID}, 0); err != nil {
+            return err
+        }
+
+        // ลบแท็ก
+        if err := tx.Delete(&tag).Error; err != nil {
+            return fmt.Errorf("failed to delete tag: %v", err)
+        }
+
+        return nil
+    })
+}
 
+func (r *GormTagRepository) UpdateTagParent(tagID, userID uint, parentTagID *uint) error {
+    result := r.db.Model(&entities.Tag{}).
+        Where("tag_id = ? AND user_id = ?", tagID, userID).
+        Update("parent_tag_id", parentTagID)
+    if result.Error != nil {
+        return fmt.Errorf("failed to move tag: %v", result.Error)
+    }
+    if result.RowsAffected == 0 {
+        return fmt.Errorf("tag not found or does not belong to this user")
+    }
     return nil
 }
 
@@ -92,3 +135,198 @@ func (r *GormTagRepository) GetTagById(tagID uint) (*entities.Tag, error) {
     return &tag, nil
 }
 
+func (r *GormTagRepository) UpdateTagAppearance(tagID, userID uint, color *string, icon *string) error {
+    updates := map[string]interface{}{}
+    if color != nil {
+        updates["color"] = *color
+    }
+    if icon != nil {
+        updates["icon"] = *icon
+    }
+    if len(updates) == 0 {
+        return nil
+    }
+
+    result := r.db.Model(&entities.Tag{}).Where("tag_id = ? AND user_id = ?", tagID, userID).Updates(updates)
+    if result.Error != nil {
+        return fmt.Errorf("failed to update tag: %v", result.Error)
+    }
+    if result.RowsAffected == 0 {
+        return fmt.Errorf("tag not found or does not belong to this user")
+    }
+    return nil
+}
+
+// MergeTags ย้ายโน้ตทั้งหมดจากแท็กต้นทางไปยังแท็กปลายทาง แล้วลบแท็กต้นทาง ภายใน transaction เดียว
+func (r *GormTagRepository) MergeTags(userID uint, targetTagID uint, sourceTagIDs []uint) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        var target entities.Tag
+        if err := tx.Where("tag_id = ? AND user_id = ?", targetTagID, userID).First(&target).Error; err != nil {
+            return fmt.Errorf("tag not found or does not belong to this user")
+        }
+
+        var sources []entities.Tag
+        if err := tx.Where("tag_id IN ? AND user_id = ?", sourceTagIDs, userID).Find(&sources).Error; err != nil {
+            return fmt.Errorf("error finding tags: %v", err)
+        }
+        if len(sources) != len(sourceTagIDs) {
+            return fmt.Errorf("tag not found or does not belong to this user")
+        }
+
+        // ชี้ note_tags ของแท็กต้นทางไปยังแท็กปลายทาง (ข้ามโน้ตที่มีแท็กปลายทางอยู่แล้ว)
+        if err := tx.Exec(`INSERT INTO note_tags (note_id, tag_id)
+            SELECT DISTINCT note_id, ? FROM note_tags WHERE tag_id IN ?
+            ON CONFLICT DO NOTHING`, targetTagID, sourceTagIDs).Error; err != nil {
+            return fmt.Errorf("failed to move notes to target tag: %v", err)
+        }
+        if err := tx.Exec("DELETE FROM note_tags WHERE tag_id IN ?", sourceTagIDs).Error; err != nil {
+            return fmt.Errorf("failed to remove source tags from notes: %v", err)
+        }
+
+        // ถ้าแท็กปลายทางอยู่ใต้แท็กต้นทาง ให้ย้ายขึ้นไปอยู่ใต้แท็กแม่ที่ไม่ได้ถูก merge
+        parents := make(map[uint]*uint, len(sources))
+        for _, source := range sources {
+            parents[source.TagID] = source.ParentTagID
+        }
+        newParent := target.ParentTagID
+        for newParent != nil {
+            parent, merged := parents[*newParent]
+            if !merged {
+                break
+            }
+            newParent = parent
+        }
+        if err := tx.Model(&target).Update("parent_tag_id", newParent).Error; err != nil {
+            return fmt.Errorf("failed to move target tag: %v", err)
+        }
+
+        // แท็กลูกของแท็กต้นทางย้ายไปอยู่ใต้แท็กปลายทาง
+        if err := tx.Model(&entities.Tag{}).
+            Where("parent_tag_id IN ? AND tag_id <> ? AND user_id = ?", sourceTagIDs, targetTagID, userID).
+            Update("parent_tag_id", targetTagID).Error; err != nil {
+            return fmt.Errorf("failed to reparent child tags: %v", err)
+        }
+
+        // กฎติดแท็กอัตโนมัติของแท็กต้นทางชี้ไปยังแท็กปลายทางแทน
+        if err := tx.Model(&entities.AutoTagRule{}).
+            Where("tag_id IN ? AND user_id = ?", sourceTagIDs, userID).
+            Update("tag_id", targetTagID).Error; err != nil {
+            return fmt.Errorf("failed to update auto-tag rules: %v", err)
+        }
+
+        // การค้นหาที่บันทึกไว้และแม่แบบที่ใช้แท็กต้นทางจะใช้แท็กปลายทางแทน
+        if err := replaceTagInSavedSearches(tx, userID, sourceTagIDs, targetTagID); err != nil {
+            return err
+        }
+        if err := replaceTagInTemplates(tx, userID, sourceTagIDs, targetTagID); err != nil {
+            return err
+        }
+
+        if err := tx.Where("tag_id IN ? AND user_id = ?", sourceTagIDs, userID).Delete(&entities.Tag{}).Error; err != nil {
+            return fmt.Errorf("failed to delete source tags: %v", err)
+        }
+
+        return nil
+    })
+}
+
+// RenameTags เปลี่ยนชื่อแท็กหลายตัวพร้อมกันภายใน transaction เดียว
+// unique index ถูกตรวจทีละแถว ถ้าชื่อใหม่ของแท็กหนึ่งเป็นชื่อเดิมของอีกแท็ก (เช่น a -> aa ขณะที่มี aa อยู่แล้ว)
+// การ update ตรง ๆ จะชนกันชั่วคราว จึงเปลี่ยนเป็นชื่อชั่วคราวที่ไม่ซ้ำใคร (อิง tag_id) ก่อนแล้วค่อยตั้งชื่อจริง
+func (r *GormTagRepository) RenameTags(userID uint, newNames map[uint]string) error {
+    if len(newNames) == 0 {
+        return nil
+    }
+    tagIDs := make([]uint, 0, len(newNames))
+    for tagID := range newNames {
+        tagIDs = append(tagIDs, tagID)
+    }
+    sort.Slice(tagIDs, func(i, j int) bool { return tagIDs[i] < tagIDs[j] })
+
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&entities.Tag{}).
+            Where("tag_id IN ? AND user_id = ?", tagIDs, userID).
+            Update("tag_name", gorm.Expr("CAST(? AS TEXT) || CAST(tag_id AS TEXT)", "\x1frenaming:")).Error; err != nil {
+            return fmt.Errorf("failed to rename tags: %v", err)
+        }
+        for _, tagID := range tagIDs {
+            if err := tx.Model(&entities.Tag{}).
+                Where("tag_id = ? AND user_id = ?", tagID, userID).
+                Update("tag_name", newNames[tagID]).Error; err != nil {
+                return fmt.Errorf("failed to rename tag %d: %v", tagID, err)
+            }
+        }
+        return nil
+    })
+}
+
+// replaceTagIDs แทนที่ tag ที่อยู่ใน from ด้วย toTagID (0 = เอาออก) และตัดรายการซ้ำ คืน false ถ้าไม่มีการเปลี่ยนแปลง
+func replaceTagIDs(tagIDs []uint, from map[uint]bool, toTagID uint) ([]uint, bool) {
+    changed := false
+    seen := make(map[uint]bool)
+    result := []uint{}
+    for _, id := range tagIDs {
+        if from[id] {
+            changed = true
+            id = toTagID
+        }
+        if id == 0 || seen[id] {
+            continue
+        }
+        seen[id] = true
+        result = append(result, id)
+    }
+    return result, changed
+}
+
+// replaceTagInSavedSearches แทนที่ tag ใน filter ของการค้นหาที่บันทึกไว้ (toTagID = 0 คือเอาออก)
+func replaceTagInSavedSearches(tx *gorm.DB, userID uint, fromTagIDs []uint, toTagID uint) error {
+    from := make(map[uint]bool, len(fromTagIDs))
+    for _, id := range fromTagIDs {
+        from[id] = true
+    }
+
+    var searches []entities.SavedSearch
+    if err := tx.Where("user_id = ?", userID).Find(&searches).Error; err != nil {
+        return fmt.Errorf("failed to fetch saved searches: %v", err)
+    }
+
+    for i := range searches {
+        tagIDs, changed := replaceTagIDs(searches[i].Filter.TagIDs, from, toTagID)
+        if !changed {
+            continue
+        }
+
+        searches[i].Filter.TagIDs = tagIDs
+        if err := tx.Save(&searches[i]).Error; err != nil {
+            return fmt.Errorf("failed to update saved search %d: %v", searches[i].SearchID, err)
+        }
+    }
+    return nil
+}
+
+// replaceTagInTemplates แทนที่ tag ในแม่แบบโน้ต (toTagID = 0 คือเอาออก)
+func replaceTagInTemplates(tx *gorm.DB, userID uint, fromTagIDs []uint, toTagID uint) error {
+    from := make(map[uint]bool, len(fromTagIDs))
+    for _, id := range fromTagIDs {
+        from[id] = true
+    }
+
+    var templates []entities.NoteTemplate
+    if err := tx.Where("user_id = ?", userID).Find(&templates).Error; err != nil {
+        return fmt.Errorf("failed to fetch templates: %v", err)
+    }
+
+    for i := range templates {
+        tagIDs, changed := replaceTagIDs(templates[i].TagIDs, from, toTagID)
+        if !changed {
+            continue
+        }
+
+        templates[i].TagIDs = tagIDs
+        if err := tx.Save(&templates[i]).Error; err != nil {
+            return fmt.Errorf("failed to update template %d: %v", templates[i].TemplateID, err)
+        }
+    }
+    return nil
+}
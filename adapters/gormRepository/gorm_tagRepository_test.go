@@ -0,0 +1,84 @@
+package gormRepository
+
+import (
+	"miw/entities"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB ฐานข้อมูล SQLite ในหน่วยความจำ ใช้ทดสอบ query ที่ไม่ขึ้นกับ PostgreSQL (unique index ถูกตรวจทีละแถวเหมือนกัน)
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestRenameTagsOverlappingPrefixes(t *testing.T) {
+	db := newTestDB(t, &entities.Tag{})
+	tags := []entities.Tag{
+		{TagID: 1, UserID: 1, TagName: "a"},
+		{TagID: 2, UserID: 1, TagName: "a/x"},
+		{TagID: 3, UserID: 1, TagName: "aa"},
+		{TagID: 4, UserID: 1, TagName: "aa/x"},
+		{TagID: 5, UserID: 2, TagName: "aa/x"},
+	}
+	if err := db.Create(&tags).Error; err != nil {
+		t.Fatal(err)
+	}
+	repo := NewGormTagRepository(db)
+
+	// เปลี่ยน prefix "a" -> "aa": ชื่อใหม่ของแท็กหนึ่งคือชื่อเดิมของอีกแท็ก ต้องสำเร็จไม่ว่าจะวนลำดับใด
+	for attempt := 0; attempt < 20; attempt++ {
+		newNames := map[uint]string{1: "aa", 2: "aa/x", 3: "aaa", 4: "aaa/x"}
+		if attempt%2 == 1 {
+			newNames = map[uint]string{1: "a", 2: "a/x", 3: "aa", 4: "aa/x"}
+		}
+		if err := repo.RenameTags(1, newNames); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+
+		var got []entities.Tag
+		db.Order("tag_id").Find(&got)
+		for _, tag := range got {
+			want := tag.TagName
+			if tag.UserID == 1 {
+				want = newNames[tag.TagID]
+			}
+			if tag.TagName != want {
+				t.Fatalf("attempt %d: tag %d = %q, want %q", attempt, tag.TagID, tag.TagName, want)
+			}
+		}
+	}
+	// แท็กของผู้ใช้อื่นไม่ถูกเปลี่ยน
+	var other entities.Tag
+	db.First(&other, 5)
+	if other.TagName != "aa/x" {
+		t.Fatalf("another user's tag was renamed to %q", other.TagName)
+	}
+}
+
+func TestRenameTagsKeepsUniqueness(t *testing.T) {
+	db := newTestDB(t, &entities.Tag{})
+	if err := db.Create(&[]entities.Tag{{TagID: 1, UserID: 1, TagName: "a"}, {TagID: 2, UserID: 1, TagName: "b"}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	repo := NewGormTagRepository(db)
+
+	// ชื่อซ้ำกันจริงต้องล้มเหลวทั้ง transaction
+	if err := repo.RenameTags(1, map[uint]string{1: "c", 2: "c"}); err == nil {
+		t.Fatal("expected a unique violation")
+	}
+	var names []string
+	db.Model(&entities.Tag{}).Order("tag_id").Pluck("tag_name", &names)
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Fatalf("tags changed after a failed rename: %v", names)
+	}
+}
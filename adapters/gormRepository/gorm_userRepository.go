@@ -1,6 +1,7 @@
 package gormRepository
 
 import (
+	"fmt"
 	"gorm.io/gorm"
 	"miw/entities"
 )
@@ -14,11 +15,16 @@ func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
 }
 
 func (r *GormUserRepository) CreateUser(user *entities.User) error {
-	// บันทึก Note ลงในฐานข้อมูล
-	if err := r.db.Create(user).Error; err != nil {
-		return err
-	}
-	return nil
+	// บันทึก User พร้อมสร้างสมุด Inbox เริ่มต้นใน transaction เดียวกัน
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(newDefaultNotebook(user.UserID)).Error; err != nil {
+			return fmt.Errorf("failed to create default notebook: %v", err)
+		}
+		return nil
+	})
 }
 
 func (r *GormUserRepository) UpdateUser(user *entities.User) error {
@@ -27,18 +33,60 @@ func (r *GormUserRepository) UpdateUser(user *entities.User) error {
 
 func (r *GormUserRepository) GetUserById(userID uint) (*entities.User, error) {
 	var user entities.User
-	if err := r.db.Preload("Notes").
-		Preload("Notes.Tags", func(db *gorm.DB) *gorm.DB {
-            return db.Select("tag_id, tag_name") // ไม่ดึง Notes ใน Tags
-        }).
-        Preload("Notes.Reminder").
-        Preload("Notes.Event").
-	First(&user, userID).Error; err != nil {
+	if err := r.db.First(&user, userID).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// GetUserStats นับจำนวนโน้ต แท็ก และ Reminder ด้วย query แบบ aggregate แทนการ preload ข้อมูลทั้งหมด
+func (r *GormUserRepository) GetUserStats(userID uint) (*entities.UserStats, error) {
+	var stats entities.UserStats
+
+	if err := r.db.Model(&entities.Note{}).
+		Where("user_id = ? AND deleted_at = ?", userID, "").
+		Count(&stats.NoteCount).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&entities.Tag{}).
+		Where("user_id = ?", userID).
+		Count(&stats.TagCount).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&entities.Reminder{}).
+		Joins("JOIN notes ON notes.note_id = reminders.note_id").
+		Where("notes.user_id = ? AND notes.deleted_at = ?", userID, "").
+		Count(&stats.ReminderCount).Error; err != nil {
+		return nil, err
+	}
+
+	// พื้นที่ที่ใช้ = ขนาดของ title + content ของโน้ต เนื้อหาของ To-Do และไฟล์แนบทั้งหมด (รวมโน้ตในถังขยะ)
+	var noteBytes, todoBytes int64
+	if err := r.db.Model(&entities.Note{}).
+		Select("COALESCE(SUM(octet_length(title) + octet_length(content)), 0)").
+		Where("user_id = ?", userID).
+		Scan(&noteBytes).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&entities.ToDo{}).
+		Select("COALESCE(SUM(octet_length(to_dos.content)), 0)").
+		Joins("JOIN notes ON notes.note_id = to_dos.note_id").
+		Where("notes.user_id = ?", userID).
+		Scan(&todoBytes).Error; err != nil {
+		return nil, err
+	}
+	var attachmentBytes int64
+	if err := r.db.Model(&entities.Attachment{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("user_id = ?", userID).
+		Scan(&attachmentBytes).Error; err != nil {
+		return nil, err
+	}
+	stats.StorageBytes = noteBytes + todoBytes + attachmentBytes
+
+	return &stats, nil
+}
+
 func (r *GormUserRepository) GetUserByEmail(email string) (*entities.User, error) {
 	var user entities.User
 	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
@@ -53,4 +101,131 @@ func (r *GormUserRepository) GetUserEmailByID(userID uint) (string, error) {
 		return "", err
 	}
 	return user.Email, nil
-}
\ No newline at end of file
+}
+func (r *GormUserRepository) GetUserData(userID uint) (*entities.UserData, error) {
+	var data entities.UserData
+	if err := r.db.First(&data.User, userID).Error; err != nil {
+		return nil, err
+	}
+
+	// ดึงโน้ตทั้งหมดรวมถึงโน้ตในถังขยะ พร้อม tag ที่ผูกไว้
+	if err := r.db.Where("user_id = ?", userID).
+		Preload("Tags", func(db *gorm.DB) *gorm.DB {
+			return db.Select("tag_id, tag_name")
+		}).
+		Find(&data.Notes).Error; err != nil {
+		return nil, err
+	}
+
+	noteIDs := make([]uint, 0, len(data.Notes))
+	for _, note := range data.Notes {
+		noteIDs = append(noteIDs, note.NoteID)
+	}
+
+	if err := r.db.Where("note_id IN ?", noteIDs).Find(&data.TodoItems).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Find(&data.Tags).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("note_id IN ?", noteIDs).Find(&data.Reminders).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("note_id IN ?", noteIDs).Find(&data.Events).Error; err != nil {
+		return nil, err
+	}
+	// การแชร์ทั้งที่ผู้ใช้แชร์ออกไปและที่ผู้อื่นแชร์ให้
+	if err := r.db.Where("note_id IN ? OR shared_with = ?", noteIDs, userID).Find(&data.Shares).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Find(&data.Identities).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Find(&data.AutoTagRules).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Find(&data.SavedSearches).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Find(&data.Notebooks).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Find(&data.Templates).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Find(&data.Attachments).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Find(&data.NoteLinks).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Find(&data.ImportJobs).Error; err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// DeleteUser ลบผู้ใช้และข้อมูลทั้งหมดที่เป็นเจ้าของภายใน transaction เดียว
+func (r *GormUserRepository) DeleteUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var noteIDs, tagIDs []uint
+		if err := tx.Model(&entities.Note{}).Where("user_id = ?", userID).Pluck("note_id", &noteIDs).Error; err != nil {
+			return fmt.Errorf("failed to fetch notes: %v", err)
+		}
+		if err := tx.Model(&entities.Tag{}).Where("user_id = ?", userID).Pluck("tag_id", &tagIDs).Error; err != nil {
+			return fmt.Errorf("failed to fetch tags: %v", err)
+		}
+
+		if err := tx.Exec("DELETE FROM note_tags WHERE note_id IN ? OR tag_id IN ?", noteIDs, tagIDs).Error; err != nil {
+			return fmt.Errorf("failed to delete note tags: %v", err)
+		}
+		if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.ToDo{}).Error; err != nil {
+			return fmt.Errorf("failed to delete todo items: %v", err)
+		}
+		if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.Reminder{}).Error; err != nil {
+			return fmt.Errorf("failed to delete reminders: %v", err)
+		}
+		if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.Event{}).Error; err != nil {
+			return fmt.Errorf("failed to delete events: %v", err)
+		}
+		// ยกเลิกการแชร์ทั้งหมด ทั้งโน้ตของผู้ใช้และโน้ตที่ผู้อื่นแชร์ให้
+		if err := tx.Where("note_id IN ? OR shared_with = ?", noteIDs, userID).Delete(&entities.ShareNote{}).Error; err != nil {
+			return fmt.Errorf("failed to revoke shares: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.Attachment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete attachments: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.NoteLink{}).Error; err != nil {
+			return fmt.Errorf("failed to delete note links: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.ImportJob{}).Error; err != nil {
+			return fmt.Errorf("failed to delete import jobs: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.Note{}).Error; err != nil {
+			return fmt.Errorf("failed to delete notes: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.Tag{}).Error; err != nil {
+			return fmt.Errorf("failed to delete tags: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.AutoTagRule{}).Error; err != nil {
+			return fmt.Errorf("failed to delete auto-tag rules: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.SavedSearch{}).Error; err != nil {
+			return fmt.Errorf("failed to delete saved searches: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.Notebook{}).Error; err != nil {
+			return fmt.Errorf("failed to delete notebooks: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.NoteTemplate{}).Error; err != nil {
+			return fmt.Errorf("failed to delete templates: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.UserIdentity{}).Error; err != nil {
+			return fmt.Errorf("failed to delete linked identities: %v", err)
+		}
+		if err := tx.Delete(&entities.User{}, userID).Error; err != nil {
+			return fmt.Errorf("failed to delete user: %v", err)
+		}
+		return nil
+	})
+}
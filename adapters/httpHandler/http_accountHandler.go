@@ -0,0 +1,92 @@
+package httpHandler
+
+import (
+	"fmt"
+	"miw/usecases/service"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpAccountHandler struct {
+	accountUseCase service.AccountUseCase
+}
+
+func NewHttpAccountHandler(useCase service.AccountUseCase) *HttpAccountHandler {
+	return &HttpAccountHandler{accountUseCase: useCase}
+}
+
+// ดาวน์โหลดข้อมูลส่วนบุคคลทั้งหมดเป็นไฟล์ ZIP
+func (h *HttpAccountHandler) ExportHandler(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	archive, err := h.accountUseCase.ExportUserData(uint(userID))
+	if err != nil {
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s.zip", userID, time.Now().Format("20060102"))
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(archive)
+}
+
+// ขอรหัสยืนยันการลบบัญชีทางอีเมล สำหรับบัญชีที่ login ผ่าน OIDC และไม่มีรหัสผ่าน
+func (h *HttpAccountHandler) RequestDeletionHandler(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	if err := h.accountUseCase.RequestAccountDeletion(uint(userID)); err != nil {
+		switch err.Error() {
+		case "user not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		case "account has a password":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Confirm account deletion with your password instead"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "A confirmation code has been sent to your email"})
+}
+
+// ลบบัญชีผู้ใช้ ต้องยืนยันด้วยรหัสผ่านปัจจุบัน หรือ confirmation_token จากอีเมลสำหรับบัญชีที่ไม่มีรหัสผ่าน
+func (h *HttpAccountHandler) DeleteAccountHandler(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	data := new(struct {
+		Password          string `json:"password"`
+		ConfirmationToken string `json:"confirmation_token"`
+	})
+	if err := c.BodyParser(data); err != nil || (data.Password == "" && data.ConfirmationToken == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Password or confirmation token is required"})
+	}
+
+	if err := h.accountUseCase.DeleteAccount(uint(userID), data.Password, data.ConfirmationToken); err != nil {
+		switch err.Error() {
+		case "user not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		case "invalid password":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Password is incorrect"})
+		case "invalid confirmation token":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Confirmation token is invalid or expired"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// ลบ cookie ของ session ปัจจุบัน
+	c.ClearCookie("jwt")
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Account deleted successfully"})
+}
@@ -0,0 +1,195 @@
+package httpHandler
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/service"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpAttachmentHandler struct {
+	attachmentUseCase service.AttachmentUseCase
+}
+
+func NewHttpAttachmentHandler(useCase service.AttachmentUseCase) *HttpAttachmentHandler {
+	return &HttpAttachmentHandler{attachmentUseCase: useCase}
+}
+
+// AttachmentResponse ไฟล์แนบใน NoteResponse พร้อม URL ของไฟล์และรูปย่อที่สร้างเสร็จแล้ว
+type AttachmentResponse struct {
+	AttachmentID    uint              `json:"attachment_id"`
+	FileName        string            `json:"file_name"`
+	ContentType     string            `json:"content_type"`
+	Size            int64             `json:"size"`
+	Width           int               `json:"width,omitempty"` // เฉพาะรูปภาพ
+	Height          int               `json:"height,omitempty"`
+	URL             string            `json:"url"`
+	ThumbnailStatus string            `json:"thumbnail_status"`
+	Thumbnails      map[string]string `json:"thumbnails,omitempty"` // ขนาด -> URL
+}
+
+func toAttachmentResponses(attachments []entities.Attachment) []AttachmentResponse {
+	response := []AttachmentResponse{}
+	for _, attachment := range attachments {
+		item := AttachmentResponse{
+			AttachmentID:    attachment.AttachmentID,
+			FileName:        attachment.FileName,
+			ContentType:     attachment.ContentType,
+			Size:            attachment.Size,
+			Width:           attachment.Width,
+			Height:          attachment.Height,
+			URL:             fmt.Sprintf("/attachment/%d", attachment.AttachmentID),
+			ThumbnailStatus: attachment.ThumbnailStatus,
+		}
+		if len(attachment.Thumbnails) > 0 {
+			item.Thumbnails = map[string]string{}
+			for _, thumbnail := range attachment.Thumbnails {
+				item.Thumbnails[thumbnail.Size] = fmt.Sprintf("/attachment/%d/thumbnail/%s", attachment.AttachmentID, thumbnail.Size)
+			}
+		}
+		response = append(response, item)
+	}
+	return response
+}
+
+// attachmentErrorResponse แปลง error จาก service เป็น HTTP status
+func attachmentErrorResponse(c *fiber.Ctx, err error) error {
+	message := err.Error()
+	switch {
+	case message == "note not found or does not belong to the user":
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to access this note"})
+	case message == "attachment not found", message == "blob not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+	case message == "thumbnail not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Thumbnail not found"})
+	case message == "cannot attach files to a deleted note", message == "file is empty", message == "invalid image file":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": message})
+	case strings.HasPrefix(message, "file is too large"), strings.HasPrefix(message, "image is too large"):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": message})
+	case strings.HasPrefix(message, "file type"):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{"error": message})
+	case message == "storage quota exceeded":
+		return c.Status(fiber.StatusInsufficientStorage).JSON(fiber.Map{"error": message})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+}
+
+// อัปโหลดไฟล์แนบแบบ multipart/form-data (ฟิลด์ "file")
+func (h *HttpAttachmentHandler) UploadAttachmentHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File is required"})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Could not read uploaded file"})
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentUseCase.UploadAttachment(uint(noteID), userID, fileHeader.Filename, fileHeader.Size, file)
+	if err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":    "Attachment uploaded successfully",
+		"attachment": attachment,
+	})
+}
+
+func (h *HttpAttachmentHandler) GetAttachmentsHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	attachments, err := h.attachmentUseCase.GetAttachments(uint(noteID), userID)
+	if err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"attachments": attachments})
+}
+
+// ดาวน์โหลดไฟล์แนบ รูปภาพแสดงในเบราว์เซอร์ได้ ไฟล์อื่นบังคับดาวน์โหลด
+func (h *HttpAttachmentHandler) DownloadAttachmentHandler(c *fiber.Ctx) error {
+	attachmentID, err := strconv.Atoi(c.Params("attachmentid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	attachment, reader, err := h.attachmentUseCase.OpenAttachment(uint(attachmentID), userID)
+	if err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		disposition = "inline"
+	}
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("%s; filename*=UTF-8''%s", disposition, url.PathEscape(attachment.FileName)))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderETag, `"`+attachment.Checksum+`"`)
+
+	// fiber ปิด reader ให้หลังส่งข้อมูลเสร็จ
+	return c.SendStream(reader, int(attachment.Size))
+}
+
+// ดูรูปย่อของไฟล์แนบรูปภาพ ได้ 404 ถ้ายังสร้างไม่เสร็จหรือไม่ใช่รูปภาพ
+func (h *HttpAttachmentHandler) DownloadThumbnailHandler(c *fiber.Ctx) error {
+	attachmentID, err := strconv.Atoi(c.Params("attachmentid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	thumbnail, reader, err := h.attachmentUseCase.OpenThumbnail(uint(attachmentID), userID, c.Params("size"))
+	if err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, thumbnail.ContentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400")
+
+	return c.SendStream(reader)
+}
+
+func (h *HttpAttachmentHandler) DeleteAttachmentHandler(c *fiber.Ctx) error {
+	attachmentID, err := strconv.Atoi(c.Params("attachmentid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.attachmentUseCase.DeleteAttachment(uint(attachmentID), userID); err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Attachment deleted successfully"})
+}
+
+// พื้นที่ไฟล์แนบที่ใช้ไปและ quota ของผู้ใช้
+func (h *HttpAttachmentHandler) GetStorageUsageHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	usage, err := h.attachmentUseCase.GetStorageUsage(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(usage)
+}
@@ -0,0 +1,144 @@
+package httpHandler
+
+import (
+	"miw/entities"
+	"miw/usecases/service"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RuleMatchResponse struct {
+	NoteID        uint   `json:"note_id"`
+	Title         string `json:"title"`
+	AlreadyTagged bool   `json:"already_tagged"`
+}
+
+type HttpAutoTagRuleHandler struct {
+	ruleUseCase service.AutoTagRuleUseCase
+}
+
+func NewHttpAutoTagRuleHandler(useCase service.AutoTagRuleUseCase) *HttpAutoTagRuleHandler {
+	return &HttpAutoTagRuleHandler{ruleUseCase: useCase}
+}
+
+// ruleErrorResponse แปลง error จาก service เป็น HTTP status
+func ruleErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case err.Error() == "rule not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Rule not found"})
+	case err.Error() == "tag not found or does not belong to this user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+	case strings.HasPrefix(err.Error(), "invalid rule"):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+func (h *HttpAutoTagRuleHandler) CreateRuleHandler(c *fiber.Ctx) error {
+	rule := new(entities.AutoTagRule)
+	if err := c.BodyParser(rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	// ดึง UserID จาก Context
+	rule.UserID = c.Locals("user_id").(uint)
+
+	if err := h.ruleUseCase.CreateRule(rule); err != nil {
+		return ruleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Rule created successfully",
+		"rule":    rule,
+	})
+}
+
+func (h *HttpAutoTagRuleHandler) GetRulesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	rules, err := h.ruleUseCase.GetRules(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"rules": rules})
+}
+
+func (h *HttpAutoTagRuleHandler) UpdateRuleHandler(c *fiber.Ctx) error {
+	ruleID, err := strconv.Atoi(c.Params("ruleid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rule ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	input := new(entities.AutoTagRule)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.ruleUseCase.UpdateRule(uint(ruleID), userID, input); err != nil {
+		return ruleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Rule updated successfully"})
+}
+
+func (h *HttpAutoTagRuleHandler) DeleteRuleHandler(c *fiber.Ctx) error {
+	ruleID, err := strconv.Atoi(c.Params("ruleid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rule ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.ruleUseCase.DeleteRule(uint(ruleID), userID); err != nil {
+		return ruleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Rule deleted successfully"})
+}
+
+// ทดลองกฎกับโน้ตที่มีอยู่ โดยไม่บันทึกกฎและไม่แก้ไขโน้ต
+func (h *HttpAutoTagRuleHandler) DryRunHandler(c *fiber.Ctx) error {
+	rule := new(entities.AutoTagRule)
+	if err := c.BodyParser(rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	matches, err := h.ruleUseCase.DryRun(userID, rule)
+	if err != nil {
+		return ruleErrorResponse(c, err)
+	}
+
+	response := []RuleMatchResponse{}
+	for _, match := range matches {
+		response = append(response, RuleMatchResponse{
+			NoteID:        match.Note.NoteID,
+			Title:         match.Note.Title,
+			AlreadyTagged: match.AlreadyTagged,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"matches": response})
+}
+
+// ใช้กฎกับโน้ตที่มีอยู่แล้วทั้งหมด
+func (h *HttpAutoTagRuleHandler) ApplyRuleHandler(c *fiber.Ctx) error {
+	ruleID, err := strconv.Atoi(c.Params("ruleid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid rule ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	applied, err := h.ruleUseCase.ApplyToExistingNotes(uint(ruleID), userID)
+	if err != nil {
+		return ruleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Rule applied successfully",
+		"tagged":  applied,
+	})
+}
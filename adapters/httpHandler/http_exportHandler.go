@@ -0,0 +1,38 @@
+package httpHandler
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"miw/usecases/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpExportHandler struct {
+	exportUseCase service.ExportUseCase
+}
+
+func NewHttpExportHandler(useCase service.ExportUseCase) *HttpExportHandler {
+	return &HttpExportHandler{exportUseCase: useCase}
+}
+
+// ดาวน์โหลดโน้ตทั้งหมดเป็นไฟล์ ZIP ของ Markdown แยกโฟลเดอร์ตามสมุดโน้ต
+// ส่งข้อมูลแบบ stream ระหว่างสร้างไฟล์ ถ้าเกิด error กลางทางจะได้ไฟล์ ZIP ที่ไม่สมบูรณ์และบันทึก log ไว้
+func (h *HttpExportHandler) ExportMarkdownHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	filename := fmt.Sprintf("notes-markdown-%s.zip", time.Now().Format("20060102"))
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.exportUseCase.ExportMarkdown(userID, w); err != nil {
+			log.Printf("Markdown export for user %d failed: %v", userID, err)
+		}
+		if err := w.Flush(); err != nil {
+			log.Printf("Markdown export for user %d was not delivered: %v", userID, err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,89 @@
+package httpHandler
+
+import (
+	"miw/usecases/service"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpImportHandler struct {
+	importUseCase service.ImportUseCase
+}
+
+func NewHttpImportHandler(useCase service.ImportUseCase) *HttpImportHandler {
+	return &HttpImportHandler{importUseCase: useCase}
+}
+
+// importErrorResponse แปลง error จาก service เป็น HTTP status
+func importErrorResponse(c *fiber.Ctx, err error) error {
+	if ok, resp := validationFailed(c, err); ok {
+		return resp
+	}
+	message := err.Error()
+	switch {
+	case message == "import job not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Import job not found"})
+	case message == "an import is already in progress":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": message})
+	case message == "file is empty", message == "file must be a ZIP archive", message == "file must be an ENEX file":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": message})
+	case strings.HasPrefix(message, "file is too large"):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": message})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+}
+
+// เริ่มนำเข้าโน้ตแบบ multipart/form-data (ฟิลด์ "source" = keep, markdown หรือ enex และฟิลด์ "file" = ไฟล์ ZIP หรือ .enex)
+// ตอบกลับทันทีด้วยงานสถานะ pending แล้วดูความคืบหน้าได้จาก GET /import/:jobid
+func (h *HttpImportHandler) StartImportHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File is required"})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Could not read uploaded file"})
+	}
+	defer file.Close()
+
+	job, err := h.importUseCase.StartImport(userID, c.FormValue("source"), fileHeader.Filename, fileHeader.Size, file)
+	if err != nil {
+		return importErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Import started",
+		"job":     job,
+	})
+}
+
+func (h *HttpImportHandler) GetImportJobsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	jobs, err := h.importUseCase.GetImportJobs(userID)
+	if err != nil {
+		return importErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"jobs": jobs})
+}
+
+// ดูสถานะ ความคืบหน้า และรายงาน error ของงานนำเข้า
+func (h *HttpImportHandler) GetImportJobHandler(c *fiber.Ctx) error {
+	jobID, err := strconv.Atoi(c.Params("jobid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid job ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	job, err := h.importUseCase.GetImportJob(uint(jobID), userID)
+	if err != nil {
+		return importErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(job)
+}
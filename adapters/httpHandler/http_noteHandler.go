@@ -1,29 +1,40 @@
 package httpHandler
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"miw/entities"
 	"miw/usecases/service"
+	"miw/utils"
+	"sort"
 	"strconv"
 	"github.com/gofiber/fiber/v2"
 )
 
 type NoteResponse struct {
-	NoteID    uint                `json:"note_id"`
-	UserID    uint                `json:"user_id"`
-	Title     string              `json:"title"`
-	Content   string              `json:"content,omitempty"` // ซ่อนถ้าไม่มีค่า
-	Color     string              `json:"color"`
-	Priority  int                 `json:"priority"`
-	IsTodo    bool                `json:"is_todo"`
-	IsAllDone bool                `json:"is_all_done"` // เพิ่มฟิลด์นี้
-	TodoItems []ToDoResponse      `json:"todo_items"`  // เพิ่มรายการ ToDo
-	CreatedAt string              `json:"created_at"`
-	UpdatedAt string              `json:"updated_at"`
-	DeletedAt string              `json:"deleted_at,omitempty"` // ซ่อนถ้าไม่มีค่า
-	Tags      []string            `json:"tags"`
-	Reminder  []entities.Reminder `json:"reminder"`
-	Event     interface{}         `json:"event"`
+	NoteID        uint                 `json:"note_id"`
+	UserID        uint                 `json:"user_id"`
+	NotebookID    *uint                `json:"notebook_id"`
+	Title         string               `json:"title"`
+	Content       string               `json:"content,omitempty"` // ซ่อนถ้าไม่มีค่า
+	ContentFormat string               `json:"content_format"`
+	ContentHTML   string               `json:"content_html,omitempty"` // มีเฉพาะเมื่อขอ ?render=html
+	Color         string               `json:"color"`
+	Priority      int                  `json:"priority"`
+	IsTodo        bool                 `json:"is_todo"`
+	IsAllDone     bool                 `json:"is_all_done"` // เพิ่มฟิลด์นี้
+	TodoItems     []ToDoResponse       `json:"todo_items"`  // เพิ่มรายการ ToDo
+	CreatedAt     string               `json:"created_at"`
+	UpdatedAt     string               `json:"updated_at"`
+	DeletedAt     string               `json:"deleted_at,omitempty"` // ซ่อนถ้าไม่มีค่า
+	IsPinned      bool                 `json:"is_pinned"`
+	ArchivedAt    string               `json:"archived_at,omitempty"` // ซ่อนถ้าไม่มีค่า
+	Position      string               `json:"position"`
+	Tags          []string             `json:"tags"`
+	Reminder      []entities.Reminder  `json:"reminder"`
+	Event         interface{}          `json:"event"`
+	Attachments   []AttachmentResponse `json:"attachments"`
 }
 
 type ReminderResponse struct {
@@ -39,6 +50,76 @@ type ToDoResponse struct {
 	IsDone  bool   `json:"is_done"`
 }
 
+// toNoteResponses แปลงโน้ตเป็น JSON Response
+func toNoteResponses(notes []entities.Note) []NoteResponse {
+	var response []NoteResponse
+	for _, note := range notes {
+		tags := []string{}
+		for _, tag := range note.Tags {
+			tags = append(tags, tag.TagName)
+		}
+
+		// แปลง TodoItems จาก entities.ToDo เป็น ToDoResponse
+		var todoResponses []ToDoResponse
+		for _, todo := range note.TodoItems {
+			todoResponses = append(todoResponses, ToDoResponse{
+				ID:      todo.ID,
+				Content: todo.Content,
+				IsDone:  todo.IsDone,
+			})
+		}
+
+		response = append(response, NoteResponse{
+			NoteID:        note.NoteID,
+			UserID:        note.UserID,
+			NotebookID:    note.NotebookID,
+			Title:         note.Title,
+			Content:       note.Content,
+			ContentFormat: note.ContentFormat,
+			Color:         note.Color,
+			Priority:      note.Priority,
+			IsTodo:        note.IsTodo,
+			IsAllDone:     note.IsAllDone,
+			TodoItems:     todoResponses,
+			CreatedAt:     note.CreatedAt,
+			UpdatedAt:     note.UpdatedAt,
+			DeletedAt:     note.DeletedAt,
+			IsPinned:      note.IsPinned,
+			ArchivedAt:    note.ArchivedAt,
+			Position:      note.Position,
+			Tags:          tags,
+			Reminder:      note.Reminder,
+			Event:         note.Event,
+			Attachments:   toAttachmentResponses(note.Attachments),
+		})
+	}
+
+	return response
+}
+
+// noteResponsesFor แปลงโน้ตเป็น JSON Response และแนบ content_html เมื่อ request มี ?render=html
+func noteResponsesFor(c *fiber.Ctx, notes []entities.Note) []NoteResponse {
+	response := toNoteResponses(notes)
+	if c.Query("render") == "html" {
+		for i := range response {
+			response[i].ContentHTML = utils.RenderContentHTML(response[i].Content, response[i].ContentFormat)
+		}
+	}
+	return response
+}
+
+// validationFailed ตอบกลับ 422 พร้อมรายการฟิลด์ที่ไม่ผ่านการตรวจสอบทั้งหมด คืน false ถ้า err ไม่ใช่ ValidationError
+func validationFailed(c *fiber.Ctx, err error) (bool, error) {
+	var validation *utils.ValidationError
+	if !errors.As(err, &validation) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error":  "Validation failed",
+		"fields": validation.Errors,
+	})
+}
+
 type HttpNoteHandler struct {
 	noteUseCase service.NoteUseCase
 }
@@ -72,6 +153,12 @@ func (h *HttpNoteHandler) CreateNoteHandler(c *fiber.Ctx) error {
 
 	// เรียกใช้ฟังก์ชันสร้างโน้ต
 	if err := h.noteUseCase.CreateNote(note); err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if err.Error() == "notebook not found or does not belong to this user" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notebook not found"})
+		}
 		return c.Status(fiber.StatusInternalServerError).SendString("Could not create note")
 	}
 
@@ -88,51 +175,32 @@ func (h *HttpNoteHandler) GetAllNoteHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
 	}
 
-	// ดึงข้อมูลโน้ตทั้งหมดของ User
-	notes, err := h.noteUseCase.GetAllNote(userID)
-	if err != nil {
-		return c.Status(fiber.StatusNotFound).SendString("Notes not found for this user")
-	}
-
-	// แปลงผลลัพธ์เป็น JSON Response
-	var response []NoteResponse
-	for _, note := range notes {
-		tags := []string{}
-		for _, tag := range note.Tags {
-			tags = append(tags, tag.TagName)
+	// ดึงข้อมูลโน้ตทั้งหมดของ User หรือกรองตาม tag: ?tag_id=5&include_descendants=true
+	// หรือเฉพาะในสมุดโน้ต: ?notebook_id=3
+	var notes []entities.Note
+	var err error
+	if c.Query("tag_id") != "" {
+		tagID, convErr := strconv.Atoi(c.Query("tag_id"))
+		if convErr != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tag ID"})
 		}
-
-		// แปลง TodoItems จาก entities.ToDo เป็น ToDoResponse
-		var todoResponses []ToDoResponse
-		for _, todo := range note.TodoItems {
-			todoResponses = append(todoResponses, ToDoResponse{
-				ID:      todo.ID,
-				Content: todo.Content,
-				IsDone:  todo.IsDone,
-			})
+		notes, err = h.noteUseCase.GetNotesByTag(userID, uint(tagID), c.QueryBool("include_descendants"))
+	} else if c.Query("notebook_id") != "" {
+		notebookID, convErr := strconv.Atoi(c.Query("notebook_id"))
+		if convErr != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid notebook ID"})
 		}
-
-		response = append(response, NoteResponse{
-			NoteID:    note.NoteID,
-			UserID:    note.UserID,
-			Title:     note.Title,
-			Content:   note.Content,
-			Color:     note.Color,
-			Priority:  note.Priority,
-			IsTodo:    note.IsTodo,
-			IsAllDone: note.IsAllDone,
-			TodoItems: todoResponses,
-			CreatedAt: note.CreatedAt,
-			UpdatedAt: note.UpdatedAt,
-			DeletedAt: note.DeletedAt,
-			Tags:      tags,
-			Reminder:  note.Reminder,
-			Event:     note.Event,
-		})
+		id := uint(notebookID)
+		notes, err = h.noteUseCase.GetAllNote(userID, &id)
+	} else {
+		notes, err = h.noteUseCase.GetAllNote(userID, nil)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Notes not found for this user")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"notes": response,
+		"notes": noteResponsesFor(c, notes),
 	})
 }
 
@@ -149,6 +217,9 @@ func (h *HttpNoteHandler) UpdateColorHandler(c *fiber.Ctx) error {
 
 	err := h.noteUseCase.UpdateColor(uint(noteID), userID, data.Color)
 	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
 		if err.Error() == "note not found or does not belong to the user" {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this note"})
 		}
@@ -171,6 +242,9 @@ func (h *HttpNoteHandler) UpdatePriorityHandler(c *fiber.Ctx) error {
 
 	err := h.noteUseCase.UpdatePriority(uint(noteID), userID, data.Priority)
 	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
 		if err.Error() == "note not found or does not belong to the user" {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this note"})
 		}
@@ -344,3 +418,363 @@ func (h *HttpNoteHandler) RestoreNoteHandler(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Note restored successfully"})
 }
+
+// ย้ายโน้ตไปไว้ระหว่างโน้ตสองรายการ: {"prev_note_id": 4, "next_note_id": 9} (null = ต้น/ท้ายรายการ)
+func (h *HttpNoteHandler) MoveNoteHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	// ดึง UserID จาก Context
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request struct {
+		PrevNoteID *uint `json:"prev_note_id"`
+		NextNoteID *uint `json:"next_note_id"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	position, err := h.noteUseCase.MoveNote(uint(noteID), userID, request.PrevNoteID, request.NextNoteID)
+	if err != nil {
+		switch err.Error() {
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to move this note"})
+		case "cannot move a note relative to itself", "previous note must come before next note", "notes must be in the same notebook":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Note moved successfully",
+		"position": position,
+	})
+}
+
+// setNoteFlag ใช้ร่วมกันสำหรับ pin/unpin/archive/unarchive
+func (h *HttpNoteHandler) setNoteFlag(c *fiber.Ctx, update func(noteID uint, userID uint) error, message string) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	// ดึง UserID จาก Context
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	if err := update(uint(noteID), userID); err != nil {
+		if err.Error() == "note not found or does not belong to the user" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this note"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": message})
+}
+
+func (h *HttpNoteHandler) PinNoteHandler(c *fiber.Ctx) error {
+	return h.setNoteFlag(c, func(noteID uint, userID uint) error {
+		return h.noteUseCase.SetPinned(noteID, userID, true)
+	}, "Note pinned successfully")
+}
+
+func (h *HttpNoteHandler) UnpinNoteHandler(c *fiber.Ctx) error {
+	return h.setNoteFlag(c, func(noteID uint, userID uint) error {
+		return h.noteUseCase.SetPinned(noteID, userID, false)
+	}, "Note unpinned successfully")
+}
+
+func (h *HttpNoteHandler) ArchiveNoteHandler(c *fiber.Ctx) error {
+	return h.setNoteFlag(c, func(noteID uint, userID uint) error {
+		return h.noteUseCase.SetArchived(noteID, userID, true)
+	}, "Note archived successfully")
+}
+
+func (h *HttpNoteHandler) UnarchiveNoteHandler(c *fiber.Ctx) error {
+	return h.setNoteFlag(c, func(noteID uint, userID uint) error {
+		return h.noteUseCase.SetArchived(noteID, userID, false)
+	}, "Note unarchived successfully")
+}
+
+// ดูโน้ตที่เก็บเข้าคลังแล้ว
+func (h *HttpNoteHandler) GetArchivedNotesHandler(c *fiber.Ctx) error {
+	// ดึง UserID จาก Context (Middleware)
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	notes, err := h.noteUseCase.GetArchivedNotes(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"notes": noteResponsesFor(c, notes),
+	})
+}
+
+// decodePatchField แปลงค่าของฟิลด์ใน merge patch ค่า null ได้ค่าว่างของชนิดนั้น
+func decodePatchField(raw json.RawMessage, field string, target interface{}, validation *utils.ValidationError) bool {
+	if string(raw) == "null" {
+		return true
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		validation.Add(field, "invalid value")
+		return false
+	}
+	return true
+}
+
+// แก้ไขโน้ตบางส่วนด้วย JSON Merge Patch (RFC 7396) ฟิลด์ที่ไม่ส่งมาจะไม่ถูกแก้ไข ค่า null คือล้างค่า
+func (h *HttpNoteHandler) PatchNoteHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	// ดึง UserID จาก Context
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(c.Body(), &fields); err != nil || fields == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	patch := &service.NotePatch{}
+	validation := &utils.ValidationError{}
+	// เรียงชื่อฟิลด์เพื่อให้ลำดับข้อผิดพลาดคงที่
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	for _, field := range names {
+		raw := fields[field]
+		switch field {
+		case "title":
+			var title string
+			if decodePatchField(raw, field, &title, validation) {
+				patch.Title = &title
+			}
+		case "content":
+			var content string
+			if decodePatchField(raw, field, &content, validation) {
+				patch.Content = &content
+			}
+		case "content_format":
+			var format string
+			if decodePatchField(raw, field, &format, validation) {
+				patch.ContentFormat = &format
+			}
+		case "color":
+			var color string
+			if decodePatchField(raw, field, &color, validation) {
+				patch.Color = &color
+			}
+		case "priority":
+			var priority int
+			if decodePatchField(raw, field, &priority, validation) {
+				patch.Priority = &priority
+			}
+		case "is_todo":
+			var isTodo bool
+			if decodePatchField(raw, field, &isTodo, validation) {
+				patch.IsTodo = &isTodo
+			}
+		case "is_all_done":
+			var isAllDone bool
+			if decodePatchField(raw, field, &isAllDone, validation) {
+				patch.IsAllDone = &isAllDone
+			}
+		case "todo_items":
+			todoItems := []entities.ToDo{}
+			if decodePatchField(raw, field, &todoItems, validation) {
+				patch.TodoItems = &todoItems
+			}
+		case "tag_ids":
+			tagIDs := []uint{}
+			if decodePatchField(raw, field, &tagIDs, validation) {
+				patch.TagIDs = &tagIDs
+			}
+		case "notebook_id":
+			var notebookID *uint
+			if decodePatchField(raw, field, &notebookID, validation) {
+				patch.NotebookID = notebookID
+				patch.SetNotebook = true
+			}
+		default:
+			validation.Add(field, "unknown field")
+		}
+	}
+	if err := validation.Err(); err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+
+	note, err := h.noteUseCase.PatchNote(uint(noteID), userID, patch)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		switch err.Error() {
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this note"})
+		case "notebook not found or does not belong to this user":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notebook not found"})
+		case "tag not found or does not belong to the user":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Note updated successfully",
+		"note":    noteResponsesFor(c, []entities.Note{*note})[0],
+	})
+}
+
+// ทำคำสั่งเดียวกันกับโน้ตหลายรายการ พร้อมรายงานผลของโน้ตแต่ละรายการ
+func (h *HttpNoteHandler) BatchNotesHandler(c *fiber.Ctx) error {
+	// ดึง UserID จาก Context
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request struct {
+		NoteIDs    []uint `json:"note_ids"`
+		Operation  string `json:"operation"`
+		Color      string `json:"color"`
+		Priority   int    `json:"priority"`
+		TagID      uint   `json:"tag_id"`
+		NotebookID *uint  `json:"notebook_id"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	results, err := h.noteUseCase.BatchUpdateNotes(userID, request.NoteIDs, service.BatchOperation{
+		Operation:  request.Operation,
+		Color:      request.Color,
+		Priority:   request.Priority,
+		TagID:      request.TagID,
+		NotebookID: request.NotebookID,
+	})
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if err.Error() == "notebook not found or does not belong to this user" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notebook not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"operation": request.Operation,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
+
+// คัดลอกโน้ต ?include_schedule=true เพื่อคัดลอก Event และ Reminder ด้วย
+func (h *HttpNoteHandler) DuplicateNoteHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	// ดึง UserID จาก Context
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	note, err := h.noteUseCase.DuplicateNote(uint(noteID), userID, c.QueryBool("include_schedule"))
+	if err != nil {
+		switch err.Error() {
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to duplicate this note"})
+		case "cannot duplicate a deleted note":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Note duplicated successfully",
+		"note":    noteResponsesFor(c, []entities.Note{*note})[0],
+	})
+}
+
+// ลบโน้ตในถังขยะถาวร พร้อมไฟล์แนบทั้งหมด
+func (h *HttpNoteHandler) PurgeNoteHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	// ดึง UserID จาก Context
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	if err := h.noteUseCase.PurgeNote(uint(noteID), userID); err != nil {
+		switch err.Error() {
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to delete this note"})
+		case "note must be in trash before it can be permanently deleted":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Note permanently deleted"})
+}
+
+// ดูโน้ตที่ลิงก์มายังโน้ตนี้ด้วย [[ชื่อโน้ต]] หรือ [[note:id]]
+func (h *HttpNoteHandler) GetBacklinksHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	// ดึง UserID จาก Context
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	notes, err := h.noteUseCase.GetBacklinks(uint(noteID), userID)
+	if err != nil {
+		if err.Error() == "note not found or does not belong to the user" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to access this note"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"backlinks": noteResponsesFor(c, notes),
+	})
+}
@@ -0,0 +1,154 @@
+package httpHandler
+
+import (
+	"miw/entities"
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpNoteTemplateHandler struct {
+	templateUseCase service.NoteTemplateUseCase
+}
+
+func NewHttpNoteTemplateHandler(useCase service.NoteTemplateUseCase) *HttpNoteTemplateHandler {
+	return &HttpNoteTemplateHandler{templateUseCase: useCase}
+}
+
+// templateErrorResponse แปลง error จาก service เป็น HTTP status
+func templateErrorResponse(c *fiber.Ctx, err error) error {
+	if ok, resp := validationFailed(c, err); ok {
+		return resp
+	}
+	switch err.Error() {
+	case "template not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Template not found"})
+	case "tag not found or does not belong to this user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+	case "notebook not found or does not belong to this user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notebook not found"})
+	case "note not found or does not belong to the user":
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to use this note"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+func (h *HttpNoteTemplateHandler) CreateTemplateHandler(c *fiber.Ctx) error {
+	template := new(entities.NoteTemplate)
+	if err := c.BodyParser(template); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	// ดึง UserID จาก Context
+	template.UserID = c.Locals("user_id").(uint)
+
+	if err := h.templateUseCase.CreateTemplate(template); err != nil {
+		return templateErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Template created successfully",
+		"template": template,
+	})
+}
+
+// บันทึกโน้ตที่มีอยู่เป็นแม่แบบ
+func (h *HttpNoteTemplateHandler) CreateTemplateFromNoteHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	template, err := h.templateUseCase.CreateTemplateFromNote(uint(noteID), userID, request.Name)
+	if err != nil {
+		return templateErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Template created successfully",
+		"template": template,
+	})
+}
+
+func (h *HttpNoteTemplateHandler) GetTemplatesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	templates, err := h.templateUseCase.GetTemplates(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"templates": templates})
+}
+
+func (h *HttpNoteTemplateHandler) UpdateTemplateHandler(c *fiber.Ctx) error {
+	templateID, err := strconv.Atoi(c.Params("templateid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	input := new(entities.NoteTemplate)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.templateUseCase.UpdateTemplate(uint(templateID), userID, input); err != nil {
+		return templateErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Template updated successfully"})
+}
+
+func (h *HttpNoteTemplateHandler) DeleteTemplateHandler(c *fiber.Ctx) error {
+	templateID, err := strconv.Atoi(c.Params("templateid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.templateUseCase.DeleteTemplate(uint(templateID), userID); err != nil {
+		return templateErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Template deleted successfully"})
+}
+
+// สร้างโน้ตใหม่จากแม่แบบ พร้อมแทนค่า placeholder เช่น {{date}} และ {{weekday}}
+func (h *HttpNoteTemplateHandler) CreateNoteFromTemplateHandler(c *fiber.Ctx) error {
+	templateID, err := strconv.Atoi(c.Params("templateid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		NotebookID *uint `json:"notebook_id"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	note, err := h.templateUseCase.CreateNoteFromTemplate(uint(templateID), userID, request.NotebookID)
+	if err != nil {
+		return templateErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Note created successfully",
+		"note":    noteResponsesFor(c, []entities.Note{*note})[0],
+	})
+}
@@ -0,0 +1,120 @@
+package httpHandler
+
+import (
+	"miw/entities"
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpNotebookHandler struct {
+	notebookUseCase service.NotebookUseCase
+}
+
+func NewHttpNotebookHandler(useCase service.NotebookUseCase) *HttpNotebookHandler {
+	return &HttpNotebookHandler{notebookUseCase: useCase}
+}
+
+// notebookErrorResponse แปลง error จาก service เป็น HTTP status
+func notebookErrorResponse(c *fiber.Ctx, err error) error {
+	if ok, resp := validationFailed(c, err); ok {
+		return resp
+	}
+	switch err.Error() {
+	case "notebook not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notebook not found"})
+	case "parent notebook not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Parent notebook not found"})
+	case "notebook name is required", "note IDs are required":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case "cannot move a notebook under itself or its descendants", "cannot delete the default notebook":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+func (h *HttpNotebookHandler) CreateNotebookHandler(c *fiber.Ctx) error {
+	notebook := new(entities.Notebook)
+	if err := c.BodyParser(notebook); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	// ดึง UserID จาก Context
+	notebook.UserID = c.Locals("user_id").(uint)
+
+	if err := h.notebookUseCase.CreateNotebook(notebook); err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Notebook created successfully",
+		"notebook": notebook,
+	})
+}
+
+func (h *HttpNotebookHandler) GetNotebooksHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	notebooks, err := h.notebookUseCase.GetNotebooks(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"notebooks": notebooks})
+}
+
+func (h *HttpNotebookHandler) UpdateNotebookHandler(c *fiber.Ctx) error {
+	notebookID, err := strconv.Atoi(c.Params("notebookid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid notebook ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	input := new(entities.Notebook)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.notebookUseCase.UpdateNotebook(uint(notebookID), userID, input); err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Notebook updated successfully"})
+}
+
+func (h *HttpNotebookHandler) DeleteNotebookHandler(c *fiber.Ctx) error {
+	notebookID, err := strconv.Atoi(c.Params("notebookid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid notebook ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.notebookUseCase.DeleteNotebook(uint(notebookID), userID); err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Notebook deleted successfully"})
+}
+
+// ย้ายโน้ตหลายรายการไปยังสมุดโน้ต: {"note_ids": [1, 2], "notebook_id": 3} (notebook_id = null คือเอาออกจากสมุด)
+func (h *HttpNotebookHandler) MoveNotesHandler(c *fiber.Ctx) error {
+	var request struct {
+		NoteIDs    []uint `json:"note_ids"`
+		NotebookID *uint  `json:"notebook_id"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	moved, err := h.notebookUseCase.MoveNotes(userID, request.NoteIDs, request.NotebookID)
+	if err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Notes moved successfully",
+		"moved":   moved,
+	})
+}
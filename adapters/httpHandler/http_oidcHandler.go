@@ -0,0 +1,71 @@
+package httpHandler
+
+import (
+	"miw/usecases/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpOIDCHandler struct {
+	oidcUseCase service.OIDCUseCase
+}
+
+func NewHttpOIDCHandler(useCase service.OIDCUseCase) *HttpOIDCHandler {
+	return &HttpOIDCHandler{oidcUseCase: useCase}
+}
+
+// เริ่ม login ผ่านผู้ให้บริการภายนอก แล้ว redirect ไปหน้า login ของผู้ให้บริการ
+func (h *HttpOIDCHandler) LoginHandler(c *fiber.Ctx) error {
+	authURL, flowToken, err := h.oidcUseCase.BeginLogin(c.Params("provider"))
+	if err != nil {
+		if err.Error() == "oidc provider not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Identity provider not found"})
+		}
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// เก็บ state/nonce/PKCE verifier ไว้ใน cookie ชั่วคราวจนกว่าจะกลับมาที่ callback
+	c.Cookie(&fiber.Cookie{
+		Name:     "oidc_flow",
+		Value:    flowToken,
+		Expires:  time.Now().Add(10 * time.Minute),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// รับ callback จากผู้ให้บริการ ตรวจสอบ ID token และออก jwt cookie ของระบบ
+func (h *HttpOIDCHandler) CallbackHandler(c *fiber.Ctx) error {
+	if errMsg := c.Query("error"); errMsg != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": errMsg})
+	}
+
+	flowToken := c.Cookies("oidc_flow")
+	if flowToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Login session not found"})
+	}
+
+	token, err := h.oidcUseCase.CompleteLogin(c.Params("provider"), c.Query("code"), c.Query("state"), flowToken)
+
+	// ลบ cookie ชั่วคราวทิ้งเสมอ ไม่ว่าผลจะสำเร็จหรือไม่
+	c.ClearCookie("oidc_flow")
+
+	if err != nil {
+		if err.Error() == "oidc provider not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Identity provider not found"})
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     "jwt",
+		Value:    token,
+		Expires:  time.Now().Add(time.Hour * 72),
+		HTTPOnly: true,
+	})
+
+	return c.JSON(fiber.Map{"message": "Login successful"})
+}
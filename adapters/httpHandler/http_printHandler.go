@@ -0,0 +1,86 @@
+package httpHandler
+
+import (
+	"fmt"
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpPrintHandler struct {
+	printUseCase service.PrintUseCase
+}
+
+func NewHttpPrintHandler(useCase service.PrintUseCase) *HttpPrintHandler {
+	return &HttpPrintHandler{printUseCase: useCase}
+}
+
+// printErrorResponse แปลง error จาก service เป็น HTTP status
+func printErrorResponse(c *fiber.Ctx, err error) error {
+	if ok, resp := validationFailed(c, err); ok {
+		return resp
+	}
+	switch err.Error() {
+	case "note not found or does not belong to the user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Note not found"})
+	case "PDF printing is not configured":
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "PDF printing is not available on this server, use the HTML print page instead"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// printNoteIDs อ่าน id ของโน้ตจาก path (/note/:noteid/...) หรือจาก body {"note_ids": [...]}
+// ถ้าอ่านไม่ได้จะคืน ok = false พร้อม response 400 ที่ส่งแล้ว
+func printNoteIDs(c *fiber.Ctx) (noteIDs []uint, ok bool, resp error) {
+	if param := c.Params("noteid"); param != "" {
+		noteID, err := strconv.Atoi(param)
+		if err != nil || noteID <= 0 {
+			return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+		}
+		return []uint{uint(noteID)}, true, nil
+	}
+
+	data := new(struct {
+		NoteIDs []uint `json:"note_ids"`
+	})
+	if err := c.BodyParser(data); err != nil {
+		return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	return data.NoteIDs, true, nil
+}
+
+// หน้า HTML สำหรับพิมพ์โน้ต (เปิดในเบราว์เซอร์แล้วสั่งพิมพ์ได้ทันที)
+func (h *HttpPrintHandler) PrintHTMLHandler(c *fiber.Ctx) error {
+	noteIDs, ok, resp := printNoteIDs(c)
+	if !ok {
+		return resp
+	}
+	userID := c.Locals("user_id").(uint)
+
+	page, err := h.printUseCase.RenderHTML(userID, noteIDs)
+	if err != nil {
+		return printErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(fiber.StatusOK).SendString(page)
+}
+
+// ไฟล์ PDF ของโน้ต (A4 หนึ่งโน้ตต่อหน้าขึ้นไป)
+func (h *HttpPrintHandler) PrintPDFHandler(c *fiber.Ctx) error {
+	noteIDs, ok, resp := printNoteIDs(c)
+	if !ok {
+		return resp
+	}
+	userID := c.Locals("user_id").(uint)
+
+	document, err := h.printUseCase.RenderPDF(userID, noteIDs)
+	if err != nil {
+		return printErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s"`, service.PrintFileName(noteIDs)))
+	return c.Status(fiber.StatusOK).Send(document)
+}
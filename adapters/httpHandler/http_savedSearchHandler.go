@@ -0,0 +1,126 @@
+package httpHandler
+
+import (
+	"miw/entities"
+	"miw/usecases/service"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpSavedSearchHandler struct {
+	searchUseCase service.SavedSearchUseCase
+}
+
+func NewHttpSavedSearchHandler(useCase service.SavedSearchUseCase) *HttpSavedSearchHandler {
+	return &HttpSavedSearchHandler{searchUseCase: useCase}
+}
+
+// savedSearchErrorResponse แปลง error จาก service เป็น HTTP status
+func savedSearchErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case err.Error() == "saved search not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Saved search not found"})
+	case err.Error() == "tag not found or does not belong to this user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+	case strings.HasPrefix(err.Error(), "invalid saved search"):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+func (h *HttpSavedSearchHandler) CreateSavedSearchHandler(c *fiber.Ctx) error {
+	search := new(entities.SavedSearch)
+	if err := c.BodyParser(search); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	// ดึง UserID จาก Context
+	search.UserID = c.Locals("user_id").(uint)
+
+	if err := h.searchUseCase.CreateSavedSearch(search); err != nil {
+		return savedSearchErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "Saved search created successfully",
+		"saved_search": search,
+	})
+}
+
+func (h *HttpSavedSearchHandler) GetSavedSearchesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	searches, err := h.searchUseCase.GetSavedSearches(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"saved_searches": searches})
+}
+
+func (h *HttpSavedSearchHandler) UpdateSavedSearchHandler(c *fiber.Ctx) error {
+	searchID, err := strconv.Atoi(c.Params("searchid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid saved search ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	input := new(entities.SavedSearch)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.searchUseCase.UpdateSavedSearch(uint(searchID), userID, input); err != nil {
+		return savedSearchErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Saved search updated successfully"})
+}
+
+func (h *HttpSavedSearchHandler) DeleteSavedSearchHandler(c *fiber.Ctx) error {
+	searchID, err := strconv.Atoi(c.Params("searchid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid saved search ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.searchUseCase.DeleteSavedSearch(uint(searchID), userID); err != nil {
+		return savedSearchErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Saved search deleted successfully"})
+}
+
+// ดึงรายการโน้ตที่ตรงกับการค้นหาที่บันทึกไว้ ณ ขณะนี้
+func (h *HttpSavedSearchHandler) RunSavedSearchHandler(c *fiber.Ctx) error {
+	searchID, err := strconv.Atoi(c.Params("searchid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid saved search ID"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	notes, err := h.searchUseCase.RunSavedSearch(uint(searchID), userID)
+	if err != nil {
+		return savedSearchErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"notes": noteResponsesFor(c, notes)})
+}
+
+// ข้อมูลแถบด้านข้าง: สมุดโน้ต ต้นไม้แท็ก และการค้นหาที่บันทึกไว้
+func (h *HttpSavedSearchHandler) GetSidebarHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	sidebar, err := h.searchUseCase.GetSidebar(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"notebooks":      sidebar.Notebooks,
+		"tags":           toTagTreeResponse(sidebar.Tags),
+		"saved_searches": sidebar.SavedSearches,
+	})
+}
@@ -11,9 +11,47 @@ type TagResponse struct {
 	TagID   uint   `json:"tag_id"`
 	TagName string `json:"tag_name"`
 	UserID  uint   `json:"user_id"` 
+	ParentTagID *uint `json:"parent_tag_id"`
+	Color   string `json:"color"`
+	Icon    string `json:"icon"`
 	Notes   []uint `json:"notes"`
 }
 
+type TagListResponse struct {
+	TagID       uint   `json:"tag_id"`
+	TagName     string `json:"tag_name"`
+	ParentTagID *uint  `json:"parent_tag_id"`
+	Color       string `json:"color"`
+	Icon        string `json:"icon"`
+	NoteCount   int64  `json:"note_count"`
+}
+
+type TagTreeResponse struct {
+	TagID     uint              `json:"tag_id"`
+	TagName   string            `json:"tag_name"`
+	Path      string            `json:"path"`
+	Color     string            `json:"color"`
+	Icon      string            `json:"icon"`
+	NoteCount int64             `json:"note_count"`
+	Children  []TagTreeResponse `json:"children"`
+}
+
+func toTagTreeResponse(nodes []*service.TagTreeNode) []TagTreeResponse {
+	response := []TagTreeResponse{}
+	for _, node := range nodes {
+		response = append(response, TagTreeResponse{
+			TagID:     node.Tag.TagID,
+			TagName:   node.Tag.TagName,
+			Path:      node.Path,
+			Color:     node.Tag.Color,
+			Icon:      node.Tag.Icon,
+			NoteCount: node.Tag.NoteCount,
+			Children:  toTagTreeResponse(node.Children),
+		})
+	}
+	return response
+}
+
 type HttpTagHandler struct {
 	tagUseCase service.TagUseCase
 }
@@ -38,6 +76,12 @@ func (h *HttpTagHandler) CreateTagHandler(c *fiber.Ctx) error {
 
 	// เรียกใช้ฟังก์ชันสร้างแท็ก
 	if err := h.tagUseCase.CreateTag(tag); err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if err.Error() == "parent tag not found or does not belong to this user" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Parent tag not found"})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -48,6 +92,48 @@ func (h *HttpTagHandler) CreateTagHandler(c *fiber.Ctx) error {
 }
 
 
+func (h *HttpTagHandler) GetAllTagsHandler(c *fiber.Ctx) error {
+	// ดึง UserID จาก Context
+	userID := c.Locals("user_id").(uint)
+
+	// เรียงตามชื่อ (ค่าเริ่มต้น) หรือจำนวนการใช้งาน: ?sort=name|usage
+	sortBy := c.Query("sort")
+
+	// ?flat=true คืนรายการแบบไม่มีลำดับชั้น
+	if c.QueryBool("flat") {
+		tags, err := h.tagUseCase.GetTagsByUser(userID, sortBy)
+		if err != nil {
+			if err.Error() == "invalid sort option" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sort must be 'name' or 'usage'"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		response := []TagListResponse{}
+		for _, tag := range tags {
+			response = append(response, TagListResponse{
+				TagID:       tag.TagID,
+				TagName:     tag.TagName,
+				ParentTagID: tag.ParentTagID,
+				Color:       tag.Color,
+				Icon:        tag.Icon,
+				NoteCount:   tag.NoteCount,
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"tags": response})
+	}
+
+	tree, err := h.tagUseCase.GetTagTree(userID, sortBy)
+	if err != nil {
+		if err.Error() == "invalid sort option" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sort must be 'name' or 'usage'"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"tags": toTagTreeResponse(tree)})
+}
+
 func (h *HttpTagHandler) GetTagHandler(c *fiber.Ctx) error {
 	tagID, err := strconv.Atoi(c.Params("tagid"))
 	if err != nil {
@@ -73,6 +159,9 @@ func (h *HttpTagHandler) GetTagHandler(c *fiber.Ctx) error {
         TagID:   tag.TagID,
         TagName: tag.TagName,
         UserID:  tag.UserID, 
+        ParentTagID: tag.ParentTagID,
+        Color:   tag.Color,
+        Icon:    tag.Icon,
         Notes:   noteIDs,
     }
 
@@ -132,3 +221,122 @@ func (h *HttpTagHandler) DeleteTagHandler(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Tag deleted successfully"})
 }
+
+func (h *HttpTagHandler) MoveTagHandler(c *fiber.Ctx) error {
+	var request struct {
+		ParentTagID *uint `json:"parent_tag_id"` // null = ย้ายไประดับบนสุด
+	}
+
+	tagID, err := strconv.Atoi(c.Params("tagid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tag ID"})
+	}
+
+	// ดึง UserID จาก Context
+	userID := c.Locals("user_id").(uint)
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.tagUseCase.MoveTag(uint(tagID), userID, request.ParentTagID); err != nil {
+		switch err.Error() {
+		case "tag not found or does not belong to this user":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+		case "parent tag not found or does not belong to this user":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Parent tag not found"})
+		case "cannot move a tag under itself or its descendants":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Tag moved successfully"})
+}
+
+func (h *HttpTagHandler) UpdateTagAppearanceHandler(c *fiber.Ctx) error {
+	var request struct {
+		Color *string `json:"color"`
+		Icon  *string `json:"icon"`
+	}
+
+	tagID, err := strconv.Atoi(c.Params("tagid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tag ID"})
+	}
+
+	// ดึง UserID จาก Context
+	userID := c.Locals("user_id").(uint)
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if request.Color == nil && request.Icon == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "At least one of 'color' or 'icon' must be provided"})
+	}
+
+	if err := h.tagUseCase.UpdateTagAppearance(uint(tagID), userID, request.Color, request.Icon); err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if err.Error() == "tag not found or does not belong to this user" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Tag updated successfully"})
+}
+
+func (h *HttpTagHandler) MergeTagsHandler(c *fiber.Ctx) error {
+	var request struct {
+		TargetTagID  uint   `json:"target_tag_id"`
+		SourceTagIDs []uint `json:"source_tag_ids"`
+	}
+
+	// ดึง UserID จาก Context
+	userID := c.Locals("user_id").(uint)
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.tagUseCase.MergeTags(userID, request.TargetTagID, request.SourceTagIDs); err != nil {
+		switch err.Error() {
+		case "tag not found or does not belong to this user":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+		case "source tags are required", "target tag cannot be one of the source tags":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Tags merged successfully"})
+}
+
+func (h *HttpTagHandler) RenameTagsByPrefixHandler(c *fiber.Ctx) error {
+	var request struct {
+		OldPrefix string `json:"old_prefix"`
+		NewPrefix string `json:"new_prefix"`
+	}
+
+	// ดึง UserID จาก Context
+	userID := c.Locals("user_id").(uint)
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	renamed, err := h.tagUseCase.RenameTagsByPrefix(userID, request.OldPrefix, request.NewPrefix)
+	if err != nil {
+		if err.Error() == "old prefix is required" || err.Error() == "tag name cannot be empty" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Tags renamed successfully",
+		"renamed": renamed,
+	})
+}
@@ -8,12 +8,40 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// UserProfileResponse ข้อมูลโปรไฟล์ที่ส่งให้ client (ไม่มีรหัสผ่านหรือ token ของบริการภายนอก)
+type UserProfileResponse struct {
+	UserID       uint                   `json:"user_id"`
+	Username     string                 `json:"username"`
+	Email        string                 `json:"email"`
+	Timezone     string                 `json:"timezone"`
+	Preferences  map[string]interface{} `json:"preferences"`
+	Counts       UserCountsResponse     `json:"counts"`
+	StorageUsage int64                  `json:"storage_usage"`
+}
+
+type UserCountsResponse struct {
+	Notes     int64 `json:"notes"`
+	Tags      int64 `json:"tags"`
+	Reminders int64 `json:"reminders"`
+}
+
 type HttpUserHandler struct {
 	userUseCase service.UserUseCase
+	rateLimiter service.RateLimitUseCase
 }
 
-func NewHttpUserHandler(useCase service.UserUseCase) *HttpUserHandler {
-	return &HttpUserHandler{userUseCase: useCase}
+func NewHttpUserHandler(useCase service.UserUseCase, rateLimiter service.RateLimitUseCase) *HttpUserHandler {
+	return &HttpUserHandler{userUseCase: useCase, rateLimiter: rateLimiter}
+}
+
+// tooManyRequests ตอบกลับ 429 พร้อม Retry-After (หน่วยเป็นวินาที)
+func tooManyRequests(c *fiber.Ctx, retryAfter time.Duration) error {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       "Too many attempts, please try again later",
+		"retry_after": seconds,
+	})
 }
 
 func (h *HttpUserHandler) Register(c *fiber.Ctx) error {
@@ -45,11 +73,37 @@ func (h *HttpUserHandler) Login(c *fiber.Ctx) error {
 		return c.SendStatus(fiber.StatusBadRequest)
 	}
 
+	// จำกัดจำนวนครั้งที่ login ผิดทั้งตาม IP และตาม email
+	// IP ที่ถูกล็อกถูกปฏิเสธทันที ส่วน email ที่ถูกล็อกยัง login ด้วยรหัสผ่านที่ถูกต้องได้
+	// เพื่อไม่ให้คนที่รู้อีเมลล็อกเจ้าของบัญชีไว้ได้ตลอด
+	ipKey := service.RateLimitKey("ip", c.IP())
+	emailKey := service.RateLimitKey("email", data.Email)
+	retryAfter, err := h.rateLimiter.Check(service.RateLimitLogin, ipKey)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Could not process login")
+	}
+	if retryAfter > 0 {
+		return tooManyRequests(c, retryAfter)
+	}
+	emailRetryAfter, err := h.rateLimiter.Check(service.RateLimitLogin, emailKey)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Could not process login")
+	}
+
 	token, err := h.userUseCase.Login(data.Email, data.Password)
 	if err != nil {
+		if retryAfter, hitErr := h.rateLimiter.Hit(service.RateLimitLogin, ipKey, emailKey); hitErr == nil && retryAfter > emailRetryAfter {
+			emailRetryAfter = retryAfter
+		}
+		if emailRetryAfter > 0 {
+			return tooManyRequests(c, emailRetryAfter)
+		}
 		return c.Status(fiber.StatusUnauthorized).SendString("Email or password is incorrect")
 	}
 
+	// login สำเร็จ ล้างตัวนับของ email นี้ (ตัวนับของ IP ยังคงอยู่)
+	_ = h.rateLimiter.Reset(service.RateLimitLogin, emailKey)
+
 	c.Cookie(&fiber.Cookie{
 		Name:     "jwt",
 		Value:    token,
@@ -68,6 +122,20 @@ func (h *HttpUserHandler) ForgotPassword(c *fiber.Ctx) error {
 		return c.SendStatus(fiber.StatusBadRequest)
 	}
 
+	// จำกัดจำนวนอีเมลรีเซ็ตรหัสผ่านที่ส่งได้ต่อ IP และต่อ email
+	ipKey := service.RateLimitKey("ip", c.IP())
+	emailKey := service.RateLimitKey("email", data.Email)
+	retryAfter, err := h.rateLimiter.Check(service.RateLimitForgotPassword, ipKey, emailKey)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Could not send reset email")
+	}
+	if retryAfter > 0 {
+		return tooManyRequests(c, retryAfter)
+	}
+	if _, err := h.rateLimiter.Hit(service.RateLimitForgotPassword, ipKey, emailKey); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Could not send reset email")
+	}
+
 	if err := h.userUseCase.SendResetPasswordEmail(data.Email); err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Could not send reset email")
 	}
@@ -79,6 +147,16 @@ func (h *HttpUserHandler) ResetPassword(c *fiber.Ctx) error {
 	// รับโทเค็นจาก query parameter
 	tokenString := c.Query("token")
 
+	// จำกัดจำนวนครั้งที่ใช้โทเค็นไม่ถูกต้องต่อ IP
+	ipKey := service.RateLimitKey("ip", c.IP())
+	retryAfter, err := h.rateLimiter.Check(service.RateLimitResetPassword, ipKey)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("could not reset password")
+	}
+	if retryAfter > 0 {
+		return tooManyRequests(c, retryAfter)
+	}
+
 	// อ่านรหัสผ่านใหม่จาก body และตรวจสอบว่ามีเพียงฟิลด์ password เท่านั้น
 	var requestBody map[string]interface{}
 	if err := c.BodyParser(&requestBody); err != nil {
@@ -94,6 +172,9 @@ func (h *HttpUserHandler) ResetPassword(c *fiber.Ctx) error {
 			if err.Error() == "user not found" {
 				return c.Status(fiber.StatusNotFound).SendString("user not found")
 			}
+			if retryAfter, hitErr := h.rateLimiter.Hit(service.RateLimitResetPassword, ipKey); hitErr == nil && retryAfter > 0 {
+				return tooManyRequests(c, retryAfter)
+			}
 			return c.Status(fiber.StatusUnauthorized).SendString("could not reset password")
 		}
 
@@ -115,7 +196,29 @@ func (h *HttpUserHandler) GetUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).SendString("User not found")
 	}
 
-	return c.JSON(user)
+	stats, err := h.userUseCase.GetUserStats(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Could not load user statistics")
+	}
+
+	preferences := user.Preferences
+	if preferences == nil {
+		preferences = map[string]interface{}{}
+	}
+
+	return c.JSON(UserProfileResponse{
+		UserID:      user.UserID,
+		Username:    user.Username,
+		Email:       user.Email,
+		Timezone:    user.Timezone,
+		Preferences: preferences,
+		Counts: UserCountsResponse{
+			Notes:     stats.NoteCount,
+			Tags:      stats.TagCount,
+			Reminders: stats.ReminderCount,
+		},
+		StorageUsage: stats.StorageBytes,
+	})
 }
 
 func (h *HttpUserHandler) ChangeUsername(c *fiber.Ctx) error {
@@ -146,3 +249,92 @@ func (h *HttpUserHandler) ChangeUsername(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusBadRequest).SendString("Only 'username' field is allowed")
 }
+
+func (h *HttpUserHandler) ChangePassword(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	data := new(struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	})
+	if err := c.BodyParser(data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if data.CurrentPassword == "" || data.NewPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "'current_password' and 'new_password' are required"})
+	}
+
+	token, err := h.userUseCase.ChangePassword(uint(id), data.CurrentPassword, data.NewPassword)
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		case "invalid credentials":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Current password is incorrect"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not change password"})
+	}
+
+	// session อื่นถูกเพิกถอนแล้ว ออก token ใหม่ให้ session ปัจจุบัน
+	c.Cookie(&fiber.Cookie{
+		Name:     "jwt",
+		Value:    token,
+		Expires:  time.Now().Add(time.Hour * 72),
+		HTTPOnly: true,
+	})
+
+	return c.JSON(fiber.Map{"message": "Password changed successfully"})
+}
+
+func (h *HttpUserHandler) RequestEmailChange(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	data := new(struct {
+		NewEmail string `json:"new_email"`
+		Password string `json:"password"`
+	})
+	if err := c.BodyParser(data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.userUseCase.RequestEmailChange(uint(id), data.NewEmail, data.Password); err != nil {
+		switch err.Error() {
+		case "user not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		case "invalid credentials":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Password is incorrect"})
+		case "invalid email", "new email is the same as current email":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case "email already in use":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not send confirmation email"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Confirmation email sent to the new address"})
+}
+
+func (h *HttpUserHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	tokenString := c.Query("token")
+	if tokenString == "" {
+		return c.Status(fiber.StatusBadRequest).SendString("token is required")
+	}
+
+	if err := h.userUseCase.ConfirmEmailChange(tokenString); err != nil {
+		switch err.Error() {
+		case "user not found":
+			return c.Status(fiber.StatusNotFound).SendString("user not found")
+		case "email already in use":
+			return c.Status(fiber.StatusConflict).SendString("email already in use")
+		}
+		return c.Status(fiber.StatusUnauthorized).SendString("could not confirm email change")
+	}
+
+	return c.JSON(fiber.Map{"message": "email updated successfully"})
+}
\ No newline at end of file
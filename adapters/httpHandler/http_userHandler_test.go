@@ -0,0 +1,74 @@
+package httpHandler
+
+import (
+	"errors"
+	"miw/adapters/memoryRepository"
+	"miw/entities"
+	"miw/usecases/service"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type fakeUserUseCase struct {
+	service.UserUseCase
+}
+
+func (fakeUserUseCase) Login(email string, password string) (string, error) {
+	if email == "owner@example.com" && password == "correct" {
+		return "token", nil
+	}
+	return "", errors.New("invalid credentials")
+}
+
+type fakeLockoutEventRepo struct{}
+
+func (fakeLockoutEventRepo) CreateLockoutEvent(event *entities.LockoutEvent) error {
+	return nil
+}
+
+func TestLoginRateLimit(t *testing.T) {
+	limiter := service.NewRateLimitService(memoryRepository.NewMemoryRateLimitRepository(), fakeLockoutEventRepo{})
+	handler := NewHttpUserHandler(fakeUserUseCase{}, limiter)
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	app.Post("/login", handler.Login)
+
+	login := func(ip string, password string) (int, string) {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"owner@example.com","password":"`+password+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", ip)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter)
+	}
+
+	for i := 1; i < 5; i++ {
+		if status, _ := login("10.0.0.1", "wrong"); status != fiber.StatusUnauthorized {
+			t.Fatalf("attempt %d status = %d, want 401", i, status)
+		}
+	}
+	if status, retryAfter := login("10.0.0.1", "wrong"); status != fiber.StatusTooManyRequests || retryAfter != "60" {
+		t.Fatalf("fifth wrong password = %d Retry-After %q, want 429 and 60", status, retryAfter)
+	}
+
+	// IP ที่ถูกล็อกใช้ไม่ได้แม้รหัสผ่านถูก
+	if status, retryAfter := login("10.0.0.1", "correct"); status != fiber.StatusTooManyRequests || retryAfter == "" {
+		t.Fatalf("locked IP with the correct password = %d Retry-After %q", status, retryAfter)
+	}
+
+	// อีเมลที่ถูกล็อกจาก IP อื่น: รหัสผ่านผิดยังถูกปฏิเสธด้วย 429 แต่เจ้าของที่ใช้รหัสผ่านถูกยัง login ได้
+	if status, retryAfter := login("10.0.0.2", "wrong"); status != fiber.StatusTooManyRequests || retryAfter == "" {
+		t.Fatalf("locked email with a wrong password = %d Retry-After %q", status, retryAfter)
+	}
+	if status, _ := login("10.0.0.3", "correct"); status != fiber.StatusOK {
+		t.Fatalf("owner login while the email is locked = %d, want 200", status)
+	}
+	// login สำเร็จล้างการล็อกของอีเมล
+	if status, _ := login("10.0.0.4", "wrong"); status != fiber.StatusUnauthorized {
+		t.Fatalf("wrong password after a successful login = %d, want 401", status)
+	}
+}
@@ -0,0 +1,86 @@
+package memoryRepository
+
+import (
+	"miw/entities"
+	"sync"
+	"time"
+)
+
+type MemoryRateLimitRepository struct {
+	mu       sync.Mutex
+	counters map[string]*entities.RateLimitCounter
+}
+
+func NewMemoryRateLimitRepository() *MemoryRateLimitRepository {
+	return &MemoryRateLimitRepository{counters: make(map[string]*entities.RateLimitCounter)}
+}
+
+func (r *MemoryRateLimitRepository) GetCounter(key string) (*entities.RateLimitCounter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counter, ok := r.counters[key]
+	if !ok {
+		return nil, nil
+	}
+	// คืนค่าเป็นสำเนาเพื่อไม่ให้ผู้เรียกแก้ไขข้อมูลภายใน map โดยตรง
+	copied := *counter
+	return &copied, nil
+}
+
+func (r *MemoryRateLimitRepository) Increment(key string, window time.Duration, lockoutDecay time.Duration) (*entities.RateLimitCounter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	counter, ok := r.counters[key]
+	if !ok {
+		counter = &entities.RateLimitCounter{Key: key}
+		r.counters[key] = counter
+	}
+
+	// เริ่มหน้าต่างเวลาใหม่ถ้าหน้าต่างเดิมหมดอายุแล้ว
+	windowStart, err := time.ParseInLocation("2006-01-02 15:04:05", counter.WindowStart, time.Local)
+	if err != nil || now.Sub(windowStart) > window {
+		counter.Count = 0
+		counter.WindowStart = now.Format("2006-01-02 15:04:05")
+	}
+
+	// ล้างประวัติการล็อกเมื่อเงียบไปนานพอ เพื่อไม่ให้การล็อกสะสมไปตลอด (เช่น มีคนพยายาม login ด้วยอีเมลของผู้อื่นเป็นระยะ)
+	if lockedUntil, err := time.ParseInLocation("2006-01-02 15:04:05", counter.LockedUntil, time.Local); err == nil && now.Sub(lockedUntil) > lockoutDecay {
+		counter.LockoutCount = 0
+		counter.LockedUntil = ""
+	}
+
+	counter.Count++
+	counter.UpdatedAt = now.Format("2006-01-02 15:04:05")
+
+	copied := *counter
+	return &copied, nil
+}
+
+func (r *MemoryRateLimitRepository) Lock(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counter, ok := r.counters[key]
+	if !ok {
+		counter = &entities.RateLimitCounter{Key: key}
+		r.counters[key] = counter
+	}
+
+	counter.Count = 0
+	counter.WindowStart = ""
+	counter.LockedUntil = until.Format("2006-01-02 15:04:05")
+	counter.LockoutCount++
+	counter.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+	return nil
+}
+
+func (r *MemoryRateLimitRepository) Reset(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.counters, key)
+	return nil
+}
@@ -0,0 +1,48 @@
+package memoryRepository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitWindowRollover(t *testing.T) {
+	repo := NewMemoryRateLimitRepository()
+	for i := 1; i <= 3; i++ {
+		counter, err := repo.Increment("login:ip:1", time.Minute, time.Hour)
+		if err != nil || counter.Count != i {
+			t.Fatalf("Increment #%d = %+v, %v", i, counter, err)
+		}
+	}
+
+	// หน้าต่างเดิมหมดอายุ ตัวนับเริ่มใหม่
+	repo.counters["login:ip:1"].WindowStart = time.Now().Add(-2 * time.Minute).Format("2006-01-02 15:04:05")
+	if counter, _ := repo.Increment("login:ip:1", time.Minute, time.Hour); counter.Count != 1 {
+		t.Fatalf("count after the window expired = %d, want 1", counter.Count)
+	}
+}
+
+func TestMemoryRateLimitLockoutDecay(t *testing.T) {
+	repo := NewMemoryRateLimitRepository()
+	repo.Increment("k", time.Minute, time.Hour)
+	repo.Lock("k", time.Now().Add(time.Minute))
+	repo.Lock("k", time.Now().Add(time.Minute))
+
+	// ยังอยู่ในช่วงล็อก ประวัติการล็อกยังอยู่
+	if counter, _ := repo.Increment("k", time.Minute, time.Hour); counter.LockoutCount != 2 || counter.Count != 1 {
+		t.Fatalf("counter during lockout = %+v", counter)
+	}
+
+	// การล็อกครั้งล่าสุดหมดไปนานกว่า lockoutDecay แล้ว
+	repo.Lock("k", time.Now().Add(-2*time.Hour))
+	counter, _ := repo.Increment("k", time.Minute, time.Hour)
+	if counter.LockoutCount != 0 || counter.LockedUntil != "" {
+		t.Fatalf("counter after a quiet period = %+v", counter)
+	}
+
+	if err := repo.Reset("k"); err != nil {
+		t.Fatal(err)
+	}
+	if counter, _ := repo.GetCounter("k"); counter != nil {
+		t.Fatalf("counter after Reset = %+v", counter)
+	}
+}
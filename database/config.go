@@ -1,18 +1,55 @@
 package database
 
 import (
+	"github.com/joho/godotenv"
 	"log"
 	"os"
-	"github.com/joho/godotenv"
+	"strconv"
+	"strings"
 )
 
 type Config struct {
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	JWTSecret  string
+	DBHost         string
+	DBPort         string
+	DBUser         string
+	DBPassword     string
+	DBName         string
+	JWTSecret      string
+	RateLimitStore string
+	OIDCProviders  []OIDCProviderConfig
+
+	// ที่เก็บไฟล์แนบ: "local" (ค่าเริ่มต้น เก็บใน AttachmentDir) หรือ "s3"
+	AttachmentStore    string
+	AttachmentDir      string
+	AttachmentMaxBytes int64
+	AttachmentTypes    []string
+	StorageQuotaBytes  int64
+	ThumbnailWorkers   int // จำนวน goroutine ที่สร้างรูปย่อ
+	S3                 S3Config
+
+	ImportMaxBytes int64 // ขนาดสูงสุดของไฟล์ที่นำเข้า
+	ImportWorkers  int   // จำนวนงานนำเข้าที่ทำพร้อมกัน
+
+	PrintFontFile string // ไฟล์ฟอนต์ TTF ที่มีอักษรไทย เช่น Sarabun หรือ Noto Sans Thai (ไม่ตั้ง = ปิดการสร้าง PDF)
+}
+
+// S3Config ตั้งค่าที่เก็บไฟล์แบบ S3-compatible อ่านจาก S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// OIDCProviderConfig ตั้งค่าผู้ให้บริการ OpenID Connect หนึ่งราย
+// อ่านจาก OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
 }
 
 func LoadConfig() *Config {
@@ -22,11 +59,89 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
-		JWTSecret:  os.Getenv("JWT_SECRET"),
+		DBHost:         os.Getenv("DB_HOST"),
+		DBPort:         os.Getenv("DB_PORT"),
+		DBUser:         os.Getenv("DB_USER"),
+		DBPassword:     os.Getenv("DB_PASSWORD"),
+		DBName:         os.Getenv("DB_NAME"),
+		JWTSecret:      os.Getenv("JWT_SECRET"),
+		RateLimitStore: os.Getenv("RATE_LIMIT_STORE"), // "memory" (ค่าเริ่มต้น) หรือ "postgres"
+		OIDCProviders:  loadOIDCProviders(),
+
+		AttachmentStore:    os.Getenv("ATTACHMENT_STORE"),
+		AttachmentDir:      envOrDefault("ATTACHMENT_DIR", "./uploads"),
+		AttachmentMaxBytes: envInt64("ATTACHMENT_MAX_BYTES", 10<<20),         // 10 MB ต่อไฟล์
+		AttachmentTypes:    splitList(os.Getenv("ATTACHMENT_ALLOWED_TYPES")), // ว่าง = ใช้ชนิดไฟล์เริ่มต้น
+		StorageQuotaBytes:  envInt64("STORAGE_QUOTA_BYTES", 100<<20),         // 100 MB ต่อผู้ใช้ (0 = ไม่จำกัด)
+		ThumbnailWorkers:   int(envInt64("THUMBNAIL_WORKERS", 2)),
+		S3: S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		},
+
+		ImportMaxBytes: envInt64("IMPORT_MAX_BYTES", 100<<20), // 100 MB
+		ImportWorkers:  int(envInt64("IMPORT_WORKERS", 1)),
+
+		PrintFontFile: os.Getenv("PRINT_FONT_FILE"),
+	}
+}
+
+func envOrDefault(name, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func envInt64(name string, defaultValue int64) int64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 {
+		log.Printf("Invalid %s=%q, using default %d.", name, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// splitList แยกค่าที่คั่นด้วย comma และตัดช่องว่าง
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadOIDCProviders อ่านรายชื่อผู้ให้บริการจาก OIDC_PROVIDERS (คั่นด้วย comma เช่น "google,microsoft")
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			log.Printf("OIDC provider %q is missing issuer, client id or redirect url, skipping.", name)
+			continue
+		}
+		providers = append(providers, provider)
 	}
+	return providers
 }
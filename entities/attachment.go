@@ -0,0 +1,72 @@
+package entities
+
+// สถานะการสร้าง thumbnail ของไฟล์แนบ
+const (
+	ThumbnailStatusNone    = "none" // ไม่ใช่รูปภาพ
+	ThumbnailStatusPending = "pending"
+	ThumbnailStatusReady   = "ready"
+	ThumbnailStatusFailed  = "failed"
+)
+
+// Attachment ไฟล์ที่แนบกับโน้ต ตัวไฟล์เก็บใน BlobStore ตาม StorageKey
+type Attachment struct {
+	AttachmentID    uint                  `json:"attachment_id" gorm:"primaryKey"`
+	NoteID          uint                  `json:"note_id" gorm:"index"`
+	UserID          uint                  `json:"user_id" gorm:"index"`
+	FileName        string                `json:"file_name"`
+	ContentType     string                `json:"content_type"` // ตรวจจากเนื้อไฟล์จริง ไม่ใช้ค่าที่ client ส่งมา
+	Size            int64                 `json:"size"`
+	Checksum        string                `json:"checksum"` // SHA-256 (hex)
+	StorageKey      string                `json:"-" gorm:"uniqueIndex"`
+	Width           int                   `json:"width"` // เฉพาะรูปภาพ
+	Height          int                   `json:"height"`
+	ThumbnailStatus string                `json:"thumbnail_status" gorm:"default:'none'"`
+	Thumbnails      []AttachmentThumbnail `json:"thumbnails" gorm:"serializer:json"`
+	CreatedAt       string                `json:"created_at"`
+}
+
+// AttachmentThumbnail รูปย่อของไฟล์แนบหนึ่งขนาด ตัวไฟล์เก็บใน BlobStore ตาม ThumbnailKey
+type AttachmentThumbnail struct {
+	Size        string `json:"size"` // small, medium, large
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+}
+
+// ThumbnailSize ขนาดรูปย่อ MaxSide คือความยาวด้านที่ยาวที่สุด (pixel)
+type ThumbnailSize struct {
+	Name    string
+	MaxSide int
+}
+
+// ThumbnailSizes ขนาดรูปย่อที่สร้างให้ไฟล์แนบรูปภาพ เรียงจากเล็กไปใหญ่
+var ThumbnailSizes = []ThumbnailSize{
+	{Name: "small", MaxSide: 128},
+	{Name: "medium", MaxSide: 320},
+	{Name: "large", MaxSide: 640},
+}
+
+// ThumbnailKey key ของรูปย่อใน BlobStore
+func (a *Attachment) ThumbnailKey(size string) string {
+	return a.StorageKey + "_thumb_" + size
+}
+
+// BlobKeys key ทั้งหมดใน BlobStore ของไฟล์แนบ (ตัวไฟล์และรูปย่อ)
+// รูปภาพจะรวม key ของรูปย่อทุกขนาดเสมอ เพราะ worker อาจกำลังเขียนรูปย่ออยู่ตอนที่ไฟล์แนบถูกลบ
+func (a *Attachment) BlobKeys() []string {
+	keys := []string{a.StorageKey}
+	if a.ThumbnailStatus == "" || a.ThumbnailStatus == ThumbnailStatusNone {
+		return keys
+	}
+	seen := map[string]bool{}
+	for _, size := range ThumbnailSizes {
+		seen[size.Name] = true
+		keys = append(keys, a.ThumbnailKey(size.Name))
+	}
+	for _, thumbnail := range a.Thumbnails {
+		if !seen[thumbnail.Size] {
+			keys = append(keys, a.ThumbnailKey(thumbnail.Size))
+		}
+	}
+	return keys
+}
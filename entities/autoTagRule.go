@@ -0,0 +1,15 @@
+package entities
+
+// AutoTagRule กฎติดแท็กอัตโนมัติของผู้ใช้ เช่น title contains "invoice" -> tag "finance"
+type AutoTagRule struct {
+	RuleID    uint   `json:"rule_id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"index"`
+	Name      string `json:"name"`
+	Field     string `json:"field"`    // title, content, color, priority, is_todo, has_reminder
+	Operator  string `json:"operator"` // contains, equals, gte, lte, is
+	Value     string `json:"value"`
+	TagID     uint   `json:"tag_id"`
+	Enabled   bool   `json:"enabled"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package entities
+
+// แหล่งข้อมูลที่นำเข้าได้
+const (
+	ImportSourceKeep     = "keep"     // ZIP จาก Google Takeout (Keep)
+	ImportSourceMarkdown = "markdown" // ZIP ของโฟลเดอร์ไฟล์ Markdown
+	ImportSourceEnex     = "enex"     // ไฟล์ .enex (XML) ที่ export จาก Evernote
+)
+
+// สถานะของงานนำเข้า
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// ImportJob งานนำเข้าโน้ตที่ทำแบบ background ไฟล์ที่อัปโหลดเก็บใน BlobStore ตาม StorageKey จนกว่างานจะจบ
+type ImportJob struct {
+	JobID      uint          `json:"job_id" gorm:"primaryKey"`
+	UserID     uint          `json:"user_id" gorm:"index"`
+	Source     string        `json:"source"`
+	FileName   string        `json:"file_name"`
+	StorageKey string        `json:"-"` // ค่าว่างหลังงานจบและลบไฟล์แล้ว
+	Status     string        `json:"status" gorm:"index"`
+	Total      int           `json:"total"`     // จำนวนโน้ตในไฟล์
+	Processed  int           `json:"processed"` // จำนวนที่ทำไปแล้ว (สำเร็จ + ล้มเหลว)
+	Imported   int           `json:"imported"`
+	Failed     int           `json:"failed"`
+	Errors     []ImportError `json:"errors" gorm:"serializer:json"` // รายงานโน้ตที่นำเข้าไม่สำเร็จ หรือนำเข้าได้ไม่ครบ
+	Error      string        `json:"error"`                         // สาเหตุที่ทั้งงานล้มเหลว เช่น ไฟล์ ZIP เสีย
+	CreatedAt  string        `json:"created_at"`
+	StartedAt  string        `json:"started_at"`
+	FinishedAt string        `json:"finished_at"`
+}
+
+// ImportError ปัญหาของโน้ตหนึ่งรายการ Item คือชื่อไฟล์หรือชื่อโน้ตในไฟล์ต้นทาง
+type ImportError struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
@@ -3,8 +3,10 @@ package entities
 type Note struct {
 	NoteID     uint       `json:"note_id" gorm:"primaryKey"`
 	UserID     uint       `json:"user_id"`
+	NotebookID *uint      `json:"notebook_id" gorm:"index"` // สมุดโน้ตที่โน้ตอยู่ (nil = ไม่อยู่ในสมุดใด)
 	Title      string     `json:"title"`
 	Content    string     `json:"content"`
+	ContentFormat string  `json:"content_format" gorm:"default:'plain'"` // plain หรือ markdown
 	Color      string     `json:"color"`
 	Priority   int        `json:"priority"`
 	IsTodo     bool       `json:"is_todo"`
@@ -13,9 +15,13 @@ type Note struct {
 	CreatedAt  string     `json:"created_at"`
 	UpdatedAt  string     `json:"updated_at"`
 	DeletedAt  string     `json:"deleted_at"`
+	IsPinned   bool       `json:"is_pinned" gorm:"default:false"`
+	ArchivedAt string     `json:"archived_at" gorm:"default:''"` // ค่าว่าง = ยังไม่ถูกเก็บเข้าคลัง (default ให้โน้ตเดิมได้ค่าว่างตอน migrate)
+	Position   string     `json:"position" gorm:"index;default:''"` // คีย์ลำดับแบบ lexicographic ภายในสมุดโน้ต (ค่าว่าง = ยังไม่เคยจัดลำดับหรือเพิ่งย้ายเข้าสมุด)
 	Tags       []Tag      `gorm:"many2many:note_tags;joinForeignKey:NoteID;joinReferences:TagID;constraint:OnDelete:CASCADE;"`
 	Reminder  []Reminder `gorm:"foreignKey:NoteID"`
 	Event      Event      `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE;"`
+	Attachments []Attachment `gorm:"foreignKey:NoteID" json:"-"` // สร้างได้ผ่าน AttachmentService เท่านั้น response ใช้ AttachmentResponse
 }
 
 type ToDo struct {
@@ -37,9 +43,13 @@ type Reminder struct {
 
 type Tag struct {
 	TagID   uint   `json:"tag_id" gorm:"primaryKey"`
-	TagName string `json:"tag_name" gorm:"unique"`
-	UserID  uint   `json:"user_id"`
+	TagName string `json:"tag_name" gorm:"uniqueIndex:idx_tags_user_tag_name,priority:2"` // ชื่อแท็กห้ามซ้ำเฉพาะภายใน User เดียวกัน
+	UserID  uint   `json:"user_id" gorm:"uniqueIndex:idx_tags_user_tag_name,priority:1"`
+	ParentTagID *uint `json:"parent_tag_id" gorm:"index"` // แท็กแม่ (nil = แท็กระดับบนสุด)
+	Color   string `json:"color"`
+	Icon    string `json:"icon"`
     Notes   []Note `gorm:"many2many:note_tags;joinForeignKey:TagID;joinReferences:NoteID;constraint:OnDelete:CASCADE;"`
+	NoteCount int64 `json:"note_count" gorm:"->;-:migration"` // คำนวณจาก query เท่านั้น ไม่มีคอลัมน์จริง
 }
 
 type ShareNote struct {
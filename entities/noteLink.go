@@ -0,0 +1,11 @@
+package entities
+
+// NoteLink ลิงก์ [[Note Title]] หรือ [[note:123]] จากเนื้อหาของโน้ตหนึ่งไปยังอีกโน้ตหนึ่ง
+type NoteLink struct {
+	LinkID       uint   `json:"link_id" gorm:"primaryKey"`
+	UserID       uint   `json:"user_id" gorm:"index"`
+	SourceNoteID uint   `json:"source_note_id" gorm:"index"`
+	TargetNoteID *uint  `json:"target_note_id" gorm:"index"` // nil = ยังไม่มีโน้ตที่ตรงกับลิงก์
+	TargetTitle  string `json:"target_title"`                // ว่าง = ลิงก์ด้วย id แบบ [[note:123]]
+	CreatedAt    string `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package entities
+
+// TemplateTodo รายการ To-Do ในแม่แบบ (ทุกรายการเริ่มต้นเป็นยังไม่เสร็จเมื่อสร้างโน้ต)
+type TemplateTodo struct {
+	Content string `json:"content"`
+}
+
+// NoteTemplate แม่แบบโน้ต ข้อความใน title, content และ To-Do ใช้ placeholder เช่น {{date}} และ {{weekday}} ได้
+type NoteTemplate struct {
+	TemplateID    uint           `json:"template_id" gorm:"primaryKey"`
+	UserID        uint           `json:"user_id" gorm:"index"`
+	Name          string         `json:"name"`
+	Title         string         `json:"title"`
+	Content       string         `json:"content"`
+	ContentFormat string         `json:"content_format" gorm:"default:'plain'"` // plain หรือ markdown
+	Color         string         `json:"color"`
+	Priority      int            `json:"priority"`
+	IsTodo        bool           `json:"is_todo"`
+	TodoItems     []TemplateTodo `json:"todo_items" gorm:"serializer:json"`
+	TagIDs        []uint         `json:"tag_ids" gorm:"serializer:json"`
+	NotebookID    *uint          `json:"notebook_id"` // สมุดที่โน้ตใหม่จะอยู่ (nil = Inbox)
+	CreatedAt     string         `json:"created_at"`
+	UpdatedAt     string         `json:"updated_at"`
+}
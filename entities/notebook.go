@@ -0,0 +1,18 @@
+package entities
+
+// ชื่อสมุดโน้ตเริ่มต้นที่สร้างให้ผู้ใช้ทุกคนตอนสมัคร
+const DefaultNotebookName = "Inbox"
+
+// Notebook สมุดโน้ตสำหรับจัดกลุ่มโน้ต ซ้อนกันได้ผ่าน ParentNotebookID
+type Notebook struct {
+	NotebookID       uint   `json:"notebook_id" gorm:"primaryKey"`
+	UserID           uint   `json:"user_id" gorm:"index"`
+	Name             string `json:"name"`
+	Color            string `json:"color"`
+	Position         int    `json:"position"`                        // ลำดับการแสดงผล (น้อยไปมาก)
+	ParentNotebookID *uint  `json:"parent_notebook_id" gorm:"index"` // สมุดแม่ (nil = ระดับบนสุด)
+	IsDefault        bool   `json:"is_default"`                      // สมุด Inbox ลบไม่ได้
+	CreatedAt        string `json:"created_at"`
+	UpdatedAt        string `json:"updated_at"`
+	NoteCount        int64  `json:"note_count" gorm:"->;-:migration"` // คำนวณจาก query เท่านั้น ไม่มีคอลัมน์จริง
+}
@@ -0,0 +1,21 @@
+package entities
+
+// RateLimitCounter เก็บจำนวนครั้งที่เรียกใช้/ล้มเหลวของแต่ละ key (เช่น login:ip:1.2.3.4)
+type RateLimitCounter struct {
+	Key          string `json:"key" gorm:"primaryKey"`
+	Count        int    `json:"count"`
+	WindowStart  string `json:"window_start"`
+	LockedUntil  string `json:"locked_until"`
+	LockoutCount int    `json:"lockout_count"` // จำนวนครั้งที่ถูกล็อก ใช้คำนวณเวลาล็อกแบบทวีคูณ
+	UpdatedAt    string `json:"updated_at"`
+}
+
+// LockoutEvent บันทึกเหตุการณ์การล็อกเพื่อใช้ตรวจสอบย้อนหลัง
+type LockoutEvent struct {
+	EventID     uint   `json:"event_id" gorm:"primaryKey"`
+	Scope       string `json:"scope"`
+	Key         string `json:"key" gorm:"index"`
+	Attempts    int    `json:"attempts"`
+	LockedUntil string `json:"locked_until"`
+	CreatedAt   string `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package entities
+
+// NoteFilter เงื่อนไขค้นหาโน้ต ทุกเงื่อนไขที่ระบุต้องเป็นจริงพร้อมกัน (ค่าว่าง/nil = ไม่กรอง)
+type NoteFilter struct {
+	Query              string `json:"query"`               // ค้นหาใน title, content และ todo items
+	TagIDs             []uint `json:"tag_ids"`             // โน้ตต้องมีแท็กใดแท็กหนึ่งในรายการ
+	IncludeDescendants bool   `json:"include_descendants"` // รวมแท็กลูกหลานของ TagIDs
+	Color              string `json:"color"`
+	MinPriority        *int   `json:"min_priority"`
+	MaxPriority        *int   `json:"max_priority"`
+	IsTodo             *bool  `json:"is_todo"`
+	IsAllDone          *bool  `json:"is_all_done"`
+	CreatedFrom        string `json:"created_from"` // รูปแบบ 2006-01-02
+	CreatedTo          string `json:"created_to"`
+	UpdatedFrom        string `json:"updated_from"`
+	UpdatedTo          string `json:"updated_to"`
+	IncludeArchived    bool   `json:"include_archived"` // รวมโน้ตที่เก็บเข้าคลังแล้ว
+}
+
+// SavedSearch การค้นหาที่ผู้ใช้บันทึกไว้ (smart folder) ประเมินผลใหม่ทุกครั้งที่เปิด
+type SavedSearch struct {
+	SearchID  uint       `json:"search_id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	Name      string     `json:"name"`
+	Filter    NoteFilter `json:"filter" gorm:"serializer:json"`
+	SortBy    string     `json:"sort_by"`    // updated_at, created_at, title, priority, position
+	SortOrder string     `json:"sort_order"` // asc, desc
+	IsPinned  bool       `json:"is_pinned"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+}
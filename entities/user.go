@@ -7,7 +7,46 @@ type User struct {
 	Email               string  `json:"email" gorm:"unique"`
 	Password            string  `json:"password"`
 	GoogleCalendarToken string  `json:"google_calendar_token"`
+	Timezone            string  `json:"timezone"`
+	Preferences         map[string]interface{} `json:"preferences" gorm:"serializer:json"`
+	TokenVersion        int     `json:"-"` // เพิ่มค่าเมื่อต้องการเพิกถอน session ทั้งหมด
 	Notes               []Note  `gorm:"foreignKey:UserID"`
 	SharedNotes         []ShareNote `gorm:"foreignKey:SharedWith"`
 }
 
+// UserIdentity เชื่อมบัญชีผู้ใช้กับตัวตนจากผู้ให้บริการภายนอก (OIDC)
+type UserIdentity struct {
+	IdentityID uint   `json:"identity_id" gorm:"primaryKey"`
+	UserID     uint   `json:"user_id" gorm:"index"`
+	Provider   string `json:"provider" gorm:"uniqueIndex:idx_identity_provider_subject"`
+	Subject    string `json:"subject" gorm:"uniqueIndex:idx_identity_provider_subject"`
+	Email      string `json:"email"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// UserData รวบรวมข้อมูลทั้งหมดที่ผู้ใช้เป็นเจ้าของ ใช้สำหรับ export ข้อมูลส่วนบุคคล
+type UserData struct {
+	User          User
+	Notes         []Note
+	TodoItems     []ToDo
+	Tags          []Tag
+	Reminders     []Reminder
+	Events        []Event
+	Shares        []ShareNote
+	Identities    []UserIdentity
+	AutoTagRules  []AutoTagRule
+	SavedSearches []SavedSearch
+	Notebooks     []Notebook
+	Templates     []NoteTemplate
+	Attachments   []Attachment
+	NoteLinks     []NoteLink
+	ImportJobs    []ImportJob
+}
+
+// UserStats สรุปจำนวนข้อมูลและพื้นที่ที่ผู้ใช้ใช้งาน (หน่วยเป็น byte)
+type UserStats struct {
+	NoteCount     int64 `json:"notes"`
+	TagCount      int64 `json:"tags"`
+	ReminderCount int64 `json:"reminders"`
+	StorageBytes  int64 `json:"storage_bytes"`
+}
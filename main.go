@@ -3,13 +3,18 @@ package main
 import (
 	"fmt"
 	"log"
+	"miw/adapters/blobStore"
 	"miw/adapters/gormRepository"
 	"miw/adapters/httpHandler"
+	"miw/adapters/memoryRepository"
 	"miw/database"
 	"miw/entities"
 	"miw/middleware"
+	"miw/usecases/repository"
 	"miw/usecases/service"
+	"os"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
 func main() {
@@ -31,31 +36,145 @@ func main() {
 		&entities.ShareNote{},
 		&entities.Event{},
 		&entities.ToDo{},
+		&entities.RateLimitCounter{},
+		&entities.LockoutEvent{},
+		&entities.UserIdentity{},
+		&entities.AutoTagRule{},
+		&entities.SavedSearch{},
+		&entities.Notebook{},
+		&entities.NoteTemplate{},
+		&entities.Attachment{},
+		&entities.NoteLink{},
+		&entities.ImportJob{},
 	)
 
 	if err != nil {
 		log.Fatal("Failed to migrate tables:", err)
 	}
 
+	// ลบ unique constraint เดิมบน tags.tag_name (เปลี่ยนเป็น unique ต่อ user แทน)
+	for _, constraint := range []string{"uni_tags_tag_name", "tags_tag_name_key"} {
+		if database.Migrator().HasConstraint(&entities.Tag{}, constraint) {
+			if err := database.Migrator().DropConstraint(&entities.Tag{}, constraint); err != nil {
+				log.Fatal("Failed to drop legacy tag constraint:", err)
+			}
+		}
+	}
+
 	// สร้าง Repository และ Service
 	userRepo := gormRepository.NewGormUserRepository(database)
 	noteRepo := gormRepository.NewGormNoteRepository(database)
 	tagRepo := gormRepository.NewGormTagRepository(database)
 	reminderRepo := gormRepository.NewGormReminderRepository(database)
+	lockoutEventRepo := gormRepository.NewGormLockoutEventRepository(database)
+	identityRepo := gormRepository.NewGormIdentityRepository(database)
+	ruleRepo := gormRepository.NewGormAutoTagRuleRepository(database)
+	savedSearchRepo := gormRepository.NewGormSavedSearchRepository(database)
+	notebookRepo := gormRepository.NewGormNotebookRepository(database)
+	templateRepo := gormRepository.NewGormNoteTemplateRepository(database)
+	attachmentRepo := gormRepository.NewGormAttachmentRepository(database)
+	linkRepo := gormRepository.NewGormNoteLinkRepository(database)
+	importJobRepo := gormRepository.NewGormImportJobRepository(database)
+
+	// เลือกที่เก็บตัวนับของ rate limiter ตาม config
+	var rateLimitRepo repository.RateLimitRepository = memoryRepository.NewMemoryRateLimitRepository()
+	if cfg.RateLimitStore == "postgres" {
+		rateLimitRepo = gormRepository.NewGormRateLimitRepository(database)
+	}
+
+	// เลือกที่เก็บไฟล์แนบตาม config
+	var attachmentStore repository.BlobStore
+	if cfg.AttachmentStore == "s3" {
+		attachmentStore, err = blobStore.NewS3BlobStore(blobStore.S3Config(cfg.S3))
+	} else {
+		attachmentStore, err = blobStore.NewLocalBlobStore(cfg.AttachmentDir)
+	}
+	if err != nil {
+		log.Fatal("Failed to set up attachment storage:", err)
+	}
 
 	userService := service.NewUserService(userRepo)
-	noteService := service.NewNoteService(noteRepo)
-	tagService := service.NewTagService(tagRepo)
 	reminderService := service.NewReminderService(reminderRepo, noteRepo, userRepo)
+	noteService := service.NewNoteService(noteRepo, ruleRepo, notebookRepo, linkRepo, reminderService, attachmentStore)
+	tagService := service.NewTagService(tagRepo)
+	rateLimitService := service.NewRateLimitService(rateLimitRepo, lockoutEventRepo)
+
+	var oidcProviders []service.OIDCProviderConfig
+	for _, p := range cfg.OIDCProviders {
+		oidcProviders = append(oidcProviders, service.OIDCProviderConfig{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+		})
+	}
+	oidcService := service.NewOIDCService(oidcProviders, userRepo, identityRepo, userService)
+	accountService := service.NewAccountService(userRepo, reminderService, attachmentStore)
+	ruleService := service.NewAutoTagRuleService(ruleRepo, tagRepo, noteRepo)
+	notebookService := service.NewNotebookService(notebookRepo)
+	savedSearchService := service.NewSavedSearchService(savedSearchRepo, noteRepo, tagRepo, tagService, notebookService)
+	thumbnailWorker := service.NewThumbnailWorker(attachmentRepo, attachmentStore, cfg.ThumbnailWorkers)
+	thumbnailWorker.Start()
+	attachmentService := service.NewAttachmentService(attachmentRepo, noteRepo, attachmentStore, thumbnailWorker, service.AttachmentLimits{
+		MaxFileBytes: cfg.AttachmentMaxBytes,
+		QuotaBytes:   cfg.StorageQuotaBytes,
+		AllowedTypes: cfg.AttachmentTypes,
+	})
+	exportService := service.NewExportService(noteRepo, notebookRepo)
+	importWorker := service.NewImportWorker(importJobRepo, noteRepo, tagRepo, reminderRepo, noteService, notebookService, reminderService, attachmentService, attachmentStore, cfg.ImportWorkers)
+	importWorker.Start()
+	importService := service.NewImportService(importJobRepo, attachmentStore, importWorker, cfg.ImportMaxBytes)
+	var printFont []byte
+	if cfg.PrintFontFile != "" {
+		printFont, err = os.ReadFile(cfg.PrintFontFile)
+		if err != nil {
+			log.Fatal("Failed to read print font:", err)
+		}
+		if err := service.ValidatePrintFont(printFont); err != nil {
+			log.Fatal("Invalid print font:", err)
+		}
+	} else {
+		log.Println("PRINT_FONT_FILE is not set: PDF printing is disabled")
+	}
+	printService := service.NewPrintService(noteRepo, printFont)
+	templateService := service.NewNoteTemplateService(templateRepo, noteRepo, tagRepo, notebookRepo, userRepo, noteService)
 
 	// สร้าง Handlers สำหรับ HTTP
-	userHandler := httpHandler.NewHttpUserHandler(userService)
+	userHandler := httpHandler.NewHttpUserHandler(userService, rateLimitService)
 	noteHandler := httpHandler.NewHttpNoteHandler(noteService)
 	tagHandler := httpHandler.NewHttpTagHandler(tagService)
 	reminderHandler := httpHandler.NewHttpReminderHandler(reminderService)
+	oidcHandler := httpHandler.NewHttpOIDCHandler(oidcService)
+	accountHandler := httpHandler.NewHttpAccountHandler(accountService)
+	ruleHandler := httpHandler.NewHttpAutoTagRuleHandler(ruleService)
+	savedSearchHandler := httpHandler.NewHttpSavedSearchHandler(savedSearchService)
+	notebookHandler := httpHandler.NewHttpNotebookHandler(notebookService)
+	templateHandler := httpHandler.NewHttpNoteTemplateHandler(templateService)
+	attachmentHandler := httpHandler.NewHttpAttachmentHandler(attachmentService)
+	exportHandler := httpHandler.NewHttpExportHandler(exportService)
+	importHandler := httpHandler.NewHttpImportHandler(importService)
+	printHandler := httpHandler.NewHttpPrintHandler(printService)
+
+	// ให้ AuthMiddleware ตรวจสอบว่า session ถูกเพิกถอนหรือไม่
+	middleware.TokenVersionLookup = func(userID uint) (int, error) {
+		user, err := userRepo.GetUserById(userID)
+		if err != nil {
+			return 0, err
+		}
+		return user.TokenVersion, nil
+	}
 
 	// สร้าง Fiber App และเพิ่ม Middleware
-	app := fiber.New()
+	// เพิ่มขนาด body สูงสุดให้รองรับไฟล์แนบและไฟล์นำเข้า (เผื่อ 1 MB สำหรับส่วนหัวของ multipart)
+	bodyLimit := fiber.DefaultBodyLimit
+	for _, maxBytes := range []int64{cfg.AttachmentMaxBytes, cfg.ImportMaxBytes} {
+		if limit := int(maxBytes) + 1<<20; limit > bodyLimit {
+			bodyLimit = limit
+		}
+	}
+	app := fiber.New(fiber.Config{BodyLimit: bodyLimit})
+	app.Use(recover.New(recover.Config{EnableStackTrace: true})) // panic ใน handler ตอบ 500 แทนการทำให้เซิร์ฟเวอร์ล่ม
 
 	//********************************************
 	// User
@@ -65,9 +184,18 @@ func main() {
 
 	app.Post("/forgot-password", userHandler.ForgotPassword)
 	app.Post("/reset-password", userHandler.ResetPassword)
+	app.Get("/confirm-email", userHandler.ConfirmEmailChange) // ยืนยันการเปลี่ยนอีเมลจากลิงก์
+
+	app.Get("/auth/oidc/:provider/login", oidcHandler.LoginHandler)       // login ผ่าน OpenID Connect
+	app.Get("/auth/oidc/:provider/callback", oidcHandler.CallbackHandler) // callback จากผู้ให้บริการ
 
 	app.Get("/user/:userid", middleware.AuthMiddleware, userHandler.GetUser)        // ดูข้อมูล user
 	app.Put("/user/:userid", middleware.AuthMiddleware, userHandler.ChangeUsername) // แก้ไข username
+	app.Put("/user/:userid/password", middleware.AuthMiddleware, userHandler.ChangePassword)       // เปลี่ยนรหัสผ่าน
+	app.Post("/user/:userid/email", middleware.AuthMiddleware, userHandler.RequestEmailChange)     // ขอเปลี่ยนอีเมล
+	app.Delete("/user/:userid", middleware.AuthMiddleware, accountHandler.DeleteAccountHandler) // ลบบัญชี
+	app.Post("/user/:userid/delete-confirmation", middleware.AuthMiddleware, accountHandler.RequestDeletionHandler) // ขอรหัสยืนยันการลบบัญชีทางอีเมล (บัญชีที่ไม่มีรหัสผ่าน)
+	app.Get("/user/:userid/export", middleware.AuthMiddleware, accountHandler.ExportHandler)    // export ข้อมูลส่วนบุคคล
 
 	//********************************************
 	// Note
@@ -78,8 +206,19 @@ func main() {
 	app.Put("/note/priority/:noteid", middleware.AuthMiddleware, noteHandler.UpdatePriorityHandler)
 	app.Put("/note/title-content/:noteid", middleware.AuthMiddleware, noteHandler.UpdateTitleAndContentHandler)
 	app.Put("/note/status/:noteid", middleware.AuthMiddleware, noteHandler.UpdateStatusHandler)
+	app.Patch("/note/:noteid", middleware.AuthMiddleware, noteHandler.PatchNoteHandler) // แก้ไขหลายฟิลด์พร้อมกัน (JSON Merge Patch)
+	app.Post("/note/batch", middleware.AuthMiddleware, noteHandler.BatchNotesHandler) // ทำคำสั่งกับหลายโน้ตพร้อมกัน
+	app.Post("/note/:noteid/duplicate", middleware.AuthMiddleware, noteHandler.DuplicateNoteHandler) // คัดลอก note
+	app.Get("/note/:noteid/backlinks", middleware.AuthMiddleware, noteHandler.GetBacklinksHandler) // ดู note ที่ลิงก์มายัง note นี้ ([[...]])
 	app.Delete("/note/:noteid",middleware.AuthMiddleware, noteHandler.DeleteNoteHandler) // ลบ note
 	app.Put("/note/restore/:noteid",middleware.AuthMiddleware, noteHandler.RestoreNoteHandler)
+	app.Delete("/note/:noteid/permanent", middleware.AuthMiddleware, noteHandler.PurgeNoteHandler) // ลบ note ในถังขยะถาวร (รวมไฟล์แนบ)
+	app.Get("/note/:userid/archived", middleware.AuthMiddleware, noteHandler.GetArchivedNotesHandler) // ดู note ที่เก็บเข้าคลัง
+	app.Put("/note/pin/:noteid", middleware.AuthMiddleware, noteHandler.PinNoteHandler)
+	app.Put("/note/unpin/:noteid", middleware.AuthMiddleware, noteHandler.UnpinNoteHandler)
+	app.Put("/note/archive/:noteid", middleware.AuthMiddleware, noteHandler.ArchiveNoteHandler)
+	app.Put("/note/unarchive/:noteid", middleware.AuthMiddleware, noteHandler.UnarchiveNoteHandler)
+	app.Put("/note/position/:noteid", middleware.AuthMiddleware, noteHandler.MoveNoteHandler) // ย้ายลำดับ note (drag-and-drop)
 	//********************************************
 	// Add Tag to Note And Remove Tag from Note
 	//********************************************
@@ -97,9 +236,83 @@ func main() {
 	// Tag
 	//********************************************
 	app.Post("/tag", middleware.AuthMiddleware, tagHandler.CreateTagHandler) // สร้าง tag
+	app.Get("/tag", middleware.AuthMiddleware, tagHandler.GetAllTagsHandler) // ดู tag ทั้งหมดพร้อมจำนวนโน้ต
+	app.Post("/tag/merge", middleware.AuthMiddleware, tagHandler.MergeTagsHandler) // รวม tag ที่ซ้ำกัน
+	app.Post("/tag/rename-prefix", middleware.AuthMiddleware, tagHandler.RenameTagsByPrefixHandler) // เปลี่ยนชื่อ tag ตาม prefix
 	app.Get("/tag/:tagid",middleware.AuthMiddleware,  tagHandler.GetTagHandler) // ดู tag
 	app.Put("/tag/:tagid", middleware.AuthMiddleware, tagHandler.UpdateTagNameHandler) // แก้ไขชื่อ tag
 	app.Delete("/tag/:tagid", middleware.AuthMiddleware, tagHandler.DeleteTagHandler) // ลบ tag
+	app.Put("/tag/:tagid/parent", middleware.AuthMiddleware, tagHandler.MoveTagHandler) // ย้าย tag ไปอยู่ใต้ tag อื่น
+	app.Put("/tag/:tagid/appearance", middleware.AuthMiddleware, tagHandler.UpdateTagAppearanceHandler) // แก้ไขสีและไอคอนของ tag
+
+	//********************************************
+	// Auto-tagging Rule
+	//********************************************
+	app.Post("/rule", middleware.AuthMiddleware, ruleHandler.CreateRuleHandler)              // สร้างกฎติดแท็กอัตโนมัติ
+	app.Get("/rule", middleware.AuthMiddleware, ruleHandler.GetRulesHandler)                 // ดูกฎทั้งหมด
+	app.Post("/rule/dry-run", middleware.AuthMiddleware, ruleHandler.DryRunHandler)          // ทดลองกฎกับโน้ตที่มีอยู่
+	app.Put("/rule/:ruleid", middleware.AuthMiddleware, ruleHandler.UpdateRuleHandler)       // แก้ไขกฎ
+	app.Delete("/rule/:ruleid", middleware.AuthMiddleware, ruleHandler.DeleteRuleHandler)    // ลบกฎ
+	app.Post("/rule/:ruleid/apply", middleware.AuthMiddleware, ruleHandler.ApplyRuleHandler) // ใช้กฎกับโน้ตที่มีอยู่แล้ว
+
+	//********************************************
+	// Saved Search
+	//********************************************
+	app.Post("/search", middleware.AuthMiddleware, savedSearchHandler.CreateSavedSearchHandler)              // บันทึกการค้นหา
+	app.Get("/search", middleware.AuthMiddleware, savedSearchHandler.GetSavedSearchesHandler)                // ดูการค้นหาที่บันทึกไว้ทั้งหมด
+	app.Put("/search/:searchid", middleware.AuthMiddleware, savedSearchHandler.UpdateSavedSearchHandler)     // แก้ไขการค้นหา
+	app.Delete("/search/:searchid", middleware.AuthMiddleware, savedSearchHandler.DeleteSavedSearchHandler)  // ลบการค้นหา
+	app.Get("/search/:searchid/notes", middleware.AuthMiddleware, savedSearchHandler.RunSavedSearchHandler) // ดูโน้ตที่ตรงกับการค้นหา
+	app.Get("/sidebar", middleware.AuthMiddleware, savedSearchHandler.GetSidebarHandler)                     // ข้อมูลแถบด้านข้าง (สมุดโน้ต + แท็ก + การค้นหา)
+
+	//********************************************
+	// Notebook
+	//********************************************
+	app.Post("/notebook", middleware.AuthMiddleware, notebookHandler.CreateNotebookHandler)               // สร้างสมุดโน้ต
+	app.Get("/notebook", middleware.AuthMiddleware, notebookHandler.GetNotebooksHandler)                  // ดูสมุดโน้ตทั้งหมด
+	app.Post("/notebook/move-notes", middleware.AuthMiddleware, notebookHandler.MoveNotesHandler)         // ย้ายโน้ตหลายรายการไปยังสมุดโน้ต
+	app.Put("/notebook/:notebookid", middleware.AuthMiddleware, notebookHandler.UpdateNotebookHandler)    // แก้ไขสมุดโน้ต
+	app.Delete("/notebook/:notebookid", middleware.AuthMiddleware, notebookHandler.DeleteNotebookHandler) // ลบสมุดโน้ต (โน้ตย้ายไป Inbox)
+
+	//********************************************
+	// Template
+	//********************************************
+	app.Post("/template", middleware.AuthMiddleware, templateHandler.CreateTemplateHandler)                          // สร้างแม่แบบ
+	app.Get("/template", middleware.AuthMiddleware, templateHandler.GetTemplatesHandler)                             // ดูแม่แบบทั้งหมด
+	app.Post("/template/from-note/:noteid", middleware.AuthMiddleware, templateHandler.CreateTemplateFromNoteHandler) // บันทึก note เป็นแม่แบบ
+	app.Put("/template/:templateid", middleware.AuthMiddleware, templateHandler.UpdateTemplateHandler)               // แก้ไขแม่แบบ
+	app.Delete("/template/:templateid", middleware.AuthMiddleware, templateHandler.DeleteTemplateHandler)            // ลบแม่แบบ
+	app.Post("/template/:templateid/note", middleware.AuthMiddleware, templateHandler.CreateNoteFromTemplateHandler) // สร้าง note จากแม่แบบ
+
+	//********************************************
+	// Attachment
+	//********************************************
+	app.Post("/note/:noteid/attachments", middleware.AuthMiddleware, attachmentHandler.UploadAttachmentHandler)     // อัปโหลดไฟล์แนบ (multipart ฟิลด์ file)
+	app.Get("/note/:noteid/attachments", middleware.AuthMiddleware, attachmentHandler.GetAttachmentsHandler)        // ดูไฟล์แนบของ note
+	app.Get("/attachment/:attachmentid", middleware.AuthMiddleware, attachmentHandler.DownloadAttachmentHandler)    // ดาวน์โหลดไฟล์แนบ
+	app.Get("/attachment/:attachmentid/thumbnail/:size", middleware.AuthMiddleware, attachmentHandler.DownloadThumbnailHandler) // ดูรูปย่อ (small, medium, large)
+	app.Delete("/attachment/:attachmentid", middleware.AuthMiddleware, attachmentHandler.DeleteAttachmentHandler)   // ลบไฟล์แนบ
+	app.Get("/storage", middleware.AuthMiddleware, attachmentHandler.GetStorageUsageHandler)                       // พื้นที่ไฟล์แนบที่ใช้และ quota
+
+	//********************************************
+	// Export
+	//********************************************
+	app.Get("/export/markdown", middleware.AuthMiddleware, exportHandler.ExportMarkdownHandler) // ดาวน์โหลดโน้ตทั้งหมดเป็น ZIP ของไฟล์ Markdown
+
+	//********************************************
+	// Import
+	//********************************************
+	app.Post("/import", middleware.AuthMiddleware, importHandler.StartImportHandler)       // นำเข้าจาก Google Keep Takeout, ZIP ของไฟล์ Markdown หรือ Evernote ENEX (multipart ฟิลด์ source, file)
+	app.Get("/import", middleware.AuthMiddleware, importHandler.GetImportJobsHandler)      // ดูงานนำเข้าทั้งหมด
+	app.Get("/import/:jobid", middleware.AuthMiddleware, importHandler.GetImportJobHandler) // ดูความคืบหน้าและรายงาน error ของงานนำเข้า
+
+	//********************************************
+	// Print
+	//********************************************
+	app.Get("/note/:noteid/print", middleware.AuthMiddleware, printHandler.PrintHTMLHandler) // หน้า HTML สำหรับพิมพ์ note
+	app.Get("/note/:noteid/pdf", middleware.AuthMiddleware, printHandler.PrintPDFHandler)    // ดาวน์โหลด note เป็น PDF
+	app.Post("/note/print", middleware.AuthMiddleware, printHandler.PrintHTMLHandler)        // หน้า HTML สำหรับพิมพ์หลาย note (body: note_ids)
+	app.Post("/note/pdf", middleware.AuthMiddleware, printHandler.PrintPDFHandler)           // PDF ของหลาย note (body: note_ids)
 	
 	// เริ่มเซิร์ฟเวอร์
 	if err := app.Listen(":8000"); err != nil {
@@ -8,6 +8,10 @@ import (
 	"fmt"
 )
 
+// TokenVersionLookup ใช้ดึง TokenVersion ปัจจุบันของผู้ใช้ (กำหนดใน main)
+// ถ้า version ใน token ไม่ตรงกัน แสดงว่า session ถูกเพิกถอนแล้ว เช่น หลังเปลี่ยนรหัสผ่าน
+var TokenVersionLookup func(userID uint) (int, error)
+
 // AuthMiddleware ตรวจสอบว่าโทเค็น JWT ถูกต้องและยังไม่หมดอายุ
 func AuthMiddleware(c *fiber.Ctx) error {
 	// รับโทเค็นจาก Cookie หรือ Header
@@ -27,8 +31,9 @@ func AuthMiddleware(c *fiber.Ctx) error {
 	}
 
 	// ดึงข้อมูล user_id จากโทเค็น
+	// token ที่มี purpose (เช่น ลิงก์ยืนยันอีเมล) ใช้เป็น session ไม่ได้
 	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || claims["user_id"] == nil {
+	if !ok || claims["user_id"] == nil || claims["purpose"] != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token data"})
 	}
 
@@ -36,6 +41,15 @@ func AuthMiddleware(c *fiber.Ctx) error {
 	userIDFloat := claims["user_id"].(float64)
 	userID := uint(userIDFloat)
 
+	// ตรวจสอบว่า session ยังไม่ถูกเพิกถอน (token เก่าที่ไม่มี ver ถือเป็น version 0)
+	if TokenVersionLookup != nil {
+		tokenVersion, _ := claims["ver"].(float64)
+		currentVersion, err := TokenVersionLookup(userID)
+		if err != nil || int(tokenVersion) != currentVersion {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Session has been revoked, please log in again"})
+		}
+	}
+
 	// เพิ่ม user_id ใน Context เพื่อให้ handler ใช้ได้
 	c.Locals("user_id", userID)
 	fmt.Printf("Middleware: user_id = %v\n", c.Locals("user_id"))
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type AttachmentRepository interface {
+	// CreateAttachment บันทึกไฟล์แนบถ้าพื้นที่รวมของผู้ใช้ไม่เกิน quotaBytes (0 = ไม่จำกัด)
+	CreateAttachment(attachment *entities.Attachment, quotaBytes int64) error
+	GetAttachmentById(attachmentID uint) (*entities.Attachment, error)
+	GetAttachmentsByNote(noteID uint) ([]entities.Attachment, error)
+	GetStorageUsed(userID uint) (int64, error)
+	DeleteAttachment(attachmentID uint) error
+	// UpdateThumbnails บันทึกสถานะและรายการรูปย่อ คืน "attachment not found" ถ้าไฟล์แนบถูกลบไปแล้ว
+	UpdateThumbnails(attachmentID uint, status string, thumbnails []entities.AttachmentThumbnail) error
+	// GetPendingThumbnails ไฟล์แนบที่ยังรอสร้างรูปย่อ (เช่น ค้างอยู่ตอนเซิร์ฟเวอร์ปิด)
+	GetPendingThumbnails() ([]entities.Attachment, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type AutoTagRuleRepository interface {
+	CreateRule(rule *entities.AutoTagRule) error
+	GetRulesByUser(userID uint) ([]entities.AutoTagRule, error)
+	GetRuleById(ruleID uint) (*entities.AutoTagRule, error)
+	UpdateRule(rule *entities.AutoTagRule) error
+	DeleteRule(ruleID uint) error
+}
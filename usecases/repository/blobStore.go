@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"io"
+)
+
+// BlobStore ที่เก็บไฟล์แนบ (มีทั้งแบบไฟล์ในเครื่องและแบบ S3-compatible)
+type BlobStore interface {
+	Put(key string, body io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
@@ -0,0 +1,10 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type IdentityRepository interface {
+	CreateIdentity(identity *entities.UserIdentity) error
+	GetIdentity(provider string, subject string) (*entities.UserIdentity, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type ImportJobRepository interface {
+	// CreateImportJob บันทึกงานใหม่ คืน "an import is already in progress" ถ้าผู้ใช้มีงานที่ยังไม่จบอยู่แล้ว
+	CreateImportJob(job *entities.ImportJob) error
+	GetImportJobById(jobID uint) (*entities.ImportJob, error)
+	GetImportJobsByUser(userID uint) ([]entities.ImportJob, error)
+	// UpdateImportJob บันทึกสถานะและความคืบหน้า คืน "import job not found" ถ้างานถูกลบไปแล้ว (เช่น ผู้ใช้ลบบัญชี)
+	UpdateImportJob(job *entities.ImportJob) error
+	// GetUnfinishedImportJobs งานที่ยัง pending หรือ running (เช่น ค้างอยู่ตอนเซิร์ฟเวอร์ปิด)
+	GetUnfinishedImportJobs() ([]entities.ImportJob, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type NoteLinkRepository interface {
+	// ReplaceLinks ลบลิงก์เดิมทั้งหมดของโน้ตต้นทางแล้วบันทึกรายการใหม่
+	ReplaceLinks(sourceNoteID uint, links []entities.NoteLink) error
+	GetLinksToNote(targetNoteID uint) ([]entities.NoteLink, error)
+	// GetBacklinks โน้ตที่ยังไม่ถูกลบซึ่งลิงก์มายังโน้ตนี้
+	GetBacklinks(targetNoteID uint) ([]entities.Note, error)
+	UpdateLinkTarget(linkID uint, targetNoteID *uint, targetTitle string) error
+	// ResolveLinksByTitle ชี้ลิงก์ที่ยังไม่มีปลายทางและมีชื่อตรงกับ title ไปยังโน้ต targetNoteID
+	ResolveLinksByTitle(userID uint, title string, targetNoteID uint) error
+	// FindNoteIDByTitle โน้ตที่ยังไม่ถูกลบซึ่งมีชื่อตรงกับ title (ไม่สนตัวพิมพ์เล็ก/ใหญ่) ถ้ามีหลายโน้ตเลือกโน้ตที่สร้างก่อน
+	FindNoteIDByTitle(userID uint, title string) (*uint, error)
+}
@@ -6,15 +6,35 @@ import (
 
 type NoteRepository interface {
 	CreateNote(note *entities.Note) error
-	GetAllNoteByUserId(userID uint) ([]entities.Note, error)
+	GetAllNoteByUserId(userID uint, notebookID *uint) ([]entities.Note, error)
+	GetArchivedNotesByUserId(userID uint) ([]entities.Note, error)
+	GetNotesByTag(userID uint, tagID uint, includeDescendants bool) ([]entities.Note, error)
+	SearchNotes(userID uint, filter entities.NoteFilter, sortBy string, sortOrder string) ([]entities.Note, error)
 	GetNoteById(noteID uint) (*entities.Note, error)
 	UpdateNoteColor(noteID uint, userID uint, color string) error 
 	UpdateNotePriority(noteID uint, userID uint, priority int) error 
 	UpdateNoteTitleAndContent(note *entities.Note) error 
 	UpdateNoteStatus(noteID uint, userID uint, isTodo *bool, isAllDone *bool) error
+	UpdateNotePinned(noteID uint, userID uint, isPinned bool) error
+	// GetNotePositions คีย์ตำแหน่งของโน้ตในสมุดโน้ตหนึ่ง (notebookID nil = โน้ตที่ไม่อยู่ในสมุดใด)
+	GetNotePositions(userID uint, notebookID *uint) ([]entities.Note, error)
+	// LockNotePositions ล็อกการคำนวณคีย์ตำแหน่งของผู้ใช้จนจบ transaction (ต้องเรียกภายใน WithTransaction)
+	LockNotePositions(userID uint) error
+	UpdateNotePosition(noteID uint, userID uint, position string) error
+	UpdateNotePositions(userID uint, positions map[uint]string) error
+	UpdateNoteArchived(noteID uint, userID uint, archivedAt string) error
 	DeleteNoteById(noteID uint) error
 	RestoreNoteById(noteID uint) error 
+	// PurgeNote ลบโน้ตและข้อมูลที่ผูกอยู่ทั้งหมดถาวร คืน key ของไฟล์แนบและรูปย่อที่ต้องลบออกจาก BlobStore
+	PurgeNote(noteID uint) ([]string, error)
 	AddTagToNote(noteID uint, tagID uint, userID uint) error
 	RemoveTagFromNote(noteID uint, tagID uint, userID uint) error
 	GetNoteByIdAndUser(noteID uint, userID uint) (*entities.Note, error)
+	UpdateNoteFields(noteID uint, userID uint, updates map[string]interface{}) error
+	ReplaceTodoItems(noteID uint, todoItems []entities.ToDo) error
+	ReplaceNoteTags(noteID uint, userID uint, tagIDs []uint) error
+	// FindNotesInBatches ส่งโน้ตที่ยังไม่ถูกลบของผู้ใช้ให้ fn ทีละ batchSize รายการ (พร้อมแท็ก Reminder Event และ To-Do)
+	FindNotesInBatches(userID uint, batchSize int, fn func(notes []entities.Note) error) error
+	// WithTransaction เรียก fn ด้วย repository ที่ผูกกับ transaction เดียวกัน ถ้า fn คืน error จะ rollback ทั้งหมด
+	WithTransaction(fn func(repo NoteRepository) error) error
 }
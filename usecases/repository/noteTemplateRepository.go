@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type NoteTemplateRepository interface {
+	CreateTemplate(template *entities.NoteTemplate) error
+	GetTemplatesByUser(userID uint) ([]entities.NoteTemplate, error)
+	GetTemplateById(templateID uint) (*entities.NoteTemplate, error)
+	UpdateTemplate(template *entities.NoteTemplate) error
+	DeleteTemplate(templateID uint) error
+}
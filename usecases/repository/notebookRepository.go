@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type NotebookRepository interface {
+	CreateNotebook(notebook *entities.Notebook) error
+	GetNotebooksByUser(userID uint) ([]entities.Notebook, error)
+	GetNotebookById(notebookID uint) (*entities.Notebook, error)
+	GetDefaultNotebook(userID uint) (*entities.Notebook, error)
+	UpdateNotebook(notebook *entities.Notebook) error
+	DeleteNotebook(notebookID uint, userID uint, moveNotesTo uint) error
+	MoveNotes(userID uint, noteIDs []uint, notebookID *uint) (int64, error)
+}
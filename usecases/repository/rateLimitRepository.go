@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"miw/entities"
+	"time"
+)
+
+// RateLimitRepository เป็นที่เก็บตัวนับของ rate limiter (มีทั้งแบบ in-memory และ Postgres)
+type RateLimitRepository interface {
+	GetCounter(key string) (*entities.RateLimitCounter, error)
+	// Increment นับเพิ่ม 1 ครั้ง เริ่มหน้าต่างใหม่เมื่อครบ window และล้าง LockoutCount เมื่อการล็อกครั้งล่าสุดหมดไปนานกว่า lockoutDecay
+	Increment(key string, window time.Duration, lockoutDecay time.Duration) (*entities.RateLimitCounter, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+type LockoutEventRepository interface {
+	CreateLockoutEvent(event *entities.LockoutEvent) error
+}
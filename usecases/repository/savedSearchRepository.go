@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type SavedSearchRepository interface {
+	CreateSavedSearch(search *entities.SavedSearch) error
+	GetSavedSearchesByUser(userID uint) ([]entities.SavedSearch, error)
+	GetSavedSearchById(searchID uint) (*entities.SavedSearch, error)
+	UpdateSavedSearch(search *entities.SavedSearch) error
+	DeleteSavedSearch(searchID uint) error
+}
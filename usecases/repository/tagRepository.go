@@ -10,4 +10,8 @@ type TagRepository interface {
 	GetTagsByUser(userID uint) ([]entities.Tag, error)
 	UpdateTagName(tagID, userID uint, newName string) error
 	DeleteTag(tagID, userID uint) error
+	UpdateTagParent(tagID, userID uint, parentTagID *uint) error
+	UpdateTagAppearance(tagID, userID uint, color *string, icon *string) error
+	MergeTags(userID uint, targetTagID uint, sourceTagIDs []uint) error
+	RenameTags(userID uint, newNames map[uint]string) error
 }
@@ -10,4 +10,7 @@ type UserRepository interface {
 	GetUserById(userID uint) (*entities.User, error)
 	GetUserByEmail(email string) (*entities.User, error)
 	GetUserEmailByID(userID uint) (string, error)
+	GetUserStats(userID uint) (*entities.UserStats, error)
+	GetUserData(userID uint) (*entities.UserData, error)
+	DeleteUser(userID uint) error
 }
\ No newline at end of file
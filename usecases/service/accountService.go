@@ -0,0 +1,275 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AccountUseCase interface {
+	ExportUserData(userID uint) ([]byte, error)
+	RequestAccountDeletion(userID uint) error
+	DeleteAccount(userID uint, password string, confirmationToken string) error
+}
+
+type AccountService struct {
+	userRepo        repository.UserRepository
+	reminderUseCase ReminderUseCase
+	blobStore       repository.BlobStore
+}
+
+func NewAccountService(userRepo repository.UserRepository, reminderUseCase ReminderUseCase, blobStore repository.BlobStore) *AccountService {
+	return &AccountService{
+		userRepo:        userRepo,
+		reminderUseCase: reminderUseCase,
+		blobStore:       blobStore,
+	}
+}
+
+// โครงสร้างข้อมูลที่ใช้ใน export (ไม่รวมรหัสผ่านและ token ของบริการภายนอก)
+type exportProfile struct {
+	UserID      uint                   `json:"user_id"`
+	Username    string                 `json:"username"`
+	Email       string                 `json:"email"`
+	Timezone    string                 `json:"timezone"`
+	Preferences map[string]interface{} `json:"preferences"`
+	ExportedAt  string                 `json:"exported_at"`
+}
+
+type exportNote struct {
+	NoteID        uint   `json:"note_id"`
+	NotebookID    *uint  `json:"notebook_id"`
+	Title         string `json:"title"`
+	Content       string `json:"content"`
+	ContentFormat string `json:"content_format"`
+	Color         string `json:"color"`
+	Priority      int    `json:"priority"`
+	IsTodo        bool   `json:"is_todo"`
+	IsAllDone     bool   `json:"is_all_done"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+	DeletedAt     string `json:"deleted_at"`
+	IsPinned      bool   `json:"is_pinned"`
+	ArchivedAt    string `json:"archived_at"`
+	Position      string `json:"position"`
+	TagIDs        []uint `json:"tag_ids"`
+}
+
+type exportTag struct {
+	TagID       uint   `json:"tag_id"`
+	TagName     string `json:"tag_name"`
+	ParentTagID *uint  `json:"parent_tag_id"`
+}
+
+type exportIdentity struct {
+	Provider  string `json:"provider"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ExportUserData สร้างไฟล์ ZIP ที่มี JSON ของข้อมูลทั้งหมดของผู้ใช้
+func (s *AccountService) ExportUserData(userID uint) ([]byte, error) {
+	data, err := s.userRepo.GetUserData(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	notes := make([]exportNote, 0, len(data.Notes))
+	for _, note := range data.Notes {
+		tagIDs := make([]uint, 0, len(note.Tags))
+		for _, tag := range note.Tags {
+			tagIDs = append(tagIDs, tag.TagID)
+		}
+		notes = append(notes, exportNote{
+			NoteID:        note.NoteID,
+			NotebookID:    note.NotebookID,
+			Title:         note.Title,
+			Content:       note.Content,
+			ContentFormat: note.ContentFormat,
+			Color:         note.Color,
+			Priority:      note.Priority,
+			IsTodo:        note.IsTodo,
+			IsAllDone:     note.IsAllDone,
+			CreatedAt:     note.CreatedAt,
+			UpdatedAt:     note.UpdatedAt,
+			DeletedAt:     note.DeletedAt,
+			IsPinned:      note.IsPinned,
+			ArchivedAt:    note.ArchivedAt,
+			Position:      note.Position,
+			TagIDs:        tagIDs,
+		})
+	}
+
+	tags := make([]exportTag, 0, len(data.Tags))
+	for _, tag := range data.Tags {
+		tags = append(tags, exportTag{TagID: tag.TagID, TagName: tag.TagName, ParentTagID: tag.ParentTagID})
+	}
+
+	identities := make([]exportIdentity, 0, len(data.Identities))
+	for _, identity := range data.Identities {
+		identities = append(identities, exportIdentity{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", exportProfile{
+			UserID:      data.User.UserID,
+			Username:    data.User.Username,
+			Email:       data.User.Email,
+			Timezone:    data.User.Timezone,
+			Preferences: data.User.Preferences,
+			ExportedAt:  time.Now().Format("2006-01-02 15:04:05"),
+		}},
+		{"notes.json", notes},
+		{"todo_items.json", data.TodoItems},
+		{"tags.json", tags},
+		{"reminders.json", data.Reminders},
+		{"events.json", data.Events},
+		{"shares.json", data.Shares},
+		{"identities.json", identities},
+		{"auto_tag_rules.json", data.AutoTagRules},
+		{"saved_searches.json", data.SavedSearches},
+		{"notebooks.json", data.Notebooks},
+		{"templates.json", data.Templates},
+		{"attachments.json", data.Attachments},
+		{"note_links.json", data.NoteLinks},
+		{"import_jobs.json", data.ImportJobs},
+	}
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %v", file.name, err)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %v", file.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize export: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RequestAccountDeletion ส่งรหัสยืนยันการลบบัญชีไปทางอีเมล สำหรับบัญชีที่ไม่มีรหัสผ่าน (สร้างผ่าน OIDC)
+func (s *AccountService) RequestAccountDeletion(userID uint) error {
+	user, err := s.userRepo.GetUserById(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.Password != "" {
+		return errors.New("account has a password")
+	}
+
+	confirmationToken, err := generateDeleteAccountToken(user)
+	if err != nil {
+		return err
+	}
+	if err := utils.SendEmail(user.Email, "Confirm account deletion",
+		"Use this confirmation code to permanently delete your account and all of your notes. It expires in 1 hour.\n\n"+confirmationToken+
+			"\n\nIf you did not request this, you can ignore this email."); err != nil {
+		return fmt.Errorf("failed to send confirmation email: %v", err)
+	}
+	return nil
+}
+
+// generateDeleteAccountToken รหัสยืนยันการลบบัญชี ผูกกับ TokenVersion จึงใช้ไม่ได้หลังเพิกถอน session
+func generateDeleteAccountToken(user *entities.User) (string, error) {
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["purpose"] = "delete_account"
+	claims["user_id"] = user.UserID
+	claims["ver"] = user.TokenVersion
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+func verifyDeleteAccountToken(tokenString string, user *entities.User) error {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil || !token.Valid {
+		return errors.New("invalid confirmation token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != "delete_account" {
+		return errors.New("invalid confirmation token")
+	}
+	userID, _ := claims["user_id"].(float64)
+	tokenVersion, ok := claims["ver"].(float64)
+	if !ok || uint(userID) != user.UserID || int(tokenVersion) != user.TokenVersion {
+		return errors.New("invalid confirmation token")
+	}
+	return nil
+}
+
+// DeleteAccount ลบบัญชีหลังยืนยันตัวตน พร้อมยกเลิกการแจ้งเตือนที่ตั้งเวลาไว้
+// บัญชีที่มีรหัสผ่านยืนยันด้วยรหัสผ่าน บัญชีที่ไม่มีรหัสผ่านยืนยันด้วยรหัสจาก RequestAccountDeletion
+func (s *AccountService) DeleteAccount(userID uint, password string, confirmationToken string) error {
+	data, err := s.userRepo.GetUserData(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if data.User.Password == "" {
+		if err := verifyDeleteAccountToken(confirmationToken, &data.User); err != nil {
+			return err
+		}
+	} else if err := bcrypt.CompareHashAndPassword([]byte(data.User.Password), []byte(password)); err != nil {
+		return errors.New("invalid password")
+	}
+
+	if err := s.userRepo.DeleteUser(userID); err != nil {
+		return fmt.Errorf("failed to delete account: %v", err)
+	}
+
+	// ยกเลิก timer ของ Reminder ทั้งหมดหลังลบข้อมูลสำเร็จ
+	reminderIDs := make([]uint, 0, len(data.Reminders))
+	for _, reminder := range data.Reminders {
+		reminderIDs = append(reminderIDs, reminder.ReminderID)
+	}
+	s.reminderUseCase.CancelScheduledReminders(reminderIDs)
+
+	// ลบไฟล์แนบทั้งหมดออกจาก BlobStore
+	for _, attachment := range data.Attachments {
+		for _, key := range attachment.BlobKeys() {
+			if err := s.blobStore.Delete(key); err != nil {
+				log.Printf("Failed to delete blob %s: %v", key, err)
+			}
+		}
+	}
+	// ไฟล์ที่อัปโหลดของงานนำเข้าที่ยังไม่จบ
+	for _, job := range data.ImportJobs {
+		if job.StorageKey == "" {
+			continue
+		}
+		if err := s.blobStore.Delete(job.StorageKey); err != nil {
+			log.Printf("Failed to delete blob %s: %v", job.StorageKey, err)
+		}
+	}
+
+	return nil
+}
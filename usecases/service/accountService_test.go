@@ -0,0 +1,79 @@
+package service
+
+import (
+	"miw/entities"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeReminderUseCase struct {
+	ReminderUseCase
+	cancelled []uint
+	scheduled []uint
+}
+
+func (f *fakeReminderUseCase) CancelScheduledReminders(reminderIDs []uint) {
+	f.cancelled = append(f.cancelled, reminderIDs...)
+}
+
+func (f *fakeReminderUseCase) ScheduleReminders(note *entities.Note) {
+	f.scheduled = append(f.scheduled, note.NoteID)
+}
+
+func TestDeleteAccountWithoutPassword(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	oidcUser := &entities.User{UserID: 1, Email: "oidc@example.com", TokenVersion: 2}
+	repo := &fakeUserRepo{users: map[uint]*entities.User{1: oidcUser}}
+	s := NewAccountService(repo, &fakeReminderUseCase{}, nil)
+
+	if err := s.DeleteAccount(1, "", ""); err == nil || err.Error() != "invalid confirmation token" {
+		t.Fatalf("DeleteAccount without confirmation error = %v", err)
+	}
+	sessionToken, _ := NewUserService(repo).generateToken(oidcUser)
+	if err := s.DeleteAccount(1, "", sessionToken); err == nil || err.Error() != "invalid confirmation token" {
+		t.Fatalf("DeleteAccount with a session token error = %v", err)
+	}
+	otherUserToken, _ := generateDeleteAccountToken(&entities.User{UserID: 2, TokenVersion: 2})
+	if err := s.DeleteAccount(1, "", otherUserToken); err == nil || err.Error() != "invalid confirmation token" {
+		t.Fatalf("DeleteAccount with another user's token error = %v", err)
+	}
+	staleToken, _ := generateDeleteAccountToken(&entities.User{UserID: 1, TokenVersion: 1})
+	if err := s.DeleteAccount(1, "", staleToken); err == nil || err.Error() != "invalid confirmation token" {
+		t.Fatalf("DeleteAccount with a revoked token error = %v", err)
+	}
+
+	confirmationToken, err := generateDeleteAccountToken(oidcUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteAccount(1, "", confirmationToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo.users[1]; ok {
+		t.Fatal("user was not deleted")
+	}
+}
+
+func TestDeleteAccountWithPassword(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	user := &entities.User{UserID: 1, Email: "a@example.com", Password: string(hash)}
+	repo := &fakeUserRepo{users: map[uint]*entities.User{1: user}}
+	s := NewAccountService(repo, &fakeReminderUseCase{}, nil)
+
+	// บัญชีที่มีรหัสผ่านต้องยืนยันด้วยรหัสผ่านเท่านั้น
+	if err := s.RequestAccountDeletion(1); err == nil || err.Error() != "account has a password" {
+		t.Fatalf("RequestAccountDeletion error = %v", err)
+	}
+	confirmationToken, _ := generateDeleteAccountToken(user)
+	if err := s.DeleteAccount(1, "", confirmationToken); err == nil || err.Error() != "invalid password" {
+		t.Fatalf("DeleteAccount with a token error = %v", err)
+	}
+	if err := s.DeleteAccount(1, "wrong", ""); err == nil || err.Error() != "invalid password" {
+		t.Fatalf("DeleteAccount with a wrong password error = %v", err)
+	}
+	if err := s.DeleteAccount(1, "secret", ""); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type AttachmentUseCase interface {
+	UploadAttachment(noteID uint, userID uint, fileName string, size int64, file io.Reader) (*entities.Attachment, error)
+	GetAttachments(noteID uint, userID uint) ([]entities.Attachment, error)
+	OpenAttachment(attachmentID uint, userID uint) (*entities.Attachment, io.ReadCloser, error)
+	OpenThumbnail(attachmentID uint, userID uint, size string) (*entities.AttachmentThumbnail, io.ReadCloser, error)
+	DeleteAttachment(attachmentID uint, userID uint) error
+	GetStorageUsage(userID uint) (*StorageUsage, error)
+}
+
+// StorageUsage พื้นที่ไฟล์แนบที่ผู้ใช้ใช้ไปเทียบกับ quota (byte, quota 0 = ไม่จำกัด)
+type StorageUsage struct {
+	UsedBytes  int64 `json:"used_bytes"`
+	QuotaBytes int64 `json:"quota_bytes"`
+}
+
+// AttachmentLimits ขนาดไฟล์สูงสุด พื้นที่ต่อผู้ใช้ และชนิดไฟล์ที่อนุญาต
+type AttachmentLimits struct {
+	MaxFileBytes int64
+	QuotaBytes   int64
+	AllowedTypes []string
+}
+
+// DefaultAttachmentTypes ชนิดไฟล์ที่อนุญาตเมื่อไม่ได้ตั้งค่า (ตรวจจากเนื้อไฟล์ ไม่ใช่นามสกุล)
+var DefaultAttachmentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf", "text/plain"}
+
+type AttachmentService struct {
+	attachmentRepo repository.AttachmentRepository
+	noteRepo       repository.NoteRepository
+	blobStore      repository.BlobStore
+	thumbnailQueue ThumbnailQueue
+	limits         AttachmentLimits
+}
+
+func NewAttachmentService(attachmentRepo repository.AttachmentRepository, noteRepo repository.NoteRepository, blobStore repository.BlobStore, thumbnailQueue ThumbnailQueue, limits AttachmentLimits) *AttachmentService {
+	if len(limits.AllowedTypes) == 0 {
+		limits.AllowedTypes = DefaultAttachmentTypes
+	}
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		noteRepo:       noteRepo,
+		blobStore:      blobStore,
+		thumbnailQueue: thumbnailQueue,
+		limits:         limits,
+	}
+}
+
+func (s *AttachmentService) isAllowedType(contentType string) bool {
+	for _, allowed := range s.limits.AllowedTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// newStorageKey สร้าง key แบบสุ่มของไฟล์ ไม่ใช้ชื่อไฟล์จากผู้ใช้เพื่อไม่ให้ชนกันหรือถูกเดา
+func newStorageKey(userID uint) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("users/%d/%s", userID, hex.EncodeToString(random)), nil
+}
+
+// cleanFileName ตัด path และอักขระควบคุมออกจากชื่อไฟล์ที่ผู้ใช้ส่งมา
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+// UploadAttachment: ตรวจขนาด ชนิดไฟล์ และ quota ก่อนเก็บไฟล์ลง BlobStore แล้วบันทึกข้อมูลไฟล์
+// รูปภาพจะถูกลบ EXIF ก่อนเก็บ และส่งเข้าคิวสร้างรูปย่อหลังบันทึกสำเร็จ
+func (s *AttachmentService) UploadAttachment(noteID uint, userID uint, fileName string, size int64, file io.Reader) (*entities.Attachment, error) {
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+	if note.DeletedAt != "" {
+		return nil, fmt.Errorf("cannot attach files to a deleted note")
+	}
+
+	if size <= 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if s.limits.MaxFileBytes > 0 && size > s.limits.MaxFileBytes {
+		return nil, fmt.Errorf("file is too large: maximum size is %d bytes", s.limits.MaxFileBytes)
+	}
+	if s.limits.QuotaBytes > 0 {
+		used, err := s.attachmentRepo.GetStorageUsed(userID)
+		if err != nil {
+			return nil, err
+		}
+		if used+size > s.limits.QuotaBytes {
+			return nil, fmt.Errorf("storage quota exceeded")
+		}
+	}
+
+	// ตรวจชนิดไฟล์จาก 512 byte แรกของเนื้อไฟล์
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	head = head[:n]
+	contentType := strings.TrimSpace(strings.Split(http.DetectContentType(head), ";")[0])
+	if !s.isAllowedType(contentType) {
+		return nil, fmt.Errorf("file type %s is not allowed", contentType)
+	}
+
+	key, err := newStorageKey(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage key: %v", err)
+	}
+
+	attachment := &entities.Attachment{
+		NoteID:          noteID,
+		UserID:          userID,
+		FileName:        cleanFileName(fileName),
+		ContentType:     contentType,
+		StorageKey:      key,
+		ThumbnailStatus: entities.ThumbnailStatusNone,
+		CreatedAt:       time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	var body io.Reader = io.MultiReader(bytes.NewReader(head), file)
+	if utils.IsImageType(contentType) {
+		// รูปภาพต้องอ่านทั้งไฟล์เพื่อลบ metadata (ขนาดถูกจำกัดด้วย MaxFileBytes แล้ว)
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %v", err)
+		}
+		// ตรวจขนาดภาพจาก header ก่อน เพราะ JPEG ที่ต้องหมุนตาม EXIF จะถูก decode ทั้งรูป
+		width, height, err := utils.ImageDimensions(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image file")
+		}
+		if utils.IsImageTooLarge(width, height) {
+			return nil, fmt.Errorf("image is too large: maximum is %d pixels", utils.MaxImagePixels)
+		}
+		if data, err = utils.StripImageMetadata(data, contentType); err != nil {
+			return nil, fmt.Errorf("invalid image file")
+		}
+		if attachment.Width, attachment.Height, err = utils.ImageDimensions(data); err != nil {
+			return nil, fmt.Errorf("invalid image file")
+		}
+		attachment.ThumbnailStatus = entities.ThumbnailStatusPending
+		body = bytes.NewReader(data)
+		size = int64(len(data))
+	}
+
+	hash := sha256.New()
+	if err := s.blobStore.Put(key, io.TeeReader(body, hash), size, contentType); err != nil {
+		return nil, err
+	}
+	attachment.Size = size
+	attachment.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	// ตรวจ quota อีกครั้งใน transaction เผื่อมีการอัปโหลดพร้อมกัน ถ้าไม่ผ่านให้ลบไฟล์ที่เพิ่งเก็บ
+	if err := s.attachmentRepo.CreateAttachment(attachment, s.limits.QuotaBytes); err != nil {
+		if deleteErr := s.blobStore.Delete(key); deleteErr != nil {
+			log.Printf("Failed to remove orphaned blob %s: %v", key, deleteErr)
+		}
+		return nil, err
+	}
+
+	if attachment.ThumbnailStatus == entities.ThumbnailStatusPending && s.thumbnailQueue != nil {
+		s.thumbnailQueue.Enqueue(attachment.AttachmentID)
+	}
+	return attachment, nil
+}
+
+func (s *AttachmentService) GetAttachments(noteID uint, userID uint) ([]entities.Attachment, error) {
+	if _, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID); err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+	return s.attachmentRepo.GetAttachmentsByNote(noteID)
+}
+
+func (s *AttachmentService) getOwnedAttachment(attachmentID uint, userID uint) (*entities.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetAttachmentById(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.UserID != userID {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	return attachment, nil
+}
+
+// OpenAttachment คืนข้อมูลไฟล์และ reader ของเนื้อไฟล์ ผู้เรียกต้องปิด reader เอง
+func (s *AttachmentService) OpenAttachment(attachmentID uint, userID uint) (*entities.Attachment, io.ReadCloser, error) {
+	attachment, err := s.getOwnedAttachment(attachmentID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := s.blobStore.Get(attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, reader, nil
+}
+
+// OpenThumbnail คืนข้อมูลและ reader ของรูปย่อขนาดที่ระบุ ถ้ายังสร้างไม่เสร็จจะได้ "thumbnail not found"
+func (s *AttachmentService) OpenThumbnail(attachmentID uint, userID uint, size string) (*entities.AttachmentThumbnail, io.ReadCloser, error) {
+	attachment, err := s.getOwnedAttachment(attachmentID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range attachment.Thumbnails {
+		if attachment.Thumbnails[i].Size != size {
+			continue
+		}
+		reader, err := s.blobStore.Get(attachment.ThumbnailKey(size))
+		if err != nil {
+			return nil, nil, err
+		}
+		return &attachment.Thumbnails[i], reader, nil
+	}
+	return nil, nil, fmt.Errorf("thumbnail not found")
+}
+
+func (s *AttachmentService) DeleteAttachment(attachmentID uint, userID uint) error {
+	attachment, err := s.getOwnedAttachment(attachmentID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.attachmentRepo.DeleteAttachment(attachmentID); err != nil {
+		return err
+	}
+	// ลบข้อมูลก่อนแล้วค่อยลบไฟล์ ถ้าลบไฟล์ไม่สำเร็จจะเหลือแค่ไฟล์กำพร้า ไม่มีข้อมูลที่ชี้ไปยังไฟล์ที่หายไป
+	for _, key := range attachment.BlobKeys() {
+		if err := s.blobStore.Delete(key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+func (s *AttachmentService) GetStorageUsage(userID uint) (*StorageUsage, error) {
+	used, err := s.attachmentRepo.GetStorageUsed(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &StorageUsage{UsedBytes: used, QuotaBytes: s.limits.QuotaBytes}, nil
+}
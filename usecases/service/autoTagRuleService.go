@@ -0,0 +1,279 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AutoTagRuleUseCase interface {
+	CreateRule(rule *entities.AutoTagRule) error
+	GetRules(userID uint) ([]entities.AutoTagRule, error)
+	UpdateRule(ruleID uint, userID uint, input *entities.AutoTagRule) error
+	DeleteRule(ruleID uint, userID uint) error
+	DryRun(userID uint, rule *entities.AutoTagRule) ([]RuleMatch, error)
+	ApplyToExistingNotes(ruleID uint, userID uint) (int, error)
+}
+
+// RuleMatch โน้ตที่ตรงกับกฎ และบอกว่ามีแท็กของกฎอยู่แล้วหรือไม่
+type RuleMatch struct {
+	Note          entities.Note
+	AlreadyTagged bool
+}
+
+type AutoTagRuleService struct {
+	ruleRepo repository.AutoTagRuleRepository
+	tagRepo  repository.TagRepository
+	noteRepo repository.NoteRepository
+}
+
+func NewAutoTagRuleService(ruleRepo repository.AutoTagRuleRepository, tagRepo repository.TagRepository, noteRepo repository.NoteRepository) *AutoTagRuleService {
+	return &AutoTagRuleService{
+		ruleRepo: ruleRepo,
+		tagRepo:  tagRepo,
+		noteRepo: noteRepo,
+	}
+}
+
+// ตัวดำเนินการที่ใช้ได้กับแต่ละ field
+var autoTagRuleOperators = map[string][]string{
+	"title":        {"contains", "equals"},
+	"content":      {"contains", "equals"},
+	"color":        {"equals"},
+	"priority":     {"equals", "gte", "lte"},
+	"is_todo":      {"is"},
+	"has_reminder": {"is"},
+}
+
+// validateRule ตรวจสอบ field/operator/value และแท็กปลายทางว่าเป็นของผู้ใช้
+func (s *AutoTagRuleService) validateRule(rule *entities.AutoTagRule) error {
+	operators, ok := autoTagRuleOperators[rule.Field]
+	if !ok {
+		return fmt.Errorf("invalid rule: unknown field '%s'", rule.Field)
+	}
+
+	validOperator := false
+	for _, op := range operators {
+		if op == rule.Operator {
+			validOperator = true
+			break
+		}
+	}
+	if !validOperator {
+		return fmt.Errorf("invalid rule: operator '%s' is not supported for field '%s'", rule.Operator, rule.Field)
+	}
+
+	switch rule.Field {
+	case "priority":
+		priority, err := strconv.Atoi(rule.Value)
+		if err != nil || !utils.IsValidPriority(priority) {
+			return fmt.Errorf("invalid rule: value must be a priority between %d and %d", utils.PriorityNone, utils.PriorityUrgent)
+		}
+	case "color":
+		rule.Value = utils.NormalizeColor(rule.Value)
+		if rule.Value == "" || !utils.IsValidColor(rule.Value) {
+			return fmt.Errorf("invalid rule: value must be a named color or a hex color")
+		}
+	case "is_todo", "has_reminder":
+		if _, err := strconv.ParseBool(rule.Value); err != nil {
+			return fmt.Errorf("invalid rule: value must be true or false")
+		}
+	default:
+		if rule.Value == "" {
+			return fmt.Errorf("invalid rule: value is required")
+		}
+	}
+
+	tag, err := s.tagRepo.GetTagById(rule.TagID)
+	if err != nil || tag.UserID != rule.UserID {
+		return fmt.Errorf("tag not found or does not belong to this user")
+	}
+
+	return nil
+}
+
+func (s *AutoTagRuleService) CreateRule(rule *entities.AutoTagRule) error {
+	rule.RuleID = 0
+	if err := s.validateRule(rule); err != nil {
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	return s.ruleRepo.CreateRule(rule)
+}
+
+func (s *AutoTagRuleService) GetRules(userID uint) ([]entities.AutoTagRule, error) {
+	return s.ruleRepo.GetRulesByUser(userID)
+}
+
+func (s *AutoTagRuleService) getOwnedRule(ruleID uint, userID uint) (*entities.AutoTagRule, error) {
+	rule, err := s.ruleRepo.GetRuleById(ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.UserID != userID {
+		return nil, fmt.Errorf("rule not found")
+	}
+	return rule, nil
+}
+
+func (s *AutoTagRuleService) UpdateRule(ruleID uint, userID uint, input *entities.AutoTagRule) error {
+	rule, err := s.getOwnedRule(ruleID, userID)
+	if err != nil {
+		return err
+	}
+
+	rule.Name = input.Name
+	rule.Field = input.Field
+	rule.Operator = input.Operator
+	rule.Value = input.Value
+	rule.TagID = input.TagID
+	rule.Enabled = input.Enabled
+	if err := s.validateRule(rule); err != nil {
+		return err
+	}
+
+	rule.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+	return s.ruleRepo.UpdateRule(rule)
+}
+
+func (s *AutoTagRuleService) DeleteRule(ruleID uint, userID uint) error {
+	if _, err := s.getOwnedRule(ruleID, userID); err != nil {
+		return err
+	}
+	return s.ruleRepo.DeleteRule(ruleID)
+}
+
+// DryRun แสดงโน้ตที่มีอยู่แล้วซึ่งตรงกับกฎ โดยไม่เปลี่ยนแปลงข้อมูล
+func (s *AutoTagRuleService) DryRun(userID uint, rule *entities.AutoTagRule) ([]RuleMatch, error) {
+	rule.UserID = userID
+	if err := s.validateRule(rule); err != nil {
+		return nil, err
+	}
+
+	notes, err := s.noteRepo.GetAllNoteByUserId(userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []RuleMatch{}
+	for i := range notes {
+		if matchAutoTagRule(rule, &notes[i]) {
+			matches = append(matches, RuleMatch{Note: notes[i], AlreadyTagged: noteHasTag(&notes[i], rule.TagID)})
+		}
+	}
+	return matches, nil
+}
+
+// ApplyToExistingNotes ติดแท็กของกฎให้กับโน้ตที่มีอยู่แล้วทั้งหมดที่ตรงกับกฎ คืนจำนวนโน้ตที่ถูกติดแท็กเพิ่ม
+func (s *AutoTagRuleService) ApplyToExistingNotes(ruleID uint, userID uint) (int, error) {
+	rule, err := s.getOwnedRule(ruleID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	matches, err := s.DryRun(userID, rule)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, match := range matches {
+		if match.AlreadyTagged {
+			continue
+		}
+		if err := s.noteRepo.AddTagToNote(match.Note.NoteID, rule.TagID, userID); err != nil {
+			return applied, fmt.Errorf("failed to tag note %d: %v", match.Note.NoteID, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// applyAutoTagRules ประเมินกฎที่เปิดใช้งานของเจ้าของโน้ต และติดแท็กที่ตรงกับกฎ
+// ถูกเรียกจาก NoteService หลังสร้าง/แก้ไขโน้ต ข้อผิดพลาดจะถูก log ไว้โดยไม่ทำให้การบันทึกโน้ตล้มเหลว
+func applyAutoTagRules(ruleRepo repository.AutoTagRuleRepository, noteRepo repository.NoteRepository, note *entities.Note) {
+	rules, err := ruleRepo.GetRulesByUser(note.UserID)
+	if err != nil {
+		log.Printf("Failed to load auto-tag rules for user %d: %v", note.UserID, err)
+		return
+	}
+
+	// กันไม่ให้ติดแท็กเดิมซ้ำ กรณีมีหลายกฎชี้ไปที่แท็กเดียวกัน
+	tagged := make(map[uint]bool)
+	for _, tag := range note.Tags {
+		tagged[tag.TagID] = true
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled || tagged[rule.TagID] || !matchAutoTagRule(rule, note) {
+			continue
+		}
+		if err := noteRepo.AddTagToNote(note.NoteID, rule.TagID, note.UserID); err != nil {
+			log.Printf("Failed to apply auto-tag rule %d to note %d: %v", rule.RuleID, note.NoteID, err)
+			continue
+		}
+		tagged[rule.TagID] = true
+	}
+}
+
+// matchAutoTagRule ตรวจสอบว่าโน้ตตรงกับกฎหรือไม่ (การเทียบข้อความไม่สนตัวพิมพ์เล็ก/ใหญ่)
+func matchAutoTagRule(rule *entities.AutoTagRule, note *entities.Note) bool {
+	switch rule.Field {
+	case "title", "content", "color":
+		text := note.Title
+		if rule.Field == "content" {
+			text = note.Content
+			for _, todo := range note.TodoItems {
+				text += "\n" + todo.Content
+			}
+		} else if rule.Field == "color" {
+			text = note.Color
+		}
+
+		if rule.Operator == "contains" {
+			return strings.Contains(strings.ToLower(text), strings.ToLower(rule.Value))
+		}
+		return strings.EqualFold(strings.TrimSpace(text), strings.TrimSpace(rule.Value))
+
+	case "priority":
+		value, err := strconv.Atoi(rule.Value)
+		if err != nil {
+			return false
+		}
+		switch rule.Operator {
+		case "gte":
+			return note.Priority >= value
+		case "lte":
+			return note.Priority <= value
+		default:
+			return note.Priority == value
+		}
+
+	case "is_todo":
+		value, _ := strconv.ParseBool(rule.Value)
+		return note.IsTodo == value
+
+	case "has_reminder":
+		value, _ := strconv.ParseBool(rule.Value)
+		return (len(note.Reminder) > 0) == value
+	}
+	return false
+}
+
+func noteHasTag(note *entities.Note, tagID uint) bool {
+	for _, tag := range note.Tags {
+		if tag.TagID == tagID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"miw/entities"
+	"testing"
+)
+
+func TestMatchAutoTagRule(t *testing.T) {
+	note := &entities.Note{
+		Title:     "  Weekly Report ",
+		Content:   "Budget for Q3",
+		Color:     "#FFCC00",
+		Priority:  2,
+		IsTodo:    true,
+		TodoItems: []entities.ToDo{{Content: "Call the BANK"}},
+		Reminder:  []entities.Reminder{{ReminderID: 1}},
+	}
+	tests := []struct {
+		field, operator, value string
+		want                   bool
+	}{
+		{"title", "equals", "weekly report", true},
+		{"title", "equals", "weekly", false},
+		{"title", "contains", "REPORT", true},
+		{"content", "contains", "budget", true},
+		{"content", "contains", "bank", true},
+		{"content", "equals", "budget for q3", false},
+		{"color", "equals", "#ffcc00", true},
+		{"priority", "equals", "2", true},
+		{"priority", "gte", "2", true},
+		{"priority", "gte", "3", false},
+		{"priority", "lte", "1", false},
+		{"priority", "lte", "2", true},
+		{"priority", "equals", "high", false},
+		{"is_todo", "equals", "true", true},
+		{"is_todo", "equals", "false", false},
+		{"has_reminder", "equals", "true", true},
+		{"has_reminder", "equals", "false", false},
+		{"notebook", "equals", "1", false},
+	}
+	for _, tt := range tests {
+		rule := &entities.AutoTagRule{Field: tt.field, Operator: tt.operator, Value: tt.value}
+		if got := matchAutoTagRule(rule, note); got != tt.want {
+			t.Errorf("%s %s %q = %v, want %v", tt.field, tt.operator, tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"miw/entities"
+	"miw/utils"
+	"strings"
+	"time"
+)
+
+// enexNote โน้ตหนึ่งรายการใน ENEX (<en-export><note>...)
+type enexNote struct {
+	Title      string   `xml:"title"`
+	Content    string   `xml:"content"`
+	Created    string   `xml:"created"`
+	Updated    string   `xml:"updated"`
+	Tags       []string `xml:"tag"`
+	Attributes struct {
+		ReminderTime     string `xml:"reminder-time"`
+		ReminderDoneTime string `xml:"reminder-done-time"`
+	} `xml:"note-attributes"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data struct {
+		Encoding string `xml:"encoding,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"data"`
+	Attributes struct {
+		FileName string `xml:"file-name"`
+	} `xml:"resource-attributes"`
+}
+
+// enexImporter อ่าน ENEX แบบ stream ทีละ <note> เพื่อไม่ให้ต้องโหลดทั้งไฟล์ (ซึ่งอาจมีไฟล์แนบหลายร้อย MB) เข้าหน่วยความจำ
+type enexImporter struct {
+	file  io.ReaderAt
+	size  int64
+	count int
+}
+
+// newEnexImporter อ่านไฟล์หนึ่งรอบเพื่อตรวจรูปแบบและนับจำนวนโน้ตสำหรับแสดงความคืบหน้า
+func newEnexImporter(file io.ReaderAt, size int64) (noteImporter, error) {
+	importer := &enexImporter{file: file, size: size}
+	decoder := newEnexDecoder(io.NewSectionReader(file, 0, size))
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("file is not a valid ENEX file: %v", err)
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			if depth == 0 && element.Name.Local != "en-export" {
+				return nil, fmt.Errorf("file is not a valid ENEX file: root element must be en-export")
+			}
+			if depth == 1 && element.Name.Local == "note" {
+				importer.count++
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return importer, nil
+}
+
+func newEnexDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	// ENEX บางไฟล์มี entity ของ HTML เช่น &nbsp; นอก CDATA
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
+
+func (e *enexImporter) Count() int {
+	return e.count
+}
+
+func (e *enexImporter) Each(fn func(item string, note *importedNote, err error) error) error {
+	decoder := newEnexDecoder(io.NewSectionReader(e.file, 0, e.size))
+	index := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid ENEX file: %v", err)
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "note" {
+			continue
+		}
+
+		var enex enexNote
+		if err := decoder.DecodeElement(&enex, &element); err != nil {
+			return fmt.Errorf("invalid ENEX file: %v", err)
+		}
+		index++
+		item := strings.TrimSpace(enex.Title)
+		if item == "" {
+			item = fmt.Sprintf("note %d", index)
+		}
+		note, err := parseEnexNote(&enex)
+		if err := fn(item, note, err); err != nil {
+			return err
+		}
+	}
+}
+
+func parseEnexNote(enex *enexNote) (*importedNote, error) {
+	body, err := utils.ENMLToMarkdown(enex.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := &importedNote{
+		Note: entities.Note{
+			Title:         strings.TrimSpace(enex.Title),
+			Content:       body,
+			ContentFormat: utils.ContentFormatMarkdown,
+			CreatedAt:     enexTime(enex.Created, time.Local),
+			UpdatedAt:     enexTime(enex.Updated, time.Local),
+		},
+		Tags: enex.Tags,
+	}
+	// โน้ตในระบบเป็นได้ทั้งข้อความหรือรายการ To-Do อย่างใดอย่างหนึ่ง จึงแปลงเป็น To-Do เฉพาะโน้ตที่มีแต่ checkbox
+	// โน้ตที่มีข้อความปนกับ checkbox ยังคงเป็น Markdown และเก็บ checkbox ไว้เป็น task list "- [ ]" ในเนื้อหา
+	if todos, ok := parseTaskList(body); ok {
+		imported.Note.Content = ""
+		imported.Note.IsTodo = true
+		imported.Note.TodoItems = todos
+	}
+	// Reminder ที่ทำเสร็จแล้วใน Evernote ไม่ต้องนำเข้า
+	if enex.Attributes.ReminderDoneTime == "" {
+		imported.ReminderTime = enexTime(enex.Attributes.ReminderTime, reminderLocation())
+	}
+
+	for i, resource := range enex.Resources {
+		name := strings.TrimSpace(resource.Attributes.FileName)
+		if name == "" {
+			name = fmt.Sprintf("attachment-%d", i+1)
+		}
+		if resource.Data.Encoding != "" && resource.Data.Encoding != "base64" {
+			return nil, fmt.Errorf("attachment %s uses unsupported encoding %s", name, resource.Data.Encoding)
+		}
+		// base64 ใน ENEX ขึ้นบรรทัดใหม่ทุก 76 ตัวอักษร
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(resource.Data.Value), ""))
+		if err != nil {
+			return nil, fmt.Errorf("attachment %s is not valid base64", name)
+		}
+		imported.Attachments = append(imported.Attachments, importedAttachment{FileName: name, Data: data})
+	}
+	return imported, nil
+}
+
+// enexTime แปลงเวลาแบบ 20060102T150405Z (UTC) ของ ENEX เป็นเวลาใน location ที่กำหนด คืนค่าว่างถ้าอ่านไม่ได้
+func enexTime(value string, location *time.Location) string {
+	t, err := time.Parse("20060102T150405Z", strings.TrimSpace(value))
+	if err != nil {
+		return ""
+	}
+	return t.In(location).Format("2006-01-02 15:04:05")
+}
+
+// reminderLocation เขตเวลาที่ ReminderService ใช้อ่านเวลาของ Reminder (เวลาประเทศไทย)
+func reminderLocation() *time.Location {
+	location, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
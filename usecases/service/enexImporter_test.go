@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"miw/entities"
+	"strings"
+	"testing"
+)
+
+func TestParseEnexNote(t *testing.T) {
+	pdf := []byte("%PDF-1.4 binary \x00\x01\x02 data")
+	encoded := base64.StdEncoding.EncodeToString(bytes.Repeat(pdf, 10))
+	// ENEX ขึ้นบรรทัดใหม่ทุก 76 ตัวอักษร
+	var wrapped strings.Builder
+	for i := 0; i < len(encoded); i += 76 {
+		end := i + 76
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		wrapped.WriteString(encoded[i:end] + "\n")
+	}
+
+	enex := &enexNote{
+		Title:   " Trip ",
+		Content: `<en-note><div>Packing</div><ul><li><en-todo checked="true"/>passport</li></ul><en-media hash="1"/></en-note>`,
+		Created: "20240102T030405Z",
+		Updated: "bad time",
+		Tags:    []string{"travel", "เที่ยว"},
+	}
+	enex.Attributes.ReminderTime = "20990101T000000Z"
+	enex.Resources = make([]enexResource, 2)
+	enex.Resources[0].Data.Encoding = "base64"
+	enex.Resources[0].Data.Value = wrapped.String()
+	enex.Resources[0].Attributes.FileName = "ticket.pdf"
+	enex.Resources[1].Data.Value = base64.StdEncoding.EncodeToString([]byte("png"))
+
+	got, err := parseEnexNote(enex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ข้อความปนกับ checkbox ยังคงเป็น Markdown
+	if got.Note.Title != "Trip" || got.Note.IsTodo || got.Note.Content != "Packing\n\n- [x] passport" || got.Note.ContentFormat != "markdown" {
+		t.Fatalf("note = %+v", got.Note)
+	}
+	if got.Note.CreatedAt == "" || got.Note.UpdatedAt != "" {
+		t.Fatalf("created = %q updated = %q", got.Note.CreatedAt, got.Note.UpdatedAt)
+	}
+	if fmt.Sprint(got.Tags) != "[travel เที่ยว]" || got.ReminderTime != "2099-01-01 07:00:00" {
+		t.Fatalf("tags = %v reminder = %q", got.Tags, got.ReminderTime)
+	}
+	if len(got.Attachments) != 2 {
+		t.Fatalf("attachments = %d, want 2", len(got.Attachments))
+	}
+	if got.Attachments[0].FileName != "ticket.pdf" || !bytes.Equal(got.Attachments[0].Data, bytes.Repeat(pdf, 10)) {
+		t.Fatalf("first attachment = %s (%d bytes)", got.Attachments[0].FileName, len(got.Attachments[0].Data))
+	}
+	if got.Attachments[1].FileName != "attachment-2" || string(got.Attachments[1].Data) != "png" {
+		t.Fatalf("second attachment = %s %q", got.Attachments[1].FileName, got.Attachments[1].Data)
+	}
+}
+
+func TestParseEnexNoteChecklist(t *testing.T) {
+	enex := &enexNote{
+		Title:   "Groceries",
+		Content: `<en-note><div><en-todo checked="true"/>milk</div><div><en-todo/>eggs</div><div><br/></div></en-note>`,
+	}
+	enex.Attributes.ReminderTime = "20240101T000000Z"
+	enex.Attributes.ReminderDoneTime = "20240101T010000Z"
+
+	got, err := parseEnexNote(enex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []entities.ToDo{{Content: "milk", IsDone: true}, {Content: "eggs"}}
+	if !got.Note.IsTodo || got.Note.Content != "" || fmt.Sprint(got.Note.TodoItems) != fmt.Sprint(want) {
+		t.Fatalf("note = %+v", got.Note)
+	}
+	// Reminder ที่ทำเสร็จแล้วไม่ถูกนำเข้า
+	if got.ReminderTime != "" {
+		t.Fatalf("reminder = %q, want none", got.ReminderTime)
+	}
+}
+
+func TestParseEnexNoteInvalidResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		value    string
+		wantErr  string
+	}{
+		{name: "unsupported encoding", encoding: "hex", value: "00", wantErr: "attachment file.bin uses unsupported encoding hex"},
+		{name: "invalid base64", encoding: "base64", value: "not base64!", wantErr: "attachment file.bin is not valid base64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enex := &enexNote{Title: "x", Content: "<en-note>x</en-note>", Resources: make([]enexResource, 1)}
+			enex.Resources[0].Data.Encoding = tt.encoding
+			enex.Resources[0].Data.Value = tt.value
+			enex.Resources[0].Attributes.FileName = "file.bin"
+			if _, err := parseEnexNote(enex); err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnexImporter(t *testing.T) {
+	file := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export4.dtd">
+<en-export export-date="20240101T000000Z" application="Evernote">
+  <note><title>First</title><content><![CDATA[<en-note><p>one&nbsp;two</p></en-note>]]></content><tag>a</tag></note>
+  <note><title></title><content><![CDATA[<en-note>untitled</en-note>]]></content>
+    <resource><data encoding="base64">aGVsbG8=</data><resource-attributes><file-name>hello.txt</file-name></resource-attributes></resource>
+  </note>
+</en-export>`
+	importer, err := newEnexImporter(strings.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if importer.Count() != 2 {
+		t.Fatalf("Count() = %d, want 2", importer.Count())
+	}
+
+	var items []string
+	err = importer.Each(func(item string, note *importedNote, err error) error {
+		if err != nil {
+			return err
+		}
+		items = append(items, item+": "+note.Note.Content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(items, "; "); got != "First: one two; note 2: untitled" {
+		t.Fatalf("items = %s", got)
+	}
+
+	if _, err := newEnexImporter(strings.NewReader("<html></html>"), 13); err == nil {
+		t.Fatal("expected an error for a file that is not ENEX")
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"miw/entities"
+	"miw/usecases/repository"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ExportUseCase interface {
+	ExportMarkdown(userID uint, w io.Writer) error
+}
+
+// exportBatchSize จำนวนโน้ตที่โหลดจากฐานข้อมูลต่อครั้งระหว่าง export
+const exportBatchSize = 200
+
+// MarkdownFrontMatter ข้อมูลของโน้ตใน YAML front matter ของไฟล์ Markdown ที่ export
+type MarkdownFrontMatter struct {
+	Title         string             `yaml:"title"`
+	Tags          []string           `yaml:"tags,omitempty"`
+	Color         string             `yaml:"color,omitempty"`
+	Priority      int                `yaml:"priority,omitempty"`
+	ContentFormat string             `yaml:"content_format,omitempty"`
+	Pinned        bool               `yaml:"pinned,omitempty"`
+	Archived      string             `yaml:"archived,omitempty"`
+	Created       string             `yaml:"created,omitempty"`
+	Updated       string             `yaml:"updated,omitempty"`
+	Reminders     []MarkdownReminder `yaml:"reminders,omitempty"`
+	Event         *MarkdownEvent     `yaml:"event,omitempty"`
+}
+
+type MarkdownReminder struct {
+	Time      string `yaml:"time"`
+	Recurring bool   `yaml:"recurring,omitempty"`
+	Frequency string `yaml:"frequency,omitempty"`
+}
+
+type MarkdownEvent struct {
+	Start string `yaml:"start"`
+	End   string `yaml:"end,omitempty"`
+}
+
+type ExportService struct {
+	noteRepo     repository.NoteRepository
+	notebookRepo repository.NotebookRepository
+}
+
+func NewExportService(noteRepo repository.NoteRepository, notebookRepo repository.NotebookRepository) *ExportService {
+	return &ExportService{
+		noteRepo:     noteRepo,
+		notebookRepo: notebookRepo,
+	}
+}
+
+// ExportMarkdown เขียนไฟล์ ZIP ลง w โดยโน้ตแต่ละรายการเป็นไฟล์ Markdown ในโฟลเดอร์ตามสมุดโน้ต
+// โหลดโน้ตจากฐานข้อมูลทีละ batch และเขียนต่อเนื่องเพื่อไม่ให้ต้องเก็บโน้ตทั้งหมดไว้ในหน่วยความจำ
+func (s *ExportService) ExportMarkdown(userID uint, w io.Writer) error {
+	notebooks, err := s.notebookRepo.GetNotebooksByUser(userID)
+	if err != nil {
+		return err
+	}
+	folders := notebookFolders(notebooks)
+
+	zw := zip.NewWriter(w)
+	usedPaths := map[string]bool{}
+	err = s.noteRepo.FindNotesInBatches(userID, exportBatchSize, func(notes []entities.Note) error {
+		for i := range notes {
+			note := &notes[i]
+			folder := ""
+			if note.NotebookID != nil {
+				folder = folders[*note.NotebookID]
+			}
+			name := uniqueExportPath(usedPaths, folder, exportFileName(note.Title, "Untitled"), ".md")
+
+			body, err := MarshalMarkdownNote(note)
+			if err != nil {
+				return fmt.Errorf("failed to render note %d: %v", note.NoteID, err)
+			}
+			header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+			if modified, err := time.ParseInLocation("2006-01-02 15:04:05", firstNonEmpty(note.UpdatedAt, note.CreatedAt), time.Local); err == nil {
+				header.Modified = modified
+			}
+			file, err := zw.CreateHeader(header)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", name, err)
+			}
+			if _, err := file.Write(body); err != nil {
+				return fmt.Errorf("failed to write %s: %v", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize export: %v", err)
+	}
+	return nil
+}
+
+// MarshalMarkdownNote แปลงโน้ตเป็น Markdown ที่มี YAML front matter รายการ To-Do เขียนเป็น GitHub task list
+func MarshalMarkdownNote(note *entities.Note) ([]byte, error) {
+	meta := MarkdownFrontMatter{
+		Title:         note.Title,
+		Color:         note.Color,
+		Priority:      note.Priority,
+		ContentFormat: note.ContentFormat,
+		Pinned:        note.IsPinned,
+		Archived:      note.ArchivedAt,
+		Created:       note.CreatedAt,
+		Updated:       note.UpdatedAt,
+	}
+	for _, tag := range note.Tags {
+		meta.Tags = append(meta.Tags, tag.TagName)
+	}
+	for _, reminder := range note.Reminder {
+		meta.Reminders = append(meta.Reminders, MarkdownReminder{
+			Time:      reminder.ReminderTime,
+			Recurring: reminder.Recurring,
+			Frequency: reminder.Frequency,
+		})
+	}
+	if note.Event.EventID != 0 {
+		meta.Event = &MarkdownEvent{Start: note.Event.StartTime, End: note.Event.EndTime}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&meta); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("---\n\n")
+	if len(note.TodoItems) > 0 {
+		for _, todo := range note.TodoItems {
+			mark := " "
+			if todo.IsDone {
+				mark = "x"
+			}
+			// รายการที่มีหลายบรรทัดต้องอยู่ในบรรทัดเดียวเพื่อให้ยังเป็น task list
+			content := strings.Join(strings.Fields(todo.Content), " ")
+			fmt.Fprintf(&buf, "- [%s] %s\n", mark, content)
+		}
+	} else if note.Content != "" {
+		buf.WriteString(note.Content)
+		if !strings.HasSuffix(note.Content, "\n") {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// notebookFolders สร้าง path ของโฟลเดอร์ตามลำดับชั้นของสมุดโน้ต เช่น "Work/Projects"
+func notebookFolders(notebooks []entities.Notebook) map[uint]string {
+	byID := make(map[uint]*entities.Notebook, len(notebooks))
+	for i := range notebooks {
+		byID[notebooks[i].NotebookID] = &notebooks[i]
+	}
+
+	folders := make(map[uint]string, len(notebooks))
+	var folderOf func(id uint, depth int) string
+	folderOf = func(id uint, depth int) string {
+		if folder, ok := folders[id]; ok {
+			return folder
+		}
+		notebook := byID[id]
+		if notebook == nil || depth > len(notebooks) {
+			return ""
+		}
+		folder := exportFileName(notebook.Name, "Notebook")
+		if notebook.ParentNotebookID != nil {
+			if parent := folderOf(*notebook.ParentNotebookID, depth+1); parent != "" {
+				folder = parent + "/" + folder
+			}
+		}
+		folders[id] = folder
+		return folder
+	}
+	for _, notebook := range notebooks {
+		folderOf(notebook.NotebookID, 0)
+	}
+	return folders
+}
+
+// exportFileName ตัดอักขระที่ใช้เป็นชื่อไฟล์ไม่ได้ออก และจำกัดความยาว ชื่อที่ว่างจะใช้ fallback แทน
+func exportFileName(name string, fallback string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			return -1
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '-'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	for utf8.RuneCountInString(name) > 100 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// uniqueExportPath เติม " (2)", " (3)" ต่อท้ายชื่อไฟล์ที่ซ้ำกันในโฟลเดอร์เดียวกัน (ไม่สนตัวพิมพ์เล็ก/ใหญ่)
+func uniqueExportPath(used map[string]bool, folder string, name string, ext string) string {
+	candidate := path.Join(folder, name+ext)
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		candidate = path.Join(folder, fmt.Sprintf("%s (%d)%s", name, n, ext))
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
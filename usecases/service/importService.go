@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"strings"
+	"time"
+)
+
+type ImportUseCase interface {
+	StartImport(userID uint, source string, fileName string, size int64, file io.Reader) (*entities.ImportJob, error)
+	GetImportJob(jobID uint, userID uint) (*entities.ImportJob, error)
+	GetImportJobs(userID uint) ([]entities.ImportJob, error)
+}
+
+// ImportSources แหล่งข้อมูลที่นำเข้าได้
+var ImportSources = []string{entities.ImportSourceKeep, entities.ImportSourceMarkdown, entities.ImportSourceEnex}
+
+type ImportService struct {
+	jobRepo      repository.ImportJobRepository
+	blobStore    repository.BlobStore
+	importQueue  ImportQueue
+	maxFileBytes int64
+}
+
+func NewImportService(jobRepo repository.ImportJobRepository, blobStore repository.BlobStore, importQueue ImportQueue, maxFileBytes int64) *ImportService {
+	return &ImportService{
+		jobRepo:      jobRepo,
+		blobStore:    blobStore,
+		importQueue:  importQueue,
+		maxFileBytes: maxFileBytes,
+	}
+}
+
+// StartImport เก็บไฟล์ที่อัปโหลดลง BlobStore แล้วสร้างงานนำเข้าและส่งเข้าคิว ผลการนำเข้าดูได้จาก GetImportJob
+func (s *ImportService) StartImport(userID uint, source string, fileName string, size int64, file io.Reader) (*entities.ImportJob, error) {
+	source = strings.ToLower(strings.TrimSpace(source))
+	validSource := false
+	for _, name := range ImportSources {
+		if source == name {
+			validSource = true
+		}
+	}
+	if !validSource {
+		validation := &utils.ValidationError{}
+		validation.Add("source", "must be one of "+strings.Join(ImportSources, ", "))
+		return nil, validation
+	}
+
+	if size <= 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if s.maxFileBytes > 0 && size > s.maxFileBytes {
+		return nil, fmt.Errorf("file is too large: maximum size is %d bytes", s.maxFileBytes)
+	}
+
+	// ตรวจชนิดไฟล์จากส่วนต้นของไฟล์ก่อนเก็บ เพื่อให้ผู้ใช้รู้ทันทีถ้าเลือกไฟล์ผิด
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	head = head[:n]
+	contentType := "application/zip"
+	if source == entities.ImportSourceEnex {
+		contentType = "application/xml"
+		text := strings.TrimLeft(strings.TrimPrefix(string(head), "\ufeff"), " \t\r\n")
+		if !strings.HasPrefix(text, "<?xml") && !strings.HasPrefix(text, "<!DOCTYPE") && !strings.HasPrefix(text, "<en-export") {
+			return nil, fmt.Errorf("file must be an ENEX file")
+		}
+	} else if !bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		return nil, fmt.Errorf("file must be a ZIP archive")
+	}
+
+	key, err := newStorageKey(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage key: %v", err)
+	}
+	key = "imports/" + key
+	if err := s.blobStore.Put(key, io.MultiReader(bytes.NewReader(head), file), size, contentType); err != nil {
+		return nil, err
+	}
+
+	job := &entities.ImportJob{
+		UserID:     userID,
+		Source:     source,
+		FileName:   cleanFileName(fileName),
+		StorageKey: key,
+		Status:     entities.ImportStatusPending,
+		Errors:     []entities.ImportError{},
+		CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := s.jobRepo.CreateImportJob(job); err != nil {
+		if deleteErr := s.blobStore.Delete(key); deleteErr != nil {
+			log.Printf("Failed to remove orphaned blob %s: %v", key, deleteErr)
+		}
+		return nil, err
+	}
+
+	if s.importQueue != nil {
+		s.importQueue.Enqueue(job.JobID)
+	}
+	return job, nil
+}
+
+func (s *ImportService) GetImportJob(jobID uint, userID uint) (*entities.ImportJob, error) {
+	job, err := s.jobRepo.GetImportJobById(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, fmt.Errorf("import job not found")
+	}
+	return job, nil
+}
+
+// GetImportJobs งานนำเข้าทั้งหมดของผู้ใช้ เรียงจากงานล่าสุด
+func (s *ImportService) GetImportJobs(userID uint) ([]entities.ImportJob, error) {
+	return s.jobRepo.GetImportJobsByUser(userID)
+}
@@ -0,0 +1,501 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"os"
+	"strings"
+	"time"
+)
+
+// ImportQueue คิวงานนำเข้า ImportService ใช้ส่งงานที่สร้างใหม่เข้าคิว
+type ImportQueue interface {
+	Enqueue(jobID uint)
+}
+
+const (
+	// maxImportErrors จำนวนรายการสูงสุดในรายงาน error ของงาน (จำนวนที่ล้มเหลวทั้งหมดดูได้จาก Failed)
+	maxImportErrors = 500
+	// maxImportItemBytes ขนาดสูงสุดของไฟล์โน้ตหนึ่งไฟล์ใน ZIP หลังคลายการบีบอัด
+	maxImportItemBytes = 5 << 20
+	// importProgressInterval ระยะเวลาระหว่างการบันทึกความคืบหน้าลงฐานข้อมูล
+	importProgressInterval = time.Second
+)
+
+// importedNote โน้ตหนึ่งรายการที่อ่านจากไฟล์ต้นทาง
+type importedNote struct {
+	Note         entities.Note // ใช้ Title, Content, ContentFormat, Color, Priority, IsTodo, TodoItems, IsPinned, ArchivedAt, CreatedAt, UpdatedAt
+	Tags         []string
+	Folder       string // path ของสมุดโน้ต เช่น "Work/Projects" (ว่าง = Inbox)
+	Trashed      bool
+	ReminderTime string // เวลาประเทศไทยตามที่ ReminderService ใช้ (ว่าง = ไม่มี Reminder)
+	Attachments  []importedAttachment
+}
+
+// importedAttachment ไฟล์แนบของโน้ตที่ฝังมาในไฟล์ต้นทาง
+type importedAttachment struct {
+	FileName string
+	Data     []byte
+}
+
+// noteImporter อ่านโน้ตจากไฟล์ต้นทางทีละรายการ
+// Each เรียก fn ทุกรายการ ถ้ารายการใดอ่านไม่ได้ err จะไม่เป็น nil และงานจะทำรายการถัดไปต่อ ถ้า fn คืน error จะหยุดทันที
+type noteImporter interface {
+	Count() int
+	Each(fn func(item string, note *importedNote, err error) error) error
+}
+
+// openImporter สร้าง noteImporter ตามแหล่งข้อมูลของงาน
+func openImporter(source string, file io.ReaderAt, size int64) (noteImporter, error) {
+	switch source {
+	case entities.ImportSourceEnex:
+		return newEnexImporter(file, size)
+	case entities.ImportSourceKeep, entities.ImportSourceMarkdown:
+		archive, err := zip.NewReader(file, size)
+		if err != nil {
+			return nil, fmt.Errorf("file is not a valid ZIP archive")
+		}
+		if source == entities.ImportSourceKeep {
+			return newKeepImporter(archive), nil
+		}
+		return newMarkdownImporter(archive), nil
+	}
+	return nil, fmt.Errorf("unsupported import source %s", source)
+}
+
+// zipImporter อ่านโน้ตจากไฟล์ใน ZIP หนึ่งไฟล์ต่อหนึ่งโน้ต
+type zipImporter struct {
+	files []*zip.File
+	parse func(name string, data []byte) (*importedNote, error)
+}
+
+func (z *zipImporter) Count() int {
+	return len(z.files)
+}
+
+func (z *zipImporter) Each(fn func(item string, note *importedNote, err error) error) error {
+	for _, file := range z.files {
+		var note *importedNote
+		data, err := readZipFile(file)
+		if err == nil {
+			note, err = z.parse(file.Name, data)
+		}
+		if err := fn(file.Name, note, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readZipFile อ่านไฟล์ใน ZIP โดยจำกัดขนาดหลังคลายการบีบอัด เพื่อป้องกัน ZIP bomb
+func readZipFile(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > maxImportItemBytes {
+		return nil, fmt.Errorf("file is too large: maximum size is %d bytes", maxImportItemBytes)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, maxImportItemBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	if len(data) > maxImportItemBytes {
+		return nil, fmt.Errorf("file is too large: maximum size is %d bytes", maxImportItemBytes)
+	}
+	return data, nil
+}
+
+// isHiddenZipEntry ไฟล์ระบบที่ไม่ใช่โน้ต เช่น __MACOSX/ หรือ .DS_Store
+func isHiddenZipEntry(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportWorker ทำงานนำเข้าแบบ background ด้วย goroutine หลายตัว
+// โน้ตถูกสร้างผ่าน NoteUseCase เพื่อให้กฎแท็กอัตโนมัติและลิงก์ [[...]] ทำงานเหมือนโน้ตที่สร้างเอง
+type ImportWorker struct {
+	jobRepo           repository.ImportJobRepository
+	noteRepo          repository.NoteRepository
+	tagRepo           repository.TagRepository
+	reminderRepo      repository.ReminderRepository
+	noteUseCase       NoteUseCase
+	notebookUseCase   NotebookUseCase
+	reminderUseCase   ReminderUseCase
+	attachmentUseCase AttachmentUseCase
+	blobStore         repository.BlobStore
+	workers           int
+	jobs              chan uint
+}
+
+func NewImportWorker(jobRepo repository.ImportJobRepository, noteRepo repository.NoteRepository, tagRepo repository.TagRepository, reminderRepo repository.ReminderRepository, noteUseCase NoteUseCase, notebookUseCase NotebookUseCase, reminderUseCase ReminderUseCase, attachmentUseCase AttachmentUseCase, blobStore repository.BlobStore, workers int) *ImportWorker {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &ImportWorker{
+		jobRepo:           jobRepo,
+		noteRepo:          noteRepo,
+		tagRepo:           tagRepo,
+		reminderRepo:      reminderRepo,
+		noteUseCase:       noteUseCase,
+		notebookUseCase:   notebookUseCase,
+		reminderUseCase:   reminderUseCase,
+		attachmentUseCase: attachmentUseCase,
+		blobStore:         blobStore,
+		workers:           workers,
+		jobs:              make(chan uint, 64),
+	}
+}
+
+// Start เริ่ม worker และนำงาน pending กลับเข้าคิว งานที่ค้างสถานะ running ตอนเซิร์ฟเวอร์ปิดจะถูกบันทึกว่าล้มเหลว
+// เพราะทำซ้ำไม่ได้โดยไม่สร้างโน้ตซ้ำ
+func (w *ImportWorker) Start() {
+	for i := 0; i < w.workers; i++ {
+		go func() {
+			for jobID := range w.jobs {
+				w.process(jobID)
+			}
+		}()
+	}
+
+	jobs, err := w.jobRepo.GetUnfinishedImportJobs()
+	if err != nil {
+		log.Printf("Failed to load unfinished import jobs: %v", err)
+		return
+	}
+	var pending []uint
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Status == entities.ImportStatusPending {
+			pending = append(pending, job.JobID)
+			continue
+		}
+		w.finish(job, fmt.Errorf("import was interrupted by a server restart"))
+	}
+	go func() {
+		for _, jobID := range pending {
+			w.jobs <- jobID
+		}
+	}()
+}
+
+// Enqueue ส่งงานเข้าคิวโดยไม่บล็อก request ถ้าคิวเต็มจะรอส่งใน goroutine จนกว่า worker จะว่าง
+func (w *ImportWorker) Enqueue(jobID uint) {
+	select {
+	case w.jobs <- jobID:
+	default:
+		go func() {
+			w.jobs <- jobID
+		}()
+	}
+}
+
+func (w *ImportWorker) process(jobID uint) {
+	job, err := w.jobRepo.GetImportJobById(jobID)
+	if err != nil {
+		return
+	}
+	if job.Status != entities.ImportStatusPending {
+		return
+	}
+
+	job.Status = entities.ImportStatusRunning
+	job.StartedAt = time.Now().Format("2006-01-02 15:04:05")
+	if err := w.jobRepo.UpdateImportJob(job); err != nil {
+		log.Printf("Failed to start import job %d: %v", jobID, err)
+		if err.Error() == "import job not found" {
+			w.deleteUpload(job)
+		}
+		return
+	}
+
+	w.finish(job, w.run(job))
+}
+
+// finish บันทึกผลของงานและลบไฟล์ที่อัปโหลดซึ่งไม่ต้องใช้แล้ว
+func (w *ImportWorker) finish(job *entities.ImportJob, err error) {
+	job.Status = entities.ImportStatusCompleted
+	if err != nil {
+		job.Status = entities.ImportStatusFailed
+		job.Error = err.Error()
+	}
+	job.FinishedAt = time.Now().Format("2006-01-02 15:04:05")
+	w.deleteUpload(job)
+	if err := w.jobRepo.UpdateImportJob(job); err != nil {
+		log.Printf("Failed to save result of import job %d: %v", job.JobID, err)
+	}
+}
+
+func (w *ImportWorker) deleteUpload(job *entities.ImportJob) {
+	if job.StorageKey == "" {
+		return
+	}
+	if err := w.blobStore.Delete(job.StorageKey); err != nil {
+		log.Printf("Failed to delete blob %s: %v", job.StorageKey, err)
+		return
+	}
+	job.StorageKey = ""
+}
+
+// run คัดลอกไฟล์จาก BlobStore ลงไฟล์ชั่วคราว (ZIP ต้องอ่านแบบ random access) แล้วนำเข้าโน้ตทีละรายการ
+func (w *ImportWorker) run(job *entities.ImportJob) error {
+	reader, err := w.blobStore.Get(job.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to read uploaded file: %v", err)
+	}
+	tmp, err := os.CreateTemp("", "import-*")
+	if err != nil {
+		reader.Close()
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read uploaded file: %v", err)
+	}
+
+	importer, err := openImporter(job.Source, tmp, size)
+	if err != nil {
+		return err
+	}
+	job.Total = importer.Count()
+
+	target, err := w.newImportTarget(job.UserID)
+	if err != nil {
+		return err
+	}
+	lastSaved := time.Now()
+	report := func(item string, message string) {
+		if len(job.Errors) < maxImportErrors {
+			job.Errors = append(job.Errors, entities.ImportError{Item: item, Error: message})
+		}
+	}
+	return importer.Each(func(item string, note *importedNote, err error) error {
+		var warnings []string
+		if err == nil {
+			warnings, err = target.save(note)
+		}
+		job.Processed++
+		if err != nil {
+			job.Failed++
+			report(item, err.Error())
+		} else {
+			job.Imported++
+		}
+		// ส่วนที่นำเข้าไม่ได้ของโน้ตที่นำเข้าสำเร็จ (เช่น ไฟล์แนบชนิดที่ไม่อนุญาต) ก็อยู่ในรายงานด้วย
+		for _, warning := range warnings {
+			report(item, warning)
+		}
+
+		if time.Since(lastSaved) < importProgressInterval {
+			return nil
+		}
+		lastSaved = time.Now()
+		// งานถูกลบระหว่างทำ (ผู้ใช้ลบบัญชี) จะได้ error และหยุดทันที
+		return w.jobRepo.UpdateImportJob(job)
+	})
+}
+
+// importTarget สร้างโน้ตของผู้ใช้หนึ่งคน พร้อม cache แท็กและสมุดโน้ตเพื่อไม่ให้สร้างซ้ำ
+type importTarget struct {
+	worker    *ImportWorker
+	userID    uint
+	tags      map[string]uint // ชื่อแท็ก (ตัวพิมพ์เล็ก) -> tag_id
+	notebooks map[string]uint // path ของสมุดโน้ต (ตัวพิมพ์เล็ก) -> notebook_id
+}
+
+func (w *ImportWorker) newImportTarget(userID uint) (*importTarget, error) {
+	target := &importTarget{worker: w, userID: userID}
+	if err := target.loadTags(); err != nil {
+		return nil, err
+	}
+
+	notebooks, err := w.notebookUseCase.GetNotebooks(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notebooks: %v", err)
+	}
+	target.notebooks = map[string]uint{}
+	for notebookID, folder := range notebookFolders(notebooks) {
+		target.notebooks[strings.ToLower(folder)] = notebookID
+	}
+	return target, nil
+}
+
+func (t *importTarget) loadTags() error {
+	tags, err := t.worker.tagRepo.GetTagsByUser(t.userID)
+	if err != nil {
+		return fmt.Errorf("failed to load tags: %v", err)
+	}
+	t.tags = map[string]uint{}
+	for _, tag := range tags {
+		t.tags[strings.ToLower(tag.TagName)] = tag.TagID
+	}
+	return nil
+}
+
+// tagID หาแท็กตามชื่อโดยไม่สนตัวพิมพ์เล็ก/ใหญ่ ถ้ายังไม่มีจะสร้างใหม่
+func (t *importTarget) tagID(name string) (uint, error) {
+	if tagID, ok := t.tags[strings.ToLower(name)]; ok {
+		return tagID, nil
+	}
+	tag := &entities.Tag{UserID: t.userID, TagName: name}
+	if err := t.worker.tagRepo.CreateTag(tag); err != nil {
+		// แท็กอาจถูกสร้างพร้อมกันจาก request อื่น ลองโหลดใหม่อีกครั้ง
+		if loadErr := t.loadTags(); loadErr == nil {
+			if tagID, ok := t.tags[strings.ToLower(name)]; ok {
+				return tagID, nil
+			}
+		}
+		return 0, fmt.Errorf("failed to create tag %s: %v", name, err)
+	}
+	t.tags[strings.ToLower(name)] = tag.TagID
+	return tag.TagID, nil
+}
+
+// notebookID หาสมุดโน้ตตาม path เช่น "Work/Projects" ถ้ายังไม่มีจะสร้างทุกระดับที่ขาด
+func (t *importTarget) notebookID(folder string) (uint, error) {
+	var parentID *uint
+	current := ""
+	for _, part := range strings.Split(folder, "/") {
+		name := exportFileName(part, "")
+		if name == "" {
+			continue
+		}
+		if current != "" {
+			current += "/"
+		}
+		current += name
+
+		if notebookID, ok := t.notebooks[strings.ToLower(current)]; ok {
+			parentID = &notebookID
+			continue
+		}
+		notebook := &entities.Notebook{UserID: t.userID, Name: name, ParentNotebookID: parentID}
+		if err := t.worker.notebookUseCase.CreateNotebook(notebook); err != nil {
+			return 0, fmt.Errorf("failed to create notebook %s: %v", current, err)
+		}
+		t.notebooks[strings.ToLower(current)] = notebook.NotebookID
+		parentID = &notebook.NotebookID
+	}
+	if parentID == nil {
+		return 0, fmt.Errorf("invalid folder %s", folder)
+	}
+	return *parentID, nil
+}
+
+// save สร้างโน้ตผ่าน NoteUseCase แล้วติดแท็ก คืนเวลาสร้างเดิม ตั้ง Reminder เพิ่มไฟล์แนบ และย้ายโน้ตที่อยู่ในถังขยะของต้นทางไปถังขยะ
+// สี ระดับความสำคัญ หรือรูปแบบเนื้อหาที่ใช้ไม่ได้จะถูกเปลี่ยนเป็นค่าเริ่มต้นแทนการทำให้ทั้งโน้ตล้มเหลว
+// Reminder และไฟล์แนบที่เพิ่มไม่ได้ไม่ทำให้โน้ตล้มเหลว แต่คืนเป็นคำเตือนเพื่อใส่ในรายงาน
+func (t *importTarget) save(in *importedNote) ([]string, error) {
+	note := in.Note
+	note.UserID = t.userID
+	note.Color = utils.NormalizeColor(note.Color)
+	if !utils.IsValidColor(note.Color) {
+		note.Color = ""
+	}
+	if !utils.IsValidPriority(note.Priority) {
+		note.Priority = utils.PriorityNone
+	}
+	if note.ContentFormat = utils.NormalizeContentFormat(note.ContentFormat); !utils.IsValidContentFormat(note.ContentFormat) {
+		note.ContentFormat = utils.ContentFormatPlain
+	}
+	note.IsTodo = note.IsTodo || len(note.TodoItems) > 0
+
+	if in.Folder != "" {
+		notebookID, err := t.notebookID(in.Folder)
+		if err != nil {
+			return nil, err
+		}
+		note.NotebookID = &notebookID
+	}
+
+	// สร้างแท็กก่อนโน้ต เพื่อไม่ให้เหลือโน้ตที่ติดแท็กไม่ครบเมื่อสร้างแท็กไม่ได้
+	var tagIDs []uint
+	seen := map[uint]bool{}
+	for _, name := range in.Tags {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		tagID, err := t.tagID(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tagID] {
+			seen[tagID] = true
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+
+	createdAt := note.CreatedAt
+	if err := t.worker.noteUseCase.CreateNote(&note); err != nil {
+		return nil, err
+	}
+	for _, tagID := range tagIDs {
+		if err := t.worker.noteUseCase.AddTagToNote(note.NoteID, tagID, t.userID); err != nil {
+			return nil, fmt.Errorf("note was created but a tag could not be added: %v", err)
+		}
+	}
+	if createdAt != "" {
+		if err := t.worker.noteRepo.UpdateNoteFields(note.NoteID, t.userID, map[string]interface{}{"created_at": createdAt}); err != nil {
+			return nil, fmt.Errorf("note was created but its creation time could not be kept: %v", err)
+		}
+	}
+
+	var warnings []string
+	if in.ReminderTime != "" && !in.Trashed {
+		if err := t.addReminder(note.NoteID, in.ReminderTime); err != nil {
+			warnings = append(warnings, fmt.Sprintf("reminder was skipped: %v", err))
+		}
+	}
+	// ต้องเพิ่มไฟล์แนบก่อนย้ายไปถังขยะ เพราะโน้ตในถังขยะแนบไฟล์ไม่ได้
+	for _, attachment := range in.Attachments {
+		if _, err := t.worker.attachmentUseCase.UploadAttachment(note.NoteID, t.userID, attachment.FileName, int64(len(attachment.Data)), bytes.NewReader(attachment.Data)); err != nil {
+			warnings = append(warnings, fmt.Sprintf("attachment %s was skipped: %v", attachment.FileName, err))
+		}
+	}
+	if in.Trashed {
+		if err := t.worker.noteUseCase.DeleteNoteById(note.NoteID, t.userID); err != nil {
+			return warnings, fmt.Errorf("note was created but could not be moved to trash: %v", err)
+		}
+	}
+	return warnings, nil
+}
+
+// addReminder ตั้ง Reminder ที่ยังไม่ถึงเวลาผ่าน ReminderUseCase เพื่อให้แจ้งเตือน ส่วน Reminder ที่เลยเวลาแล้ว
+// (ซึ่ง ReminderUseCase ไม่รับ) บันทึกตรงลงฐานข้อมูลเพื่อเก็บประวัติไว้โดยไม่แจ้งเตือน
+func (t *importTarget) addReminder(noteID uint, reminderTime string) error {
+	reminder := &entities.Reminder{NoteID: noteID, ReminderTime: reminderTime}
+	at, err := time.ParseInLocation("2006-01-02 15:04:05", reminderTime, reminderLocation())
+	if err != nil {
+		return err
+	}
+	if at.After(time.Now()) {
+		return t.worker.reminderUseCase.AddReminder(noteID, t.userID, reminder)
+	}
+	return t.worker.reminderRepo.AddReminder(noteID, reminder)
+}
+
+// importTime แปลงเวลาในไฟล์ต้นทางเป็นรูปแบบที่ใช้ในระบบ คืนค่าว่างถ้าอ่านไม่ได้
+func importTime(value string) string {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t.Local().Format("2006-01-02 15:04:05")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnqueueWhenQueueIsFull(t *testing.T) {
+	worker := &ImportWorker{jobs: make(chan uint, 2)}
+	for jobID := uint(1); jobID <= 5; jobID++ {
+		worker.Enqueue(jobID)
+	}
+
+	received := map[uint]bool{}
+	for len(received) < 5 {
+		select {
+		case jobID := <-worker.jobs:
+			received[jobID] = true
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of 5 jobs reached the queue: %v", len(received), received)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"miw/entities"
+	"miw/utils"
+	"path"
+	"strings"
+	"time"
+)
+
+// keepNote โน้ตหนึ่งไฟล์ใน Google Takeout (Keep/*.json)
+type keepNote struct {
+	Title       string `json:"title"`
+	TextContent string `json:"textContent"`
+	ListContent []struct {
+		Text      string `json:"text"`
+		IsChecked bool   `json:"isChecked"`
+	} `json:"listContent"`
+	Color      string `json:"color"`
+	IsPinned   bool   `json:"isPinned"`
+	IsArchived bool   `json:"isArchived"`
+	IsTrashed  bool   `json:"isTrashed"`
+	Labels     []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	CreatedTimestampUsec    int64 `json:"createdTimestampUsec"`
+	UserEditedTimestampUsec int64 `json:"userEditedTimestampUsec"`
+}
+
+// keepColors สีของ Keep -> สีใน NoteColors (Keep มีสีฟ้าสองระดับ จึงใช้ blue ทั้งคู่)
+var keepColors = map[string]string{
+	"DEFAULT":  "",
+	"RED":      "red",
+	"ORANGE":   "orange",
+	"YELLOW":   "yellow",
+	"GREEN":    "green",
+	"TEAL":     "teal",
+	"BLUE":     "blue",
+	"CERULEAN": "blue",
+	"PURPLE":   "purple",
+	"PINK":     "pink",
+	"BROWN":    "brown",
+	"GRAY":     "gray",
+}
+
+// newKeepImporter อ่านไฟล์ .json ในโฟลเดอร์ Keep ของ Takeout ถ้า ZIP ไม่มีโฟลเดอร์ Keep (เช่น ผู้ใช้ zip เฉพาะไฟล์) จะใช้ .json ทุกไฟล์
+// ไฟล์ .html และไฟล์แนบที่ Takeout ใส่มาด้วยจะถูกข้าม
+func newKeepImporter(archive *zip.Reader) noteImporter {
+	var all, inKeep []*zip.File
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || isHiddenZipEntry(file.Name) || !strings.EqualFold(path.Ext(file.Name), ".json") {
+			continue
+		}
+		all = append(all, file)
+		for _, part := range strings.Split(path.Dir(file.Name), "/") {
+			if strings.EqualFold(part, "Keep") {
+				inKeep = append(inKeep, file)
+				break
+			}
+		}
+	}
+	if len(inKeep) > 0 {
+		all = inKeep
+	}
+	return &zipImporter{files: all, parse: parseKeepNote}
+}
+
+func parseKeepNote(name string, data []byte) (*importedNote, error) {
+	var keep keepNote
+	if err := json.Unmarshal(data, &keep); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	if keep.Title == "" && keep.TextContent == "" && len(keep.ListContent) == 0 && keep.UserEditedTimestampUsec == 0 {
+		return nil, fmt.Errorf("file is not a Google Keep note")
+	}
+
+	imported := &importedNote{
+		Note: entities.Note{
+			Title:         keep.Title,
+			Content:       keep.TextContent,
+			ContentFormat: utils.ContentFormatPlain,
+			Color:         keepColors[strings.ToUpper(keep.Color)],
+			IsPinned:      keep.IsPinned,
+			CreatedAt:     keepTime(keep.CreatedTimestampUsec),
+			UpdatedAt:     keepTime(keep.UserEditedTimestampUsec),
+		},
+		Trashed: keep.IsTrashed,
+	}
+	if len(keep.ListContent) > 0 {
+		// Keep ไม่มีทั้งข้อความและรายการในโน้ตเดียวกัน แต่ถ้ามีให้ใช้รายการเพราะโน้ตในระบบเก็บได้แบบเดียว
+		imported.Note.Content = ""
+		imported.Note.IsTodo = true
+		for _, item := range keep.ListContent {
+			imported.Note.TodoItems = append(imported.Note.TodoItems, entities.ToDo{Content: item.Text, IsDone: item.IsChecked})
+		}
+	}
+	if keep.IsArchived {
+		imported.Note.ArchivedAt = imported.Note.UpdatedAt
+		if imported.Note.ArchivedAt == "" {
+			imported.Note.ArchivedAt = time.Now().Format("2006-01-02 15:04:05")
+		}
+	}
+	for _, label := range keep.Labels {
+		imported.Tags = append(imported.Tags, label.Name)
+	}
+	return imported, nil
+}
+
+// keepTime แปลงเวลาแบบ microsecond ของ Keep (0 = ไม่มีข้อมูล)
+func keepTime(usec int64) string {
+	if usec <= 0 {
+		return ""
+	}
+	return time.UnixMicro(usec).Local().Format("2006-01-02 15:04:05")
+}
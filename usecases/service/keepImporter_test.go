@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeepNote(t *testing.T) {
+	const created, edited = int64(1700000000000000), int64(1700000600000000)
+	localTime := func(usec int64) string {
+		return time.UnixMicro(usec).Local().Format("2006-01-02 15:04:05")
+	}
+
+	tests := []struct {
+		name    string
+		json    string
+		want    entities.Note
+		tags    []string
+		trashed bool
+		wantErr string
+	}{
+		{
+			name: "text note",
+			json: fmt.Sprintf(`{"title":"สวัสดี","textContent":"line 1\nline 2","color":"CERULEAN","isPinned":true,
+				"labels":[{"name":"work"},{"name":"งาน"}],"createdTimestampUsec":%d,"userEditedTimestampUsec":%d}`, created, edited),
+			want: entities.Note{Title: "สวัสดี", Content: "line 1\nline 2", ContentFormat: "plain", Color: "blue", IsPinned: true,
+				CreatedAt: localTime(created), UpdatedAt: localTime(edited)},
+			tags: []string{"work", "งาน"},
+		},
+		{
+			name: "checklist wins over text",
+			json: `{"title":"Shopping","textContent":"ignored","listContent":[{"text":"milk","isChecked":true},{"text":"eggs"}],"color":"DEFAULT"}`,
+			want: entities.Note{Title: "Shopping", ContentFormat: "plain", IsTodo: true,
+				TodoItems: []entities.ToDo{{Content: "milk", IsDone: true}, {Content: "eggs"}}},
+		},
+		{
+			name:    "archived and trashed",
+			json:    fmt.Sprintf(`{"title":"Old","isArchived":true,"isTrashed":true,"userEditedTimestampUsec":%d}`, edited),
+			want:    entities.Note{Title: "Old", ContentFormat: "plain", UpdatedAt: localTime(edited), ArchivedAt: localTime(edited)},
+			trashed: true,
+		},
+		{
+			name: "unknown color",
+			json: `{"textContent":"x","color":"NEON"}`,
+			want: entities.Note{Content: "x", ContentFormat: "plain"},
+		},
+		{name: "invalid JSON", json: `{"title":`, wantErr: "invalid JSON"},
+		{name: "other JSON file", json: `{"kind":"something else"}`, wantErr: "file is not a Google Keep note"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseKeepNote("Keep/note.json", []byte(tt.json))
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprintf("%+v", got.Note) != fmt.Sprintf("%+v", tt.want) {
+				t.Fatalf("note = %+v\nwant   %+v", got.Note, tt.want)
+			}
+			if fmt.Sprint(got.Tags) != fmt.Sprint(tt.tags) || got.Trashed != tt.trashed {
+				t.Fatalf("tags = %v trashed = %v, want %v %v", got.Tags, got.Trashed, tt.tags, tt.trashed)
+			}
+		})
+	}
+
+	// โน้ตที่เก็บเข้าคลังแต่ไม่มีเวลาแก้ไข ใช้เวลาตอนนำเข้า
+	got, err := parseKeepNote("note.json", []byte(`{"title":"x","isArchived":true}`))
+	if err != nil || got.Note.ArchivedAt == "" {
+		t.Fatalf("archived note without timestamp = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"archive/zip"
+	"fmt"
+	"miw/entities"
+	"miw/utils"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taskListItemPattern บรรทัด "- [ ] ..." หรือ "- [x] ..." ของ GitHub task list
+var taskListItemPattern = regexp.MustCompile(`^\s*[-*+] \[([ xX])\](?:\s+(.*))?$`)
+
+// newMarkdownImporter อ่านไฟล์ .md และ .markdown ทุกไฟล์ใน ZIP โฟลเดอร์ของไฟล์จะกลายเป็นสมุดโน้ต
+// ไฟล์ที่ได้จาก ExportMarkdown นำเข้ากลับได้ตรงตาม front matter (ยกเว้น Reminder และ Event)
+func newMarkdownImporter(archive *zip.Reader) noteImporter {
+	var files []*zip.File
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || isHiddenZipEntry(file.Name) {
+			continue
+		}
+		switch strings.ToLower(path.Ext(file.Name)) {
+		case ".md", ".markdown":
+			files = append(files, file)
+		}
+	}
+	return &zipImporter{files: files, parse: parseMarkdownNote}
+}
+
+func parseMarkdownNote(name string, data []byte) (*importedNote, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("file is not valid UTF-8 text")
+	}
+	text := strings.ReplaceAll(strings.TrimPrefix(string(data), "\ufeff"), "\r\n", "\n")
+
+	var meta MarkdownFrontMatter
+	front, body, ok := splitFrontMatter(text)
+	if ok {
+		if err := yaml.Unmarshal([]byte(front), &meta); err != nil {
+			return nil, fmt.Errorf("invalid front matter: %v", err)
+		}
+	}
+	body = strings.TrimLeft(body, "\n")
+
+	title := strings.TrimSpace(meta.Title)
+	if title == "" {
+		title = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+	imported := &importedNote{
+		Note: entities.Note{
+			Title:         title,
+			Content:       body,
+			ContentFormat: firstNonEmpty(meta.ContentFormat, utils.ContentFormatMarkdown),
+			Color:         meta.Color,
+			Priority:      meta.Priority,
+			IsPinned:      meta.Pinned,
+			CreatedAt:     importTime(meta.Created),
+			UpdatedAt:     importTime(meta.Updated),
+		},
+		Tags: meta.Tags,
+	}
+	if folder := path.Dir(name); folder != "." {
+		imported.Folder = folder
+	}
+	if meta.Archived != "" {
+		// ค่าที่ไม่ใช่เวลา เช่น archived: true ให้ถือว่าเก็บเข้าคลังตอนนำเข้า
+		imported.Note.ArchivedAt = importTime(meta.Archived)
+		if imported.Note.ArchivedAt == "" && meta.Archived != "false" {
+			imported.Note.ArchivedAt = time.Now().Format("2006-01-02 15:04:05")
+		}
+	}
+	if todos, ok := parseTaskList(body); ok {
+		imported.Note.Content = ""
+		imported.Note.IsTodo = true
+		imported.Note.TodoItems = todos
+	}
+	return imported, nil
+}
+
+// splitFrontMatter แยก YAML front matter ที่อยู่ระหว่างบรรทัด "---" สองบรรทัดแรกของไฟล์
+func splitFrontMatter(text string) (string, string, bool) {
+	if !strings.HasPrefix(text, "---\n") {
+		return "", text, false
+	}
+	offset := len("---\n")
+	for _, line := range strings.SplitAfter(text[offset:], "\n") {
+		if strings.TrimRight(line, " \t\n") == "---" {
+			return text[len("---\n"):offset], text[offset+len(line):], true
+		}
+		offset += len(line)
+	}
+	return "", text, false
+}
+
+// parseTaskList แปลงเนื้อหาเป็นรายการ To-Do ถ้าทุกบรรทัดที่ไม่ว่างเป็น task list
+func parseTaskList(body string) ([]entities.ToDo, bool) {
+	var todos []entities.ToDo
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		match := taskListItemPattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil, false
+		}
+		todos = append(todos, entities.ToDo{Content: strings.TrimSpace(match[2]), IsDone: match[1] != " "})
+	}
+	return todos, len(todos) > 0
+}
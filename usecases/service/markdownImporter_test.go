@@ -0,0 +1,251 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"miw/entities"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		front string
+		body  string
+		ok    bool
+	}{
+		{name: "front matter", text: "---\ntitle: A\n---\nbody\n", front: "title: A\n", body: "body\n", ok: true},
+		{name: "empty front matter", text: "---\n---\nbody", front: "", body: "body", ok: true},
+		{name: "closing line with trailing spaces", text: "---\ntitle: A\n---  \nbody", front: "title: A\n", body: "body", ok: true},
+		{name: "closing line at end of file", text: "---\ntitle: A\n---", front: "title: A\n", body: "", ok: true},
+		{name: "no front matter", text: "# Heading\n---\n", front: "", body: "# Heading\n---\n", ok: false},
+		{name: "unterminated", text: "---\ntitle: A\nbody\n", front: "", body: "---\ntitle: A\nbody\n", ok: false},
+		{name: "horizontal rule inside body", text: "---\na: 1\n---\ntext\n---\nmore", front: "a: 1\n", body: "text\n---\nmore", ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			front, body, ok := splitFrontMatter(tt.text)
+			if front != tt.front || body != tt.body || ok != tt.ok {
+				t.Fatalf("splitFrontMatter(%q) = %q, %q, %v; want %q, %q, %v", tt.text, front, body, ok, tt.front, tt.body, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownNote(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		want    entities.Note
+		tags    []string
+		folder  string
+		wantErr string
+	}{
+		{
+			name: "front matter",
+			file: "Work/Projects/plan.md",
+			data: "---\ntitle: Plan\ntags: [work, งาน]\ncolor: red\npriority: 2\npinned: true\ncreated: 2024-01-02 03:04:05\nupdated: 2024-01-03T03:04:05\n---\n\n# Goals\n",
+			want: entities.Note{Title: "Plan", Content: "# Goals\n", ContentFormat: "markdown", Color: "red", Priority: 2, IsPinned: true,
+				CreatedAt: "2024-01-02 03:04:05", UpdatedAt: "2024-01-03 03:04:05"},
+			tags:   []string{"work", "งาน"},
+			folder: "Work/Projects",
+		},
+		{
+			name: "title from file name, BOM and CRLF",
+			file: "ideas.markdown",
+			data: "\ufeffline 1\r\nline 2\r\n",
+			want: entities.Note{Title: "ideas", Content: "line 1\nline 2\n", ContentFormat: "markdown"},
+		},
+		{
+			name: "task list becomes to-do items",
+			file: "todo.md",
+			data: "---\ntitle: Todo\ncontent_format: plain\n---\n- [x] done\n* [ ] open\n\n- [ ]\n",
+			want: entities.Note{Title: "Todo", ContentFormat: "plain", IsTodo: true,
+				TodoItems: []entities.ToDo{{Content: "done", IsDone: true}, {Content: "open"}, {Content: ""}}},
+		},
+		{
+			name: "mixed text keeps checkboxes as markdown",
+			file: "mixed.md",
+			data: "intro\n- [ ] task\n",
+			want: entities.Note{Title: "mixed", Content: "intro\n- [ ] task\n", ContentFormat: "markdown"},
+		},
+		{
+			name: "archived",
+			file: "old.md",
+			data: "---\narchived: 2024-05-06 07:08:09\n---\nx",
+			want: entities.Note{Title: "old", Content: "x", ContentFormat: "markdown", ArchivedAt: "2024-05-06 07:08:09"},
+		},
+		{
+			name: "archived false",
+			file: "active.md",
+			data: "---\narchived: \"false\"\n---\nx",
+			want: entities.Note{Title: "active", Content: "x", ContentFormat: "markdown"},
+		},
+		{name: "invalid UTF-8", file: "bad.md", data: "\xff\xfe", wantErr: "file is not valid UTF-8 text"},
+		{name: "invalid YAML", file: "bad.md", data: "---\ntags: [a\n---\n", wantErr: "invalid front matter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMarkdownNote(tt.file, []byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprintf("%+v", got.Note) != fmt.Sprintf("%+v", tt.want) {
+				t.Fatalf("note = %+v\nwant   %+v", got.Note, tt.want)
+			}
+			if fmt.Sprint(got.Tags) != fmt.Sprint(tt.tags) || got.Folder != tt.folder {
+				t.Fatalf("tags = %v folder = %q, want %v %q", got.Tags, got.Folder, tt.tags, tt.folder)
+			}
+		})
+	}
+
+	// archived ที่ไม่ใช่เวลา ให้ถือว่าเก็บเข้าคลังตอนนำเข้า
+	got, err := parseMarkdownNote("x.md", []byte("---\narchived: \"yes\"\n---\n"))
+	if err != nil || got.Note.ArchivedAt == "" {
+		t.Fatalf("archived: yes = %+v, %v", got, err)
+	}
+}
+
+// TestMarkdownExportRoundTrip ไฟล์ ZIP จาก ExportMarkdown นำเข้ากลับได้ตรงตามโน้ตเดิม (ยกเว้น Reminder และ Event)
+func TestMarkdownExportRoundTrip(t *testing.T) {
+	work, projects := uintPtr(1), uintPtr(2)
+	notes := []entities.Note{
+		{NoteID: 1, UserID: 1, NotebookID: projects, Title: "Plan: Q1/Q2", Content: "# Goals\n\n- ship\n", ContentFormat: "markdown",
+			Color: "red", Priority: 2, IsPinned: true, CreatedAt: "2024-01-02 03:04:05", UpdatedAt: "2024-01-03 04:05:06",
+			Tags: []entities.Tag{{TagName: "work"}, {TagName: "งาน/ด่วน"}}},
+		{NoteID: 2, UserID: 1, NotebookID: work, Title: "ซื้อของ", IsTodo: true, ContentFormat: "plain",
+			CreatedAt: "2024-02-01 00:00:00", ArchivedAt: "2024-03-01 00:00:00",
+			TodoItems: []entities.ToDo{{Content: "นม", IsDone: true}, {Content: "ไข่\nสองแผง"}}},
+		{NoteID: 3, UserID: 1, Title: "Plan: Q1/Q2", Content: "plain text", ContentFormat: "plain"},
+		{NoteID: 4, UserID: 1, NotebookID: projects, Title: "", Content: "untitled", ContentFormat: "markdown"},
+	}
+	repo := newFakeNoteRepo(notes...)
+	notebooks := fakeNotebookRepo{notebooks: []entities.Notebook{
+		{NotebookID: 1, UserID: 1, Name: "Work"},
+		{NotebookID: 2, UserID: 1, Name: "Projects", ParentNotebookID: work},
+	}}
+
+	var buf bytes.Buffer
+	if err := NewExportService(repo, notebooks).ExportMarkdown(1, &buf); err != nil {
+		t.Fatal(err)
+	}
+	importer, err := openImporter(entities.ImportSourceMarkdown, bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if importer.Count() != len(notes) {
+		t.Fatalf("Count() = %d, want %d", importer.Count(), len(notes))
+	}
+
+	imported := map[string]*importedNote{}
+	err = importer.Each(func(item string, note *importedNote, err error) error {
+		if err != nil {
+			return fmt.Errorf("%s: %v", item, err)
+		}
+		imported[item] = note
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []string
+	for item := range imported {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	if got := strings.Join(items, ", "); got != "Plan- Q1-Q2.md, Work/Projects/Plan- Q1-Q2.md, Work/Projects/Untitled.md, Work/ซื้อของ.md" {
+		t.Fatalf("exported files = %s", got)
+	}
+
+	plan := imported["Work/Projects/Plan- Q1-Q2.md"]
+	if want := (entities.Note{Title: "Plan: Q1/Q2", Content: "# Goals\n\n- ship\n", ContentFormat: "markdown", Color: "red", Priority: 2,
+		IsPinned: true, CreatedAt: "2024-01-02 03:04:05", UpdatedAt: "2024-01-03 04:05:06"}); fmt.Sprintf("%+v", plan.Note) != fmt.Sprintf("%+v", want) {
+		t.Fatalf("plan = %+v\nwant   %+v", plan.Note, want)
+	}
+	if fmt.Sprint(plan.Tags) != "[work งาน/ด่วน]" || plan.Folder != "Work/Projects" {
+		t.Fatalf("plan tags = %v folder = %q", plan.Tags, plan.Folder)
+	}
+
+	// รายการหลายบรรทัดถูกรวมเป็นบรรทัดเดียวตอน export
+	shopping := imported["Work/ซื้อของ.md"]
+	if want := (entities.Note{Title: "ซื้อของ", ContentFormat: "plain", IsTodo: true, ArchivedAt: "2024-03-01 00:00:00", CreatedAt: "2024-02-01 00:00:00",
+		TodoItems: []entities.ToDo{{Content: "นม", IsDone: true}, {Content: "ไข่ สองแผง"}}}); fmt.Sprintf("%+v", shopping.Note) != fmt.Sprintf("%+v", want) {
+		t.Fatalf("shopping = %+v\nwant   %+v", shopping.Note, want)
+	}
+
+	if inbox := imported["Plan- Q1-Q2.md"]; inbox.Folder != "" || inbox.Note.Content != "plain text\n" || inbox.Note.ContentFormat != "plain" {
+		t.Fatalf("note without notebook = %+v folder %q", inbox.Note, inbox.Folder)
+	}
+	if untitled := imported["Work/Projects/Untitled.md"]; untitled.Note.Title != "Untitled" || untitled.Note.Content != "untitled\n" {
+		t.Fatalf("untitled note = %+v", untitled.Note)
+	}
+}
+
+func TestReadZipFile(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]int{
+		"small.md": 10,
+		"limit.md": maxImportItemBytes,
+		"large.md": maxImportItemBytes + 1,
+	}
+	for name, size := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(bytes.Repeat([]byte("a"), size))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range archive.File {
+		t.Run(file.Name, func(t *testing.T) {
+			data, err := readZipFile(file)
+			if files[file.Name] > maxImportItemBytes {
+				if err == nil || !strings.HasPrefix(err.Error(), "file is too large") {
+					t.Fatalf("error = %v, want file is too large", err)
+				}
+				return
+			}
+			if err != nil || len(data) != files[file.Name] {
+				t.Fatalf("read %d bytes, %v; want %d", len(data), err, files[file.Name])
+			}
+		})
+	}
+}
+
+// TestReadZipFileUnderstatedSize ZIP ที่แจ้งขนาดหลังคลายการบีบอัดน้อยกว่าความจริงต้องไม่ถูกอ่านเกินขนาดสูงสุด
+func TestReadZipFileUnderstatedSize(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zip.NewWriter(&compressed)
+	w, _ := zw.CreateHeader(&zip.FileHeader{Name: "bomb.md", Method: zip.Deflate})
+	w.Write(bytes.Repeat([]byte("a"), maxImportItemBytes*2))
+	zw.Close()
+	archive, err := zip.NewReader(bytes.NewReader(compressed.Bytes()), int64(compressed.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := archive.File[0]
+	file.UncompressedSize64 = 100
+
+	if data, err := readZipFile(file); err == nil {
+		t.Fatalf("read %d bytes from a file with an understated size", len(data))
+	}
+}
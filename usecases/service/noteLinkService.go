@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"strings"
+	"time"
+)
+
+// syncNoteLinks อ่านลิงก์ [[...]] จากเนื้อหาโน้ตแล้วบันทึกแทนลิงก์เดิมของโน้ต
+// ลิงก์ด้วยชื่อที่ยังไม่มีโน้ตตรงกันจะถูกเก็บไว้ และจะชี้ไปยังโน้ตที่สร้างหรือเปลี่ยนชื่อให้ตรงภายหลัง
+func syncNoteLinks(linkRepo repository.NoteLinkRepository, noteRepo repository.NoteRepository, note *entities.Note) {
+	now := time.Now().Format("2006-01-02 15:04:05")
+	var links []entities.NoteLink
+	for _, wikiLink := range utils.ParseWikiLinks(note.Content) {
+		link := entities.NoteLink{UserID: note.UserID, TargetTitle: wikiLink.Title, CreatedAt: now}
+		if wikiLink.NoteID != 0 {
+			// ลิงก์ด้วย id ต้องชี้ไปยังโน้ตของผู้ใช้คนเดียวกัน
+			target, err := noteRepo.GetNoteByIdAndUser(wikiLink.NoteID, note.UserID)
+			if err != nil {
+				continue
+			}
+			link.TargetNoteID = &target.NoteID
+		} else {
+			targetID, err := linkRepo.FindNoteIDByTitle(note.UserID, wikiLink.Title)
+			if err != nil {
+				log.Printf("Failed to resolve link [[%s]] in note %d: %v", wikiLink.Title, note.NoteID, err)
+				continue
+			}
+			link.TargetNoteID = targetID
+		}
+		if link.TargetNoteID != nil && *link.TargetNoteID == note.NoteID {
+			continue
+		}
+		links = append(links, link)
+	}
+
+	if err := linkRepo.ReplaceLinks(note.NoteID, links); err != nil {
+		log.Printf("Failed to save links of note %d: %v", note.NoteID, err)
+	}
+}
+
+// resolveLinksToNote ชี้ลิงก์ที่ยังไม่มีปลายทางซึ่งตรงกับชื่อโน้ตมาที่โน้ตนี้ (ใช้เมื่อสร้าง เปลี่ยนชื่อ หรือกู้คืนโน้ต)
+func resolveLinksToNote(linkRepo repository.NoteLinkRepository, note *entities.Note) {
+	if !utils.IsLinkableTitle(note.Title) {
+		return
+	}
+	if err := linkRepo.ResolveLinksByTitle(note.UserID, note.Title, note.NoteID); err != nil {
+		log.Printf("Failed to resolve links to note %d: %v", note.NoteID, err)
+	}
+}
+
+// renameNoteLinks เปลี่ยน [[ชื่อเดิม]] ในโน้ตที่ลิงก์มาเป็นชื่อใหม่ ถ้าชื่อใหม่ใช้เป็นลิงก์ไม่ได้ (เช่น ว่าง) จะเปลี่ยนเป็น [[note:id]]
+func renameNoteLinks(linkRepo repository.NoteLinkRepository, noteRepo repository.NoteRepository, note *entities.Note, oldTitle string) {
+	if strings.TrimSpace(oldTitle) == strings.TrimSpace(note.Title) {
+		return
+	}
+
+	links, err := linkRepo.GetLinksToNote(note.NoteID)
+	if err != nil {
+		log.Printf("Failed to load links to note %d: %v", note.NoteID, err)
+		return
+	}
+
+	newTitle := strings.TrimSpace(note.Title)
+	replacement := "[[" + newTitle + "]]"
+	if !utils.IsLinkableTitle(newTitle) {
+		newTitle = ""
+		replacement = fmt.Sprintf("[[note:%d]]", note.NoteID)
+	}
+
+	for _, link := range links {
+		if link.TargetTitle == "" || !strings.EqualFold(link.TargetTitle, strings.TrimSpace(oldTitle)) {
+			continue
+		}
+		source, err := noteRepo.GetNoteByIdAndUser(link.SourceNoteID, note.UserID)
+		if err != nil {
+			continue
+		}
+		if content := utils.RenameWikiLinks(source.Content, oldTitle, replacement); content != source.Content {
+			if err := noteRepo.UpdateNoteFields(source.NoteID, note.UserID, map[string]interface{}{"content": content}); err != nil {
+				log.Printf("Failed to rewrite links in note %d: %v", source.NoteID, err)
+				continue
+			}
+		}
+		if err := linkRepo.UpdateLinkTarget(link.LinkID, &note.NoteID, newTitle); err != nil {
+			log.Printf("Failed to update link %d: %v", link.LinkID, err)
+		}
+	}
+
+	resolveLinksToNote(linkRepo, note)
+}
+
+// unlinkTrashedNote ลิงก์ด้วยชื่อที่ชี้มายังโน้ตที่ถูกย้ายไปถังขยะ จะชี้ไปยังโน้ตอื่นที่มีชื่อเดียวกัน หรือกลายเป็นลิงก์ที่ยังไม่มีปลายทาง
+// ลิงก์ด้วย id ยังชี้ที่เดิมเพื่อให้กลับมาใช้ได้เมื่อกู้คืน
+func unlinkTrashedNote(linkRepo repository.NoteLinkRepository, noteID uint, userID uint) {
+	links, err := linkRepo.GetLinksToNote(noteID)
+	if err != nil {
+		log.Printf("Failed to load links to note %d: %v", noteID, err)
+		return
+	}
+	for _, link := range links {
+		if link.TargetTitle == "" {
+			continue
+		}
+		targetID, err := linkRepo.FindNoteIDByTitle(userID, link.TargetTitle)
+		if err != nil {
+			log.Printf("Failed to resolve link %d: %v", link.LinkID, err)
+			continue
+		}
+		if targetID != nil && *targetID == link.SourceNoteID {
+			targetID = nil
+		}
+		if err := linkRepo.UpdateLinkTarget(link.LinkID, targetID, link.TargetTitle); err != nil {
+			log.Printf("Failed to update link %d: %v", link.LinkID, err)
+		}
+	}
+}
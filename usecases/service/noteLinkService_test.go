@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"strings"
+	"testing"
+)
+
+// memoryLinkRepo เก็บลิงก์ไว้ในหน่วยความจำ failTitles คือชื่อที่ FindNoteIDByTitle จะคืน error
+type memoryLinkRepo struct {
+	fakeLinkRepo
+	links      []entities.NoteLink
+	titles     map[string]uint
+	failTitles map[string]bool
+	replaced   bool
+}
+
+func (r *memoryLinkRepo) ReplaceLinks(sourceNoteID uint, links []entities.NoteLink) error {
+	r.replaced = true
+	kept := r.links[:0]
+	for _, link := range r.links {
+		if link.SourceNoteID != sourceNoteID {
+			kept = append(kept, link)
+		}
+	}
+	for _, link := range links {
+		link.SourceNoteID = sourceNoteID
+		link.LinkID = uint(len(kept) + 1)
+		kept = append(kept, link)
+	}
+	r.links = kept
+	return nil
+}
+
+func (r *memoryLinkRepo) FindNoteIDByTitle(userID uint, title string) (*uint, error) {
+	if r.failTitles[title] {
+		return nil, fmt.Errorf("connection refused")
+	}
+	if noteID, ok := r.titles[strings.ToLower(title)]; ok {
+		return &noteID, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryLinkRepo) GetLinksToNote(targetNoteID uint) ([]entities.NoteLink, error) {
+	var links []entities.NoteLink
+	for _, link := range r.links {
+		if link.TargetNoteID != nil && *link.TargetNoteID == targetNoteID {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+func (r *memoryLinkRepo) UpdateLinkTarget(linkID uint, targetNoteID *uint, targetTitle string) error {
+	for i := range r.links {
+		if r.links[i].LinkID == linkID {
+			r.links[i].TargetNoteID = targetNoteID
+			r.links[i].TargetTitle = targetTitle
+			return nil
+		}
+	}
+	return fmt.Errorf("link not found")
+}
+
+func TestSyncNoteLinksSkipsUnresolvedTitle(t *testing.T) {
+	linkRepo := &memoryLinkRepo{
+		links:      []entities.NoteLink{{LinkID: 1, SourceNoteID: 1, TargetTitle: "Stale", TargetNoteID: uintPtr(9)}},
+		titles:     map[string]uint{"plan": 2},
+		failTitles: map[string]bool{"Broken": true},
+	}
+	note := &entities.Note{NoteID: 1, UserID: 1, Content: "see [[Broken]] and [[Plan]]"}
+
+	syncNoteLinks(linkRepo, newFakeNoteRepo(), note)
+
+	if !linkRepo.replaced {
+		t.Fatal("links were not replaced")
+	}
+	if len(linkRepo.links) != 1 {
+		t.Fatalf("links = %+v, want only [[Plan]]", linkRepo.links)
+	}
+	link := linkRepo.links[0]
+	if link.TargetTitle != "Plan" || link.TargetNoteID == nil || *link.TargetNoteID != 2 {
+		t.Fatalf("link = %+v, want [[Plan]] pointing to note 2", link)
+	}
+}
+
+func TestRenameNoteLinks(t *testing.T) {
+	tests := []struct {
+		name        string
+		newTitle    string
+		wantContent string
+		wantTitle   string
+	}{
+		{"new title", "Roadmap", "see [[Roadmap]] and [[Other]]", "Roadmap"},
+		{"unlinkable title", "  ", "see [[note:2]] and [[Other]]", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noteRepo := newFakeNoteRepo(
+				entities.Note{NoteID: 1, UserID: 1, Content: "see [[plan]] and [[Other]]"},
+				entities.Note{NoteID: 2, UserID: 1, Title: tt.newTitle},
+			)
+			linkRepo := &memoryLinkRepo{links: []entities.NoteLink{
+				{LinkID: 1, SourceNoteID: 1, TargetTitle: "plan", TargetNoteID: uintPtr(2)},
+				{LinkID: 2, SourceNoteID: 1, TargetTitle: "Other", TargetNoteID: uintPtr(3)},
+			}}
+			note, _ := noteRepo.GetNoteByIdAndUser(2, 1)
+
+			renameNoteLinks(linkRepo, noteRepo, note, "Plan")
+
+			source, _ := noteRepo.GetNoteByIdAndUser(1, 1)
+			if source.Content != tt.wantContent {
+				t.Fatalf("content = %q, want %q", source.Content, tt.wantContent)
+			}
+			if linkRepo.links[0].TargetTitle != tt.wantTitle || *linkRepo.links[0].TargetNoteID != 2 {
+				t.Fatalf("renamed link = %+v, want title %q pointing to note 2", linkRepo.links[0], tt.wantTitle)
+			}
+			if linkRepo.links[1].TargetTitle != "Other" {
+				t.Fatalf("unrelated link changed: %+v", linkRepo.links[1])
+			}
+		})
+	}
+}
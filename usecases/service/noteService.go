@@ -2,35 +2,124 @@ package service
 
 import (
 	"fmt"
+	"log"
 	"miw/entities"
 	"miw/usecases/repository"
+	"miw/utils"
+	"strings"
 	"time"
 )
 
 type NoteUseCase interface {
 	CreateNote(note *entities.Note) error
-	GetAllNote(userid uint) ([]entities.Note, error)
+	GetAllNote(userid uint, notebookID *uint) ([]entities.Note, error)
+	GetArchivedNotes(userID uint) ([]entities.Note, error)
+	GetNotesByTag(userID uint, tagID uint, includeDescendants bool) ([]entities.Note, error)
 	UpdateColor(noteID uint, userID uint, color string) error
 	UpdatePriority(noteID uint, userID uint, priority int) error
 	UpdateTitleAndContent(noteID uint, userID uint, title string, content string, todoItems []entities.ToDo) error 
 	UpdateStatus(noteID uint, userID uint, isTodo *bool, isAllDone *bool) error
+	PatchNote(noteID uint, userID uint, patch *NotePatch) (*entities.Note, error)
+	BatchUpdateNotes(userID uint, noteIDs []uint, op BatchOperation) ([]BatchNoteResult, error)
+	DuplicateNote(noteID uint, userID uint, includeSchedule bool) (*entities.Note, error)
+	SetPinned(noteID uint, userID uint, isPinned bool) error
+	SetArchived(noteID uint, userID uint, archived bool) error
+	MoveNote(noteID uint, userID uint, prevNoteID *uint, nextNoteID *uint) (string, error)
 	DeleteNoteById(noteID uint, userID uint) error
 	RestoreNoteById(noteID uint, userID uint) error
+	PurgeNote(noteID uint, userID uint) error
+	GetBacklinks(noteID uint, userID uint) ([]entities.Note, error)
 	AddTagToNote(noteID uint, tagID uint, userID uint) error
 	RemoveTagFromNote(noteID uint, tagID uint, userID uint) error
 }
 
+// NotePatch การแก้ไขบางฟิลด์ของโน้ตตาม JSON Merge Patch (nil = ไม่แก้ไขฟิลด์นั้น)
+// ค่า null ใน patch จะถูกแปลงเป็นค่าว่างของฟิลด์นั้นก่อนส่งเข้ามา
+type NotePatch struct {
+	Title         *string
+	Content       *string
+	ContentFormat *string
+	Color         *string
+	Priority      *int
+	IsTodo        *bool
+	IsAllDone     *bool
+	TodoItems     *[]entities.ToDo
+	TagIDs        *[]uint
+	NotebookID    *uint
+	SetNotebook   bool // true เมื่อ patch มี notebook_id (NotebookID = nil คือเอาออกจากสมุด)
+}
+
+// MaxBatchNotes จำนวนโน้ตสูงสุดต่อหนึ่งคำสั่ง batch
+const MaxBatchNotes = 500
+
+// ชื่อคำสั่งที่ใช้กับ batch ได้
+var BatchOperations = []string{"delete", "restore", "color", "priority", "add_tag", "remove_tag", "move_notebook", "archive", "unarchive"}
+
+// BatchOperation คำสั่งที่ทำกับโน้ตทุกรายการใน batch ใช้เฉพาะฟิลด์ที่เกี่ยวกับคำสั่งนั้น
+type BatchOperation struct {
+	Operation  string
+	Color      string
+	Priority   int
+	TagID      uint
+	NotebookID *uint // move_notebook: nil = เอาออกจากสมุด
+}
+
+// BatchNoteResult ผลลัพธ์ของโน้ตแต่ละรายการใน batch
+type BatchNoteResult struct {
+	NoteID  uint   `json:"note_id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
 type NoteService struct {
-	noteRepo repository.NoteRepository
+	noteRepo        repository.NoteRepository
+	ruleRepo        repository.AutoTagRuleRepository
+	notebookRepo    repository.NotebookRepository
+	linkRepo        repository.NoteLinkRepository
+	reminderUseCase ReminderUseCase
+	blobStore       repository.BlobStore
 }
 
-func NewNoteService(noteRepo repository.NoteRepository) *NoteService {
+func NewNoteService(noteRepo repository.NoteRepository, ruleRepo repository.AutoTagRuleRepository, notebookRepo repository.NotebookRepository, linkRepo repository.NoteLinkRepository, reminderUseCase ReminderUseCase, blobStore repository.BlobStore) *NoteService {
 	return &NoteService{
-		noteRepo: noteRepo,
+		noteRepo:        noteRepo,
+		ruleRepo:        ruleRepo,
+		notebookRepo:    notebookRepo,
+		linkRepo:        linkRepo,
+		reminderUseCase: reminderUseCase,
+		blobStore:       blobStore,
 	}
 }
 
 func (s *NoteService) CreateNote(note *entities.Note) error {
+	if err := s.prepareNote(note); err != nil {
+		return err
+	}
+	if err := s.noteRepo.WithTransaction(func(repo repository.NoteRepository) error {
+		return insertNote(repo, note)
+	}); err != nil {
+		return err
+	}
+	s.afterCreateNote(note)
+	return nil
+}
+
+// prepareNote ตรวจสอบและเติมค่าเริ่มต้นของโน้ตใหม่ก่อนบันทึก
+func (s *NoteService) prepareNote(note *entities.Note) error {
+	// ไฟล์แนบเพิ่มได้ผ่าน AttachmentService.UploadAttachment เท่านั้น
+	note.Attachments = nil
+
+	// ตรวจสอบสีและระดับความสำคัญ (รายงานทุกฟิลด์ที่ผิดพร้อมกัน)
+	note.Color = utils.NormalizeColor(note.Color)
+	note.ContentFormat = utils.NormalizeContentFormat(note.ContentFormat)
+	validation := &utils.ValidationError{}
+	validation.ValidateColor("color", note.Color)
+	validation.ValidatePriority("priority", note.Priority)
+	validation.ValidateContentFormat("content_format", note.ContentFormat)
+	if err := validation.Err(); err != nil {
+		return err
+	}
+
 	timeCreate := time.Now().Format("2006-01-02 15:04:05")
 	note.CreatedAt = timeCreate
 
@@ -43,14 +132,70 @@ func (s *NoteService) CreateNote(note *entities.Note) error {
 		}
 	}
 
-	return s.noteRepo.CreateNote(note)
+	// โน้ตใหม่ที่ไม่ระบุสมุดจะอยู่ใน Inbox ถ้าระบุต้องเป็นสมุดของ User คนเดียวกัน
+	if note.NotebookID == nil {
+		inbox, err := s.notebookRepo.GetDefaultNotebook(note.UserID)
+		if err != nil {
+			return err
+		}
+		note.NotebookID = &inbox.NotebookID
+	} else {
+		notebook, err := s.notebookRepo.GetNotebookById(*note.NotebookID)
+		if err != nil || notebook.UserID != note.UserID {
+			return fmt.Errorf("notebook not found or does not belong to this user")
+		}
+	}
+
+	return nil
 }
 
-func (s *NoteService) GetAllNote(userid uint) ([]entities.Note, error) {
-	return s.noteRepo.GetAllNoteByUserId(userid)
+// insertNote บันทึกโน้ตใหม่ไว้บนสุดของสมุดโน้ต ต้องเรียกภายใน transaction
+// อ่านคีย์แรกและบันทึกภายใต้ lock เดียวกันเพื่อไม่ให้ได้คีย์ซ้ำกับ request อื่น
+func insertNote(repo repository.NoteRepository, note *entities.Note) error {
+	if err := repo.LockNotePositions(note.UserID); err != nil {
+		return err
+	}
+	positions, err := notePositions(repo, note.UserID, note.NotebookID)
+	if err != nil {
+		return err
+	}
+	first := ""
+	if len(positions) > 0 {
+		first = positions[0].Position
+	}
+	if note.Position, err = utils.PositionKeyBetween("", first); err != nil {
+		return err
+	}
+	return repo.CreateNote(note)
+}
+
+// afterCreateNote งานที่ทำหลังโน้ตใหม่ถูก commit แล้ว
+func (s *NoteService) afterCreateNote(note *entities.Note) {
+	// ติดแท็กอัตโนมัติตามกฎของผู้ใช้
+	applyAutoTagRules(s.ruleRepo, s.noteRepo, note)
+
+	// บันทึกลิงก์ [[...]] ในโน้ต และลิงก์จากโน้ตอื่นที่รอโน้ตชื่อนี้
+	syncNoteLinks(s.linkRepo, s.noteRepo, note)
+	resolveLinksToNote(s.linkRepo, note)
+}
+
+func (s *NoteService) GetAllNote(userid uint, notebookID *uint) ([]entities.Note, error) {
+	return s.noteRepo.GetAllNoteByUserId(userid, notebookID)
+}
+
+// GetNotesByTag ดึงโน้ตที่มี tag นี้ และถ้า includeDescendants เป็น true จะรวม tag ลูกหลานทั้งหมดด้วย
+func (s *NoteService) GetNotesByTag(userID uint, tagID uint, includeDescendants bool) ([]entities.Note, error) {
+	return s.noteRepo.GetNotesByTag(userID, tagID, includeDescendants)
 }
 
 func (s *NoteService) UpdateColor(noteID uint, userID uint, color string) error {
+	color = utils.NormalizeColor(color)
+	validation := &utils.ValidationError{}
+	validation.ValidateColor("color", color)
+	if err := validation.Err(); err != nil {
+		return err
+	}
+
 	// ตรวจสอบว่า Note เป็นของ User หรือไม่
 	_, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
 	if err != nil {
@@ -61,6 +206,12 @@ func (s *NoteService) UpdateColor(noteID uint, userID uint, color string) error
 }
 
 func (s *NoteService) UpdatePriority(noteID uint, userID uint, priority int) error {
+	validation := &utils.ValidationError{}
+	validation.ValidatePriority("priority", priority)
+	if err := validation.Err(); err != nil {
+		return err
+	}
+
 	// ตรวจสอบว่า Note เป็นของ User หรือไม่
 	_, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
 	if err != nil {
@@ -82,6 +233,8 @@ func (s *NoteService) UpdateTitleAndContent(noteID uint, userID uint, title stri
 		return fmt.Errorf("note cannot have both content and todo_items")
 	}
 
+	oldTitle := note.Title
+
 	// อัปเดต Title หากมีการส่งค่า
 	if title != "" {
 		note.Title = title
@@ -103,9 +256,352 @@ func (s *NoteService) UpdateTitleAndContent(noteID uint, userID uint, title stri
 	note.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
 
 	// บันทึกการอัปเดต
-	return s.noteRepo.UpdateNoteTitleAndContent(note)
+	if err := s.noteRepo.UpdateNoteTitleAndContent(note); err != nil {
+		return err
+	}
+
+	// ติดแท็กอัตโนมัติตามกฎของผู้ใช้
+	applyAutoTagRules(s.ruleRepo, s.noteRepo, note)
+
+	// อัปเดตลิงก์ในโน้ต และเปลี่ยน [[ชื่อเดิม]] ในโน้ตที่ลิงก์มาถ้าชื่อเปลี่ยน
+	syncNoteLinks(s.linkRepo, s.noteRepo, note)
+	renameNoteLinks(s.linkRepo, s.noteRepo, note, oldTitle)
+	return nil
+}
+
+
+// PatchNote: แก้ไขหลายฟิลด์ของโน้ตพร้อมกันภายใน transaction เดียว และอัปเดต updated_at ครั้งเดียว
+func (s *NoteService) PatchNote(noteID uint, userID uint, patch *NotePatch) (*entities.Note, error) {
+	validation := &utils.ValidationError{}
+	if patch.Color != nil {
+		color := utils.NormalizeColor(*patch.Color)
+		patch.Color = &color
+		validation.ValidateColor("color", color)
+	}
+	if patch.Priority != nil {
+		validation.ValidatePriority("priority", *patch.Priority)
+	}
+	if patch.ContentFormat != nil {
+		format := utils.NormalizeContentFormat(*patch.ContentFormat)
+		patch.ContentFormat = &format
+		validation.ValidateContentFormat("content_format", format)
+	}
+	if patch.Content != nil && *patch.Content != "" && patch.TodoItems != nil && len(*patch.TodoItems) > 0 {
+		validation.Add("content", "note cannot have both content and todo_items")
+	}
+	if err := validation.Err(); err != nil {
+		return nil, err
+	}
+
+	// สมุดโน้ตปลายทางต้องเป็นของ User คนเดียวกัน
+	if patch.SetNotebook && patch.NotebookID != nil {
+		notebook, err := s.notebookRepo.GetNotebookById(*patch.NotebookID)
+		if err != nil || notebook.UserID != userID {
+			return nil, fmt.Errorf("notebook not found or does not belong to this user")
+		}
+	}
+
+	var oldTitle string
+	err := s.noteRepo.WithTransaction(func(repo repository.NoteRepository) error {
+		current, err := repo.GetNoteByIdAndUser(noteID, userID)
+		if err != nil {
+			return fmt.Errorf("note not found or does not belong to the user")
+		}
+		oldTitle = current.Title
+
+		now := time.Now().Format("2006-01-02 15:04:05")
+		updates := map[string]interface{}{}
+		if patch.Title != nil {
+			updates["title"] = *patch.Title
+		}
+		if patch.ContentFormat != nil {
+			updates["content_format"] = *patch.ContentFormat
+		}
+		if patch.Color != nil {
+			updates["color"] = *patch.Color
+		}
+		if patch.Priority != nil {
+			updates["priority"] = *patch.Priority
+		}
+		if patch.IsTodo != nil {
+			updates["is_todo"] = *patch.IsTodo
+		}
+		if patch.IsAllDone != nil {
+			updates["is_all_done"] = *patch.IsAllDone
+		}
+		if patch.SetNotebook {
+			updates["notebook_id"] = patch.NotebookID
+			if !sameNotebook(current.NotebookID, patch.NotebookID) {
+				// โน้ตที่ย้ายเข้าสมุดใหม่ไปอยู่บนสุด และได้คีย์ใหม่ตอน rebalance ครั้งถัดไป
+				updates["position"] = ""
+			}
+		}
+
+		// content กับ todo_items ใช้ร่วมกันไม่ได้: ตั้งค่าอย่างหนึ่งจะล้างอีกอย่าง
+		if patch.Content != nil {
+			updates["content"] = *patch.Content
+			if *patch.Content != "" {
+				if err := repo.ReplaceTodoItems(noteID, nil); err != nil {
+					return err
+				}
+			}
+		}
+		if patch.TodoItems != nil {
+			for i := range *patch.TodoItems {
+				(*patch.TodoItems)[i].CreatedAt = now
+				(*patch.TodoItems)[i].UpdatedAt = now
+			}
+			if err := repo.ReplaceTodoItems(noteID, *patch.TodoItems); err != nil {
+				return err
+			}
+			if len(*patch.TodoItems) > 0 {
+				updates["content"] = ""
+			}
+
+			// คำนวณ IsAllDone ใหม่จาก TodoItems ถ้าไม่ได้ระบุมา
+			if patch.IsAllDone == nil {
+				allDone := true
+				for _, todo := range *patch.TodoItems {
+					if !todo.IsDone {
+						allDone = false
+						break
+					}
+				}
+				updates["is_all_done"] = allDone
+			}
+		}
+
+		if patch.TagIDs != nil {
+			if err := repo.ReplaceNoteTags(noteID, userID, uniqueIDs(*patch.TagIDs)); err != nil {
+				return err
+			}
+		}
+
+		updates["updated_at"] = now
+		return repo.UpdateNoteFields(noteID, userID, updates)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// ติดแท็กอัตโนมัติตามกฎของผู้ใช้เมื่อเนื้อหาเปลี่ยน
+	if patch.Title != nil || patch.Content != nil || patch.TodoItems != nil {
+		applyAutoTagRules(s.ruleRepo, s.noteRepo, note)
+	}
+
+	// อัปเดตลิงก์เมื่อเนื้อหาเปลี่ยน และเปลี่ยน [[ชื่อเดิม]] ในโน้ตที่ลิงก์มาเมื่อชื่อเปลี่ยน
+	if patch.Content != nil || patch.TodoItems != nil {
+		syncNoteLinks(s.linkRepo, s.noteRepo, note)
+	}
+	if patch.Title != nil {
+		renameNoteLinks(s.linkRepo, s.noteRepo, note, oldTitle)
+	}
+	return note, nil
+}
+
+// BatchUpdateNotes: ทำคำสั่งเดียวกันกับโน้ตหลายรายการภายใน transaction เดียว
+// โน้ตแต่ละรายการทำใน savepoint ของตัวเอง รายการที่ล้มเหลว (เช่น ไม่ใช่เจ้าของ) จะถูก rollback เฉพาะรายการนั้นและรายงานในผลลัพธ์
+func (s *NoteService) BatchUpdateNotes(userID uint, noteIDs []uint, op BatchOperation) ([]BatchNoteResult, error) {
+	noteIDs = uniqueIDs(noteIDs)
+	validation := &utils.ValidationError{}
+	if len(noteIDs) == 0 {
+		validation.Add("note_ids", "must contain at least one note ID")
+	} else if len(noteIDs) > MaxBatchNotes {
+		validation.Add("note_ids", fmt.Sprintf("must contain at most %d note IDs", MaxBatchNotes))
+	}
+
+	switch op.Operation {
+	case "delete", "restore", "archive", "unarchive":
+	case "color":
+		op.Color = utils.NormalizeColor(op.Color)
+		validation.ValidateColor("color", op.Color)
+	case "priority":
+		validation.ValidatePriority("priority", op.Priority)
+	case "add_tag", "remove_tag":
+		if op.TagID == 0 {
+			validation.Add("tag_id", "is required")
+		}
+	case "move_notebook":
+	default:
+		validation.Add("operation", "must be one of "+strings.Join(BatchOperations, ", "))
+	}
+	if err := validation.Err(); err != nil {
+		return nil, err
+	}
+
+	// สมุดโน้ตปลายทางต้องเป็นของ User คนเดียวกัน
+	if op.Operation == "move_notebook" && op.NotebookID != nil {
+		notebook, err := s.notebookRepo.GetNotebookById(*op.NotebookID)
+		if err != nil || notebook.UserID != userID {
+			return nil, fmt.Errorf("notebook not found or does not belong to this user")
+		}
+	}
+
+	results := make([]BatchNoteResult, 0, len(noteIDs))
+	err := s.noteRepo.WithTransaction(func(repo repository.NoteRepository) error {
+		for _, noteID := range noteIDs {
+			// transaction ซ้อนจะกลายเป็น savepoint
+			err := repo.WithTransaction(func(noteRepo repository.NoteRepository) error {
+				return applyBatchOperation(noteRepo, noteID, userID, op)
+			})
+			result := BatchNoteResult{NoteID: noteID, Success: err == nil}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if op.Operation == "archive" || op.Operation == "unarchive" {
+		for _, result := range results {
+			if result.Success {
+				s.syncArchivedReminders(result.NoteID, userID, op.Operation == "archive")
+			}
+		}
+	}
+
+	// ลิงก์ที่ชี้ไปยังโน้ตที่ถูกลบหรือกู้คืน
+	if op.Operation == "delete" || op.Operation == "restore" {
+		for _, result := range results {
+			if !result.Success {
+				continue
+			}
+			if op.Operation == "delete" {
+				unlinkTrashedNote(s.linkRepo, result.NoteID, userID)
+			} else if note, err := s.noteRepo.GetNoteByIdAndUser(result.NoteID, userID); err == nil {
+				resolveLinksToNote(s.linkRepo, note)
+			}
+		}
+	}
+	return results, nil
 }
 
+func applyBatchOperation(repo repository.NoteRepository, noteID uint, userID uint, op BatchOperation) error {
+	// ตรวจสอบว่า Note เป็นของ User หรือไม่
+	note, err := repo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	switch op.Operation {
+	case "delete":
+		return repo.DeleteNoteById(noteID)
+	case "restore":
+		return repo.RestoreNoteById(noteID)
+	case "color":
+		return repo.UpdateNoteColor(noteID, userID, op.Color)
+	case "priority":
+		return repo.UpdateNotePriority(noteID, userID, op.Priority)
+	case "add_tag":
+		return repo.AddTagToNote(noteID, op.TagID, userID)
+	case "remove_tag":
+		return repo.RemoveTagFromNote(noteID, op.TagID, userID)
+	case "move_notebook":
+		updates := map[string]interface{}{
+			"notebook_id": op.NotebookID,
+			"updated_at":  now,
+		}
+		if !sameNotebook(note.NotebookID, op.NotebookID) {
+			updates["position"] = ""
+		}
+		return repo.UpdateNoteFields(noteID, userID, updates)
+	case "archive":
+		return repo.UpdateNoteArchived(noteID, userID, now)
+	case "unarchive":
+		return repo.UpdateNoteArchived(noteID, userID, "")
+	}
+	return fmt.Errorf("unknown batch operation: %s", op.Operation)
+}
+
+// DuplicateNote: คัดลอกโน้ตเป็นโน้ตใหม่ (เนื้อหา To-Do ที่รีเซ็ตเป็นยังไม่เสร็จ แท็ก สี และระดับความสำคัญ)
+// ถ้า includeSchedule เป็น true จะคัดลอก Event และ Reminder ที่ยังไม่ถึงเวลาด้วย
+func (s *NoteService) DuplicateNote(noteID uint, userID uint, includeSchedule bool) (*entities.Note, error) {
+	source, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+	if source.DeletedAt != "" {
+		return nil, fmt.Errorf("cannot duplicate a deleted note")
+	}
+
+	note := &entities.Note{
+		UserID:        userID,
+		NotebookID:    source.NotebookID,
+		Title:         source.Title,
+		Content:       source.Content,
+		ContentFormat: source.ContentFormat,
+		Color:         source.Color,
+		Priority:      source.Priority,
+		IsTodo:        source.IsTodo,
+	}
+	for _, todo := range source.TodoItems {
+		note.TodoItems = append(note.TodoItems, entities.ToDo{Content: todo.Content})
+	}
+	if includeSchedule && source.Event.EventID != 0 {
+		note.Event = entities.Event{StartTime: source.Event.StartTime, EndTime: source.Event.EndTime}
+	}
+	// Reminder ที่เลยเวลาไปแล้วจะไม่ถูกคัดลอก
+	if includeSchedule {
+		thLocation, _ := time.LoadLocation("Asia/Bangkok")
+		for _, reminder := range source.Reminder {
+			reminderTime, err := time.ParseInLocation("2006-01-02 15:04:05", reminder.ReminderTime, thLocation)
+			if err != nil || reminderTime.Before(time.Now().In(thLocation)) {
+				continue
+			}
+			note.Reminder = append(note.Reminder, entities.Reminder{
+				ReminderTime: reminder.ReminderTime,
+				Recurring:    reminder.Recurring,
+				Frequency:    reminder.Frequency,
+			})
+		}
+	}
+
+	if err := s.prepareNote(note); err != nil {
+		return nil, err
+	}
+	// โน้ต To-Do แท็ก Event และ Reminder ถูกคัดลอกภายใน transaction เดียว ถ้าขั้นใดล้มเหลวจะไม่เหลือสำเนาครึ่ง ๆ กลาง ๆ
+	err = s.noteRepo.WithTransaction(func(repo repository.NoteRepository) error {
+		if err := insertNote(repo, note); err != nil {
+			return err
+		}
+		for _, tag := range source.Tags {
+			if err := repo.AddTagToNote(note.NoteID, tag.TagID, userID); err != nil {
+				return err
+			}
+			note.Tags = append(note.Tags, tag)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.afterCreateNote(note)
+	s.reminderUseCase.ScheduleReminders(note)
+
+	return s.noteRepo.GetNoteByIdAndUser(note.NoteID, userID)
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
 
 func (s *NoteService) UpdateStatus(noteID uint, userID uint, isTodo *bool, isAllDone *bool) error {
 	// ตรวจสอบว่า Note เป็นของ User หรือไม่
@@ -130,12 +626,13 @@ func (s *NoteService) DeleteNoteById(noteID uint, userID uint) error {
 	if err := s.noteRepo.DeleteNoteById(noteID); err != nil {
 		return fmt.Errorf("failed to delete note: %v", err)
 	}
+	unlinkTrashedNote(s.linkRepo, noteID, userID)
 	return nil
 }
 
 func (s *NoteService) RestoreNoteById(noteID uint, userID uint) error {
 	// ตรวจสอบว่า Note เป็นของ User หรือไม่
-	_, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
 	if err != nil {
 		return fmt.Errorf("note not found or does not belong to the user")
 	}
@@ -144,9 +641,195 @@ func (s *NoteService) RestoreNoteById(noteID uint, userID uint) error {
 	if err := s.noteRepo.RestoreNoteById(noteID); err != nil {
 		return fmt.Errorf("failed to restore note: %v", err)
 	}
+	resolveLinksToNote(s.linkRepo, note)
 	return nil
 }
 
+// PurgeNote: ลบโน้ตในถังขยะถาวร รวมถึงไฟล์แนบใน BlobStore และ Reminder ที่ตั้งเวลาไว้
+func (s *NoteService) PurgeNote(noteID uint, userID uint) error {
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	if note.DeletedAt == "" {
+		return fmt.Errorf("note must be in trash before it can be permanently deleted")
+	}
+
+	storageKeys, err := s.noteRepo.PurgeNote(noteID)
+	if err != nil {
+		return err
+	}
+
+	reminderIDs := make([]uint, 0, len(note.Reminder))
+	for _, reminder := range note.Reminder {
+		reminderIDs = append(reminderIDs, reminder.ReminderID)
+	}
+	s.reminderUseCase.CancelScheduledReminders(reminderIDs)
+
+	// ลบไฟล์หลังข้อมูลถูกลบแล้ว ถ้าลบไฟล์ไม่สำเร็จจะเหลือแค่ไฟล์กำพร้าใน BlobStore
+	for _, key := range storageKeys {
+		if err := s.blobStore.Delete(key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// GetBacklinks: โน้ตที่ลิงก์มายังโน้ตนี้ด้วย [[ชื่อโน้ต]] หรือ [[note:id]] (ไม่รวมโน้ตในถังขยะ)
+func (s *NoteService) GetBacklinks(noteID uint, userID uint) ([]entities.Note, error) {
+	if _, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID); err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+	return s.linkRepo.GetBacklinks(noteID)
+}
+
+func (s *NoteService) GetArchivedNotes(userID uint) ([]entities.Note, error) {
+	return s.noteRepo.GetArchivedNotesByUserId(userID)
+}
+
+// SetPinned: ปักหมุด/เลิกปักหมุดโน้ต
+func (s *NoteService) SetPinned(noteID uint, userID uint, isPinned bool) error {
+	return s.noteRepo.UpdateNotePinned(noteID, userID, isPinned)
+}
+
+// SetArchived: เก็บโน้ตเข้าคลัง/นำออกจากคลัง ระหว่างอยู่ในคลัง Reminder ของโน้ตจะไม่ส่งแจ้งเตือน
+func (s *NoteService) SetArchived(noteID uint, userID uint, archived bool) error {
+	archivedAt := ""
+	if archived {
+		archivedAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+	if err := s.noteRepo.UpdateNoteArchived(noteID, userID, archivedAt); err != nil {
+		return err
+	}
+	s.syncArchivedReminders(noteID, userID, archived)
+	return nil
+}
+
+// syncArchivedReminders พักการแจ้งเตือนของโน้ตที่เก็บเข้าคลัง และตั้งเวลาใหม่ให้ Reminder ที่ยังไม่ถึงเวลาเมื่อนำออกจากคลัง
+func (s *NoteService) syncArchivedReminders(noteID uint, userID uint, archived bool) {
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil || len(note.Reminder) == 0 {
+		return
+	}
+	if !archived {
+		s.reminderUseCase.ScheduleReminders(note)
+		return
+	}
+	reminderIDs := make([]uint, 0, len(note.Reminder))
+	for _, reminder := range note.Reminder {
+		reminderIDs = append(reminderIDs, reminder.ReminderID)
+	}
+	s.reminderUseCase.CancelScheduledReminders(reminderIDs)
+}
+
+// notePositions ดึงคีย์ตำแหน่งของโน้ตในสมุดโน้ต ถ้ามีโน้ตที่ยังไม่มีคีย์ (โน้ตเก่าหรือเพิ่งย้ายเข้าสมุด) จะ rebalance ก่อน
+func notePositions(repo repository.NoteRepository, userID uint, notebookID *uint) ([]entities.Note, error) {
+	notes, err := repo.GetNotePositions(userID, notebookID)
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) > 0 && notes[0].Position == "" {
+		return rebalancePositions(repo, userID, notes)
+	}
+	return notes, nil
+}
+
+// sameNotebook เทียบ notebook_id ที่อาจเป็น nil
+func sameNotebook(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// rebalancePositions แจกคีย์ตำแหน่งใหม่ที่สั้นและเว้นระยะเท่ากัน โดยคงลำดับเดิมไว้
+func rebalancePositions(repo repository.NoteRepository, userID uint, notes []entities.Note) ([]entities.Note, error) {
+	keys := utils.EvenPositionKeys(len(notes))
+	positions := make(map[uint]string, len(notes))
+	for i := range notes {
+		notes[i].Position = keys[i]
+		positions[notes[i].NoteID] = keys[i]
+	}
+	if err := repo.UpdateNotePositions(userID, positions); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// MoveNote: ย้ายโน้ตไปไว้ระหว่าง prevNoteID และ nextNoteID (nil = ต้น/ท้ายรายการ) ในสมุดโน้ตเดียวกัน ด้วยการอัปเดตแถวเดียว
+// คืนคีย์ตำแหน่งใหม่ของโน้ต
+func (s *NoteService) MoveNote(noteID uint, userID uint, prevNoteID *uint, nextNoteID *uint) (string, error) {
+	if (prevNoteID != nil && *prevNoteID == noteID) || (nextNoteID != nil && *nextNoteID == noteID) {
+		return "", fmt.Errorf("cannot move a note relative to itself")
+	}
+
+	var position string
+	err := s.noteRepo.WithTransaction(func(repo repository.NoteRepository) error {
+		if err := repo.LockNotePositions(userID); err != nil {
+			return err
+		}
+		note, err := repo.GetNoteByIdAndUser(noteID, userID)
+		if err != nil {
+			return fmt.Errorf("note not found or does not belong to the user")
+		}
+		notes, err := notePositions(repo, userID, note.NotebookID)
+		if err != nil {
+			return err
+		}
+
+		for attempt := 0; attempt < 2; attempt++ {
+			keys := make(map[uint]string, len(notes))
+			for _, note := range notes {
+				keys[note.NoteID] = note.Position
+			}
+
+			// คีย์ของโน้ตก่อนหน้าและถัดไป (ค่าว่าง = ไม่มีขอบ) ต้องอยู่ในสมุดเดียวกับโน้ตที่ย้าย
+			neighbourKey := func(neighbourID *uint) (string, error) {
+				if neighbourID == nil {
+					return "", nil
+				}
+				if key, ok := keys[*neighbourID]; ok {
+					return key, nil
+				}
+				if _, err := repo.GetNoteByIdAndUser(*neighbourID, userID); err != nil {
+					return "", fmt.Errorf("note not found or does not belong to the user")
+				}
+				return "", fmt.Errorf("notes must be in the same notebook")
+			}
+			before, err := neighbourKey(prevNoteID)
+			if err != nil {
+				return err
+			}
+			after, err := neighbourKey(nextNoteID)
+			if err != nil {
+				return err
+			}
+			if prevNoteID != nil && nextNoteID != nil && before >= after {
+				return fmt.Errorf("previous note must come before next note")
+			}
+
+			key, err := utils.PositionKeyBetween(before, after)
+			if err != nil {
+				return err
+			}
+			if len(key) <= utils.MaxPositionKeyLength {
+				position = key
+				return repo.UpdateNotePosition(noteID, userID, key)
+			}
+
+			// คีย์ยาวเกินกำหนด: จัดคีย์ใหม่ทั้งสมุดแล้วลองอีกครั้ง
+			if notes, err = rebalancePositions(repo, userID, notes); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("failed to compute note position")
+	})
+	if err != nil {
+		return "", err
+	}
+	return position, nil
+}
+
 func (s *NoteService) AddTagToNote(noteID uint, tagID uint, userID uint) error {
 	return s.noteRepo.AddTagToNote(noteID, tagID, userID)
 }
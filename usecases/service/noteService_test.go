@@ -0,0 +1,555 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// fakeNoteStore ข้อมูลโน้ตในหน่วยความจำที่ใช้ร่วมกันระหว่าง fakeNoteRepo ทุกตัว
+// LockNotePositions จำลอง SELECT ... FOR UPDATE: ถือ lock ของผู้ใช้ไว้จนจบ transaction
+// transaction ที่ล้มเหลวจะคืนค่าโน้ตทั้งหมดกลับเป็นสถานะก่อนเริ่ม (ใช้กับ test ที่ไม่ได้รันพร้อมกันเท่านั้น)
+type fakeNoteStore struct {
+	mu     sync.Mutex
+	nextID uint
+	notes  map[uint]*entities.Note
+	locks  map[uint]*sync.Mutex
+	tags   map[uint]entities.Tag // แท็กที่ AddTagToNote ใช้ได้
+}
+
+type fakeNoteRepo struct {
+	repository.NoteRepository
+	store *fakeNoteStore
+	held  *[]*sync.Mutex // lock ที่ transaction ปัจจุบันถืออยู่ (nil = ไม่อยู่ใน transaction)
+}
+
+func newFakeNoteRepo(notes ...entities.Note) *fakeNoteRepo {
+	store := &fakeNoteStore{notes: map[uint]*entities.Note{}, locks: map[uint]*sync.Mutex{}}
+	for i := range notes {
+		note := notes[i]
+		store.notes[note.NoteID] = &note
+		if note.NoteID > store.nextID {
+			store.nextID = note.NoteID
+		}
+	}
+	return &fakeNoteRepo{store: store}
+}
+
+func (r *fakeNoteRepo) WithTransaction(fn func(repo repository.NoteRepository) error) error {
+	if r.held != nil {
+		return fn(r)
+	}
+	r.store.mu.Lock()
+	snapshot := make(map[uint]entities.Note, len(r.store.notes))
+	for id, note := range r.store.notes {
+		snapshot[id] = *note
+	}
+	r.store.mu.Unlock()
+
+	held := []*sync.Mutex{}
+	err := fn(&fakeNoteRepo{store: r.store, held: &held})
+	if err != nil {
+		r.store.mu.Lock()
+		r.store.notes = map[uint]*entities.Note{}
+		for id := range snapshot {
+			note := snapshot[id]
+			r.store.notes[id] = &note
+		}
+		r.store.mu.Unlock()
+	}
+	for _, lock := range held {
+		lock.Unlock()
+	}
+	return err
+}
+
+func (r *fakeNoteRepo) LockNotePositions(userID uint) error {
+	if r.held == nil {
+		return fmt.Errorf("LockNotePositions called outside a transaction")
+	}
+	r.store.mu.Lock()
+	lock, ok := r.store.locks[userID]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.store.locks[userID] = lock
+	}
+	r.store.mu.Unlock()
+	lock.Lock()
+	*r.held = append(*r.held, lock)
+	return nil
+}
+
+func (r *fakeNoteRepo) CreateNote(note *entities.Note) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.nextID++
+	note.NoteID = r.store.nextID
+	copied := *note
+	r.store.notes[note.NoteID] = &copied
+	return nil
+}
+
+func (r *fakeNoteRepo) GetNoteByIdAndUser(noteID uint, userID uint) (*entities.Note, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	note, ok := r.store.notes[noteID]
+	if !ok || note.UserID != userID {
+		return nil, fmt.Errorf("note not found")
+	}
+	copied := *note
+	return &copied, nil
+}
+
+func (r *fakeNoteRepo) GetNotePositions(userID uint, notebookID *uint) ([]entities.Note, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var notes []entities.Note
+	for _, note := range r.store.notes {
+		if note.UserID == userID && sameNotebook(note.NotebookID, notebookID) {
+			notes = append(notes, entities.Note{NoteID: note.NoteID, NotebookID: note.NotebookID, Position: note.Position})
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		if notes[i].Position != notes[j].Position {
+			return notes[i].Position < notes[j].Position
+		}
+		return notes[i].NoteID < notes[j].NoteID
+	})
+	return notes, nil
+}
+
+func (r *fakeNoteRepo) AddTagToNote(noteID uint, tagID uint, userID uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	note, ok := r.store.notes[noteID]
+	if !ok || note.UserID != userID {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	tag, ok := r.store.tags[tagID]
+	if !ok || tag.UserID != userID {
+		return fmt.Errorf("tag not found or does not belong to the user")
+	}
+	note.Tags = append(note.Tags, tag)
+	return nil
+}
+
+func (r *fakeNoteRepo) ReplaceTodoItems(noteID uint, todoItems []entities.ToDo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	note, ok := r.store.notes[noteID]
+	if !ok {
+		return fmt.Errorf("note not found")
+	}
+	note.TodoItems = append([]entities.ToDo(nil), todoItems...)
+	return nil
+}
+
+func (r *fakeNoteRepo) ReplaceNoteTags(noteID uint, userID uint, tagIDs []uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	note, ok := r.store.notes[noteID]
+	if !ok || note.UserID != userID {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	var tags []entities.Tag
+	for _, tagID := range tagIDs {
+		tag, ok := r.store.tags[tagID]
+		if !ok || tag.UserID != userID {
+			return fmt.Errorf("tag not found or does not belong to the user")
+		}
+		tags = append(tags, tag)
+	}
+	note.Tags = tags
+	return nil
+}
+
+func (r *fakeNoteRepo) UpdateNoteArchived(noteID uint, userID uint, archivedAt string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	note, ok := r.store.notes[noteID]
+	if !ok || note.UserID != userID || note.DeletedAt != "" {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	note.ArchivedAt = archivedAt
+	return nil
+}
+
+func (r *fakeNoteRepo) UpdateNoteFields(noteID uint, userID uint, updates map[string]interface{}) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	note, ok := r.store.notes[noteID]
+	if !ok || note.UserID != userID {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	for field, value := range updates {
+		switch field {
+		case "title":
+			note.Title = value.(string)
+		case "content":
+			note.Content = value.(string)
+		default:
+			return fmt.Errorf("unsupported field %s", field)
+		}
+	}
+	return nil
+}
+
+func (r *fakeNoteRepo) UpdateNotePosition(noteID uint, userID uint, position string) error {
+	return r.UpdateNotePositions(userID, map[uint]string{noteID: position})
+}
+
+func (r *fakeNoteRepo) UpdateNotePositions(userID uint, positions map[uint]string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for noteID, position := range positions {
+		note, ok := r.store.notes[noteID]
+		if !ok || note.UserID != userID {
+			return fmt.Errorf("note not found or does not belong to the user")
+		}
+		note.Position = position
+	}
+	return nil
+}
+
+func (r *fakeNoteRepo) FindNotesInBatches(userID uint, batchSize int, fn func(notes []entities.Note) error) error {
+	r.store.mu.Lock()
+	var notes []entities.Note
+	for _, note := range r.store.notes {
+		if note.UserID == userID && note.DeletedAt == "" {
+			notes = append(notes, *note)
+		}
+	}
+	r.store.mu.Unlock()
+	sort.Slice(notes, func(i, j int) bool { return notes[i].NoteID < notes[j].NoteID })
+	for start := 0; start < len(notes); start += batchSize {
+		end := start + batchSize
+		if end > len(notes) {
+			end = len(notes)
+		}
+		if err := fn(notes[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderedNoteIDs id ของโน้ตในสมุดตามลำดับที่แสดงผล
+func (r *fakeNoteRepo) orderedNoteIDs(userID uint, notebookID *uint) []uint {
+	notes, _ := r.GetNotePositions(userID, notebookID)
+	ids := make([]uint, 0, len(notes))
+	for _, note := range notes {
+		ids = append(ids, note.NoteID)
+	}
+	return ids
+}
+
+type fakeNotebookRepo struct {
+	repository.NotebookRepository
+	notebooks []entities.Notebook
+}
+
+func (r fakeNotebookRepo) GetNotebooksByUser(userID uint) ([]entities.Notebook, error) {
+	return r.notebooks, nil
+}
+
+func (fakeNotebookRepo) GetNotebookById(notebookID uint) (*entities.Notebook, error) {
+	return &entities.Notebook{NotebookID: notebookID, UserID: 1}, nil
+}
+
+type fakeRuleRepo struct {
+	repository.AutoTagRuleRepository
+}
+
+func (fakeRuleRepo) GetRulesByUser(userID uint) ([]entities.AutoTagRule, error) {
+	return nil, nil
+}
+
+type fakeLinkRepo struct {
+	repository.NoteLinkRepository
+}
+
+func (fakeLinkRepo) ReplaceLinks(sourceNoteID uint, links []entities.NoteLink) error {
+	return nil
+}
+
+func (fakeLinkRepo) ResolveLinksByTitle(userID uint, title string, targetNoteID uint) error {
+	return nil
+}
+
+func newTestNoteService(repo *fakeNoteRepo) *NoteService {
+	return NewNoteService(repo, fakeRuleRepo{}, fakeNotebookRepo{}, fakeLinkRepo{}, &fakeReminderUseCase{}, nil)
+}
+
+func uintPtr(value uint) *uint {
+	return &value
+}
+
+func TestMoveNoteWithinNotebook(t *testing.T) {
+	work, home := uintPtr(10), uintPtr(20)
+	repo := newFakeNoteRepo(
+		entities.Note{NoteID: 1, UserID: 1, NotebookID: work},
+		entities.Note{NoteID: 2, UserID: 1, NotebookID: work},
+		entities.Note{NoteID: 3, UserID: 1, NotebookID: work},
+		entities.Note{NoteID: 4, UserID: 1, NotebookID: home},
+	)
+	s := newTestNoteService(repo)
+
+	// โน้ตเก่าที่ยังไม่มีคีย์ถูก rebalance ตามลำดับเดิมก่อนย้าย
+	if _, err := s.MoveNote(3, 1, nil, uintPtr(1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(repo.orderedNoteIDs(1, work)); got != "[3 1 2]" {
+		t.Fatalf("work notebook order = %s, want [3 1 2]", got)
+	}
+	if _, err := s.MoveNote(3, 1, uintPtr(1), uintPtr(2)); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(repo.orderedNoteIDs(1, work)); got != "[1 3 2]" {
+		t.Fatalf("work notebook order = %s, want [1 3 2]", got)
+	}
+
+	if _, err := s.MoveNote(4, 1, uintPtr(1), nil); err == nil || err.Error() != "notes must be in the same notebook" {
+		t.Fatalf("MoveNote across notebooks error = %v", err)
+	}
+	if _, err := s.MoveNote(1, 1, uintPtr(99), nil); err == nil || err.Error() != "note not found or does not belong to the user" {
+		t.Fatalf("MoveNote next to a missing note error = %v", err)
+	}
+	if _, err := s.MoveNote(1, 1, uintPtr(2), uintPtr(3)); err == nil || err.Error() != "previous note must come before next note" {
+		t.Fatalf("MoveNote with reversed neighbours error = %v", err)
+	}
+}
+
+func TestCreateNoteConcurrentPositions(t *testing.T) {
+	work := uintPtr(10)
+	repo := newFakeNoteRepo()
+	s := newTestNoteService(repo)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- s.CreateNote(&entities.Note{UserID: 1, NotebookID: work, Title: fmt.Sprintf("note %d", i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notes, _ := repo.GetNotePositions(1, work)
+	seen := map[string]bool{}
+	for _, note := range notes {
+		if note.Position == "" || seen[note.Position] {
+			t.Fatalf("duplicate or empty position %q in %+v", note.Position, notes)
+		}
+		seen[note.Position] = true
+	}
+	// โน้ตที่สร้างทีหลังอยู่บนสุด
+	if first := notes[0].NoteID; first != 20 {
+		t.Fatalf("first note = %d, want the latest note 20", first)
+	}
+}
+
+func TestDuplicateNote(t *testing.T) {
+	work := uintPtr(10)
+	source := entities.Note{
+		NoteID:     1,
+		UserID:     1,
+		NotebookID: work,
+		Title:      "Groceries",
+		IsTodo:     true,
+		TodoItems:  []entities.ToDo{{ID: 1, Content: "milk", IsDone: true}, {ID: 2, Content: "eggs"}},
+		Tags:       []entities.Tag{{TagID: 5, UserID: 1, TagName: "home"}},
+		Event:      entities.Event{EventID: 3, StartTime: "2099-01-01 09:00:00"},
+		Reminder: []entities.Reminder{
+			{ReminderID: 7, ReminderTime: "2099-01-01 08:00:00"},
+			{ReminderID: 8, ReminderTime: "2000-01-01 08:00:00"},
+		},
+	}
+	repo := newFakeNoteRepo(source)
+	repo.store.tags = map[uint]entities.Tag{5: {TagID: 5, UserID: 1, TagName: "home"}}
+	reminders := &fakeReminderUseCase{}
+	s := NewNoteService(repo, fakeRuleRepo{}, fakeNotebookRepo{}, fakeLinkRepo{}, reminders, nil)
+
+	copied, err := s.DuplicateNote(1, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.NoteID == 1 || copied.Title != "Groceries" || len(copied.Tags) != 1 || copied.Event.StartTime != "2099-01-01 09:00:00" {
+		t.Fatalf("unexpected copy %+v", copied)
+	}
+	if len(copied.TodoItems) != 2 || copied.TodoItems[0].IsDone || copied.IsAllDone {
+		t.Fatalf("to-do items should be copied unchecked: %+v", copied.TodoItems)
+	}
+	// Reminder ที่เลยเวลาแล้วไม่ถูกคัดลอก และ Reminder ที่คัดลอกถูกตั้งเวลาหลัง commit
+	if len(copied.Reminder) != 1 || copied.Reminder[0].ReminderTime != "2099-01-01 08:00:00" {
+		t.Fatalf("reminders = %+v", copied.Reminder)
+	}
+	if fmt.Sprint(reminders.scheduled) != fmt.Sprint([]uint{copied.NoteID}) {
+		t.Fatalf("scheduled reminders for notes %v", reminders.scheduled)
+	}
+}
+
+func TestDuplicateNoteRollsBack(t *testing.T) {
+	// แท็ก 6 ไม่ใช่ของผู้ใช้ การติดแท็กล้มเหลว ต้องไม่เหลือสำเนาที่ไม่มีแท็ก
+	source := entities.Note{NoteID: 1, UserID: 1, NotebookID: uintPtr(10), Title: "Report", Tags: []entities.Tag{{TagID: 6}}}
+	repo := newFakeNoteRepo(source)
+	repo.store.tags = map[uint]entities.Tag{6: {TagID: 6, UserID: 2}}
+	reminders := &fakeReminderUseCase{}
+	s := NewNoteService(repo, fakeRuleRepo{}, fakeNotebookRepo{}, fakeLinkRepo{}, reminders, nil)
+
+	if _, err := s.DuplicateNote(1, 1, true); err == nil {
+		t.Fatal("expected DuplicateNote to fail")
+	}
+	if len(repo.store.notes) != 1 {
+		t.Fatalf("a partial copy was left behind: %d notes", len(repo.store.notes))
+	}
+	if len(reminders.scheduled) != 0 {
+		t.Fatalf("reminders scheduled for a rolled back copy: %v", reminders.scheduled)
+	}
+}
+
+func TestCreateNoteDropsAttachments(t *testing.T) {
+	repo := newFakeNoteRepo()
+	note := &entities.Note{UserID: 1, NotebookID: uintPtr(10), Content: "x", Attachments: []entities.Attachment{{UserID: 2, Size: -1}}}
+	if err := newTestNoteService(repo).CreateNote(note); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := repo.GetNoteByIdAndUser(note.NoteID, 1); len(stored.Attachments) != 0 {
+		t.Fatalf("attachments were saved with the note: %+v", stored.Attachments)
+	}
+}
+
+func TestArchivePausesReminders(t *testing.T) {
+	reminder := entities.Reminder{ReminderID: 7, NoteID: 1, ReminderTime: "2099-01-01 08:00:00"}
+	repo := newFakeNoteRepo(
+		entities.Note{NoteID: 1, UserID: 1, Reminder: []entities.Reminder{reminder}},
+		entities.Note{NoteID: 2, UserID: 1},
+	)
+	reminders := &fakeReminderUseCase{}
+	s := NewNoteService(repo, fakeRuleRepo{}, fakeNotebookRepo{}, fakeLinkRepo{}, reminders, nil)
+
+	if err := s.SetArchived(1, 1, true); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(reminders.cancelled) != "[7]" || len(reminders.scheduled) != 0 {
+		t.Fatalf("after archive cancelled = %v scheduled = %v", reminders.cancelled, reminders.scheduled)
+	}
+	if err := s.SetArchived(1, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(reminders.scheduled) != "[1]" {
+		t.Fatalf("after unarchive scheduled = %v, want [1]", reminders.scheduled)
+	}
+
+	// คำสั่งแบบกลุ่มทำเหมือนกัน โน้ตที่ไม่มี Reminder หรือทำไม่สำเร็จจะถูกข้าม
+	reminders.cancelled, reminders.scheduled = nil, nil
+	if _, err := s.BatchUpdateNotes(1, []uint{1, 2, 99}, BatchOperation{Operation: "archive"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.BatchUpdateNotes(1, []uint{1, 2, 99}, BatchOperation{Operation: "unarchive"}); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(reminders.cancelled) != "[7]" || fmt.Sprint(reminders.scheduled) != "[1]" {
+		t.Fatalf("batch cancelled = %v scheduled = %v", reminders.cancelled, reminders.scheduled)
+	}
+}
+
+func TestPatchNoteRollsBackOnValidationFailure(t *testing.T) {
+	original := entities.Note{
+		NoteID:    1,
+		UserID:    1,
+		Title:     "Groceries",
+		IsTodo:    true,
+		TodoItems: []entities.ToDo{{Content: "milk"}},
+		Tags:      []entities.Tag{{TagID: 1, UserID: 1}},
+	}
+	title := "Renamed"
+	color := "not-a-color"
+	todos := []entities.ToDo{{Content: "eggs"}}
+	tests := []struct {
+		name    string
+		patch   NotePatch
+		wantErr string
+	}{
+		// ตรวจสอบไม่ผ่านก่อนเริ่ม transaction
+		{"invalid color", NotePatch{Title: &title, Color: &color}, ""},
+		// แท็กของผู้ใช้อื่นถูกตรวจพบหลังจากแทนที่ todo items ไปแล้ว
+		{"foreign tag", NotePatch{Title: &title, TodoItems: &todos, TagIDs: &[]uint{1, 2}}, "tag not found or does not belong to the user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeNoteRepo(original)
+			repo.store.tags = map[uint]entities.Tag{1: {TagID: 1, UserID: 1}, 2: {TagID: 2, UserID: 2}}
+			s := newTestNoteService(repo)
+
+			_, err := s.PatchNote(1, 1, &tt.patch)
+			if err == nil {
+				t.Fatal("expected PatchNote to fail")
+			}
+			if tt.wantErr != "" && err.Error() != tt.wantErr {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+
+			note, _ := repo.GetNoteByIdAndUser(1, 1)
+			if note.Title != "Groceries" || len(note.TodoItems) != 1 || note.TodoItems[0].Content != "milk" || len(note.Tags) != 1 {
+				t.Fatalf("note changed after a failed patch: %+v", note)
+			}
+		})
+	}
+}
+
+func TestBatchUpdateNotesPerNoteResults(t *testing.T) {
+	repo := newFakeNoteRepo(
+		entities.Note{NoteID: 1, UserID: 1},
+		entities.Note{NoteID: 2, UserID: 2},
+		entities.Note{NoteID: 3, UserID: 1},
+	)
+	repo.store.tags = map[uint]entities.Tag{1: {TagID: 1, UserID: 1}}
+	s := newTestNoteService(repo)
+
+	results, err := s.BatchUpdateNotes(1, []uint{1, 2, 99, 3, 1}, BatchOperation{Operation: "add_tag", TagID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BatchNoteResult{
+		{NoteID: 1, Success: true},
+		{NoteID: 2, Error: "note not found or does not belong to the user"},
+		{NoteID: 99, Error: "note not found or does not belong to the user"},
+		{NoteID: 3, Success: true},
+	}
+	if fmt.Sprint(results) != fmt.Sprint(want) {
+		t.Fatalf("results = %+v, want %+v", results, want)
+	}
+	for _, noteID := range []uint{1, 3} {
+		if note, _ := repo.GetNoteByIdAndUser(noteID, 1); len(note.Tags) != 1 {
+			t.Fatalf("note %d tags = %+v, want the tag added once", noteID, note.Tags)
+		}
+	}
+	if note, _ := repo.GetNoteByIdAndUser(2, 2); len(note.Tags) != 0 {
+		t.Fatalf("another user's note was tagged: %+v", note.Tags)
+	}
+
+	// แท็กของผู้ใช้อื่นทำให้ทุกโน้ตล้มเหลวแยกกัน โดยคำสั่งทั้งหมดยังไม่ error
+	results, err = s.BatchUpdateNotes(1, []uint{1, 3}, BatchOperation{Operation: "add_tag", TagID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Success || result.Error != "tag not found or does not belong to the user" {
+			t.Fatalf("result = %+v, want a tag error", result)
+		}
+	}
+
+	if _, err := s.BatchUpdateNotes(1, []uint{1}, BatchOperation{Operation: "explode"}); err == nil {
+		t.Fatal("expected an unknown operation to be rejected")
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"strings"
+	"time"
+)
+
+type NoteTemplateUseCase interface {
+	CreateTemplate(template *entities.NoteTemplate) error
+	CreateTemplateFromNote(noteID uint, userID uint, name string) (*entities.NoteTemplate, error)
+	GetTemplates(userID uint) ([]entities.NoteTemplate, error)
+	UpdateTemplate(templateID uint, userID uint, input *entities.NoteTemplate) error
+	DeleteTemplate(templateID uint, userID uint) error
+	CreateNoteFromTemplate(templateID uint, userID uint, notebookID *uint) (*entities.Note, error)
+}
+
+type NoteTemplateService struct {
+	templateRepo repository.NoteTemplateRepository
+	noteRepo     repository.NoteRepository
+	tagRepo      repository.TagRepository
+	notebookRepo repository.NotebookRepository
+	userRepo     repository.UserRepository
+	noteUseCase  NoteUseCase
+}
+
+func NewNoteTemplateService(templateRepo repository.NoteTemplateRepository, noteRepo repository.NoteRepository, tagRepo repository.TagRepository, notebookRepo repository.NotebookRepository, userRepo repository.UserRepository, noteUseCase NoteUseCase) *NoteTemplateService {
+	return &NoteTemplateService{
+		templateRepo: templateRepo,
+		noteRepo:     noteRepo,
+		tagRepo:      tagRepo,
+		notebookRepo: notebookRepo,
+		userRepo:     userRepo,
+		noteUseCase:  noteUseCase,
+	}
+}
+
+// validateTemplate ตรวจสอบชื่อ สี ระดับความสำคัญ แท็ก และสมุดโน้ตของแม่แบบ
+func (s *NoteTemplateService) validateTemplate(template *entities.NoteTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	template.Color = utils.NormalizeColor(template.Color)
+	template.ContentFormat = utils.NormalizeContentFormat(template.ContentFormat)
+
+	validation := &utils.ValidationError{}
+	if template.Name == "" {
+		validation.Add("name", "is required")
+	}
+	validation.ValidateColor("color", template.Color)
+	validation.ValidatePriority("priority", template.Priority)
+	validation.ValidateContentFormat("content_format", template.ContentFormat)
+	if template.Content != "" && len(template.TodoItems) > 0 {
+		validation.Add("content", "template cannot have both content and todo_items")
+	}
+	if err := validation.Err(); err != nil {
+		return err
+	}
+
+	template.TagIDs = uniqueIDs(template.TagIDs)
+	for _, tagID := range template.TagIDs {
+		tag, err := s.tagRepo.GetTagById(tagID)
+		if err != nil || tag.UserID != template.UserID {
+			return fmt.Errorf("tag not found or does not belong to this user")
+		}
+	}
+	if template.NotebookID != nil {
+		notebook, err := s.notebookRepo.GetNotebookById(*template.NotebookID)
+		if err != nil || notebook.UserID != template.UserID {
+			return fmt.Errorf("notebook not found or does not belong to this user")
+		}
+	}
+	return nil
+}
+
+func (s *NoteTemplateService) CreateTemplate(template *entities.NoteTemplate) error {
+	template.TemplateID = 0
+	if err := s.validateTemplate(template); err != nil {
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	return s.templateRepo.CreateTemplate(template)
+}
+
+// CreateTemplateFromNote: บันทึกโน้ตที่มีอยู่เป็นแม่แบบ (placeholder ในโน้ตจะถูกเก็บไว้ตามเดิม)
+func (s *NoteTemplateService) CreateTemplateFromNote(noteID uint, userID uint, name string) (*entities.NoteTemplate, error) {
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+
+	if strings.TrimSpace(name) == "" {
+		name = note.Title
+	}
+	template := &entities.NoteTemplate{
+		UserID:        userID,
+		Name:          name,
+		Title:         note.Title,
+		Content:       note.Content,
+		ContentFormat: note.ContentFormat,
+		Color:         note.Color,
+		Priority:      note.Priority,
+		IsTodo:        note.IsTodo,
+		NotebookID:    note.NotebookID,
+	}
+	for _, todo := range note.TodoItems {
+		template.TodoItems = append(template.TodoItems, entities.TemplateTodo{Content: todo.Content})
+	}
+	for _, tag := range note.Tags {
+		template.TagIDs = append(template.TagIDs, tag.TagID)
+	}
+
+	if err := s.CreateTemplate(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *NoteTemplateService) GetTemplates(userID uint) ([]entities.NoteTemplate, error) {
+	return s.templateRepo.GetTemplatesByUser(userID)
+}
+
+func (s *NoteTemplateService) getOwnedTemplate(templateID uint, userID uint) (*entities.NoteTemplate, error) {
+	template, err := s.templateRepo.GetTemplateById(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template.UserID != userID {
+		return nil, fmt.Errorf("template not found")
+	}
+	return template, nil
+}
+
+func (s *NoteTemplateService) UpdateTemplate(templateID uint, userID uint, input *entities.NoteTemplate) error {
+	template, err := s.getOwnedTemplate(templateID, userID)
+	if err != nil {
+		return err
+	}
+
+	template.Name = input.Name
+	template.Title = input.Title
+	template.Content = input.Content
+	template.ContentFormat = input.ContentFormat
+	template.Color = input.Color
+	template.Priority = input.Priority
+	template.IsTodo = input.IsTodo
+	template.TodoItems = input.TodoItems
+	template.TagIDs = input.TagIDs
+	template.NotebookID = input.NotebookID
+	if err := s.validateTemplate(template); err != nil {
+		return err
+	}
+
+	template.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+	return s.templateRepo.UpdateTemplate(template)
+}
+
+func (s *NoteTemplateService) DeleteTemplate(templateID uint, userID uint) error {
+	if _, err := s.getOwnedTemplate(templateID, userID); err != nil {
+		return err
+	}
+	return s.templateRepo.DeleteTemplate(templateID)
+}
+
+// userLocation เขตเวลาของผู้ใช้ ถ้าไม่ได้ตั้งไว้ใช้เวลาประเทศไทยเหมือน Reminder
+func (s *NoteTemplateService) userLocation(userID uint) *time.Location {
+	if user, err := s.userRepo.GetUserById(userID); err == nil && user.Timezone != "" {
+		if location, err := time.LoadLocation(user.Timezone); err == nil {
+			return location
+		}
+	}
+	if location, err := time.LoadLocation("Asia/Bangkok"); err == nil {
+		return location
+	}
+	return time.UTC
+}
+
+// CreateNoteFromTemplate: สร้างโน้ตใหม่จากแม่แบบ แทนค่า placeholder ด้วยวันเวลาปัจจุบันในเขตเวลาของผู้ใช้
+// notebookID ที่ระบุจะใช้แทนสมุดของแม่แบบ
+func (s *NoteTemplateService) CreateNoteFromTemplate(templateID uint, userID uint, notebookID *uint) (*entities.Note, error) {
+	template, err := s.getOwnedTemplate(templateID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.userLocation(userID))
+	note := &entities.Note{
+		UserID:        userID,
+		NotebookID:    notebookID,
+		Title:         utils.ExpandPlaceholders(template.Title, now),
+		Content:       utils.ExpandPlaceholders(template.Content, now),
+		ContentFormat: template.ContentFormat,
+		Color:         template.Color,
+		Priority:      template.Priority,
+		IsTodo:        template.IsTodo,
+	}
+	for _, todo := range template.TodoItems {
+		note.TodoItems = append(note.TodoItems, entities.ToDo{Content: utils.ExpandPlaceholders(todo.Content, now)})
+	}
+
+	// สมุดของแม่แบบอาจถูกลบไปแล้ว ในกรณีนั้นโน้ตจะไปอยู่ที่ Inbox
+	if note.NotebookID == nil && template.NotebookID != nil {
+		if notebook, err := s.notebookRepo.GetNotebookById(*template.NotebookID); err == nil && notebook.UserID == userID {
+			note.NotebookID = template.NotebookID
+		}
+	}
+
+	if err := s.noteUseCase.CreateNote(note); err != nil {
+		return nil, err
+	}
+	for _, tagID := range template.TagIDs {
+		if err := s.noteRepo.AddTagToNote(note.NoteID, tagID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.noteRepo.GetNoteByIdAndUser(note.NoteID, userID)
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"strings"
+	"time"
+)
+
+type NotebookUseCase interface {
+	CreateNotebook(notebook *entities.Notebook) error
+	GetNotebooks(userID uint) ([]entities.Notebook, error)
+	UpdateNotebook(notebookID uint, userID uint, input *entities.Notebook) error
+	DeleteNotebook(notebookID uint, userID uint) error
+	MoveNotes(userID uint, noteIDs []uint, notebookID *uint) (int64, error)
+}
+
+type NotebookService struct {
+	repo repository.NotebookRepository
+}
+
+func NewNotebookService(repo repository.NotebookRepository) *NotebookService {
+	return &NotebookService{repo: repo}
+}
+
+func (s *NotebookService) getOwnedNotebook(notebookID uint, userID uint) (*entities.Notebook, error) {
+	notebook, err := s.repo.GetNotebookById(notebookID)
+	if err != nil {
+		return nil, err
+	}
+	if notebook.UserID != userID {
+		return nil, fmt.Errorf("notebook not found")
+	}
+	return notebook, nil
+}
+
+// validateParent ตรวจสอบว่าสมุดแม่เป็นของผู้ใช้ และการย้ายไม่ทำให้เกิดวงวน
+func (s *NotebookService) validateParent(notebookID uint, userID uint, parentNotebookID *uint) error {
+	if parentNotebookID == nil {
+		return nil
+	}
+
+	notebooks, err := s.repo.GetNotebooksByUser(userID)
+	if err != nil {
+		return err
+	}
+	parents := make(map[uint]*uint, len(notebooks))
+	for _, notebook := range notebooks {
+		parents[notebook.NotebookID] = notebook.ParentNotebookID
+	}
+	if _, ok := parents[*parentNotebookID]; !ok {
+		return fmt.Errorf("parent notebook not found")
+	}
+
+	// ไล่ขึ้นจากสมุดแม่ใหม่ไปจนถึงราก ถ้าเจอสมุดที่กำลังย้ายแสดงว่าจะเกิดวงวน (notebookID = 0 คือสมุดใหม่)
+	visited := make(map[uint]bool)
+	for current := parentNotebookID; current != nil && !visited[*current]; current = parents[*current] {
+		if notebookID != 0 && *current == notebookID {
+			return fmt.Errorf("cannot move a notebook under itself or its descendants")
+		}
+		visited[*current] = true
+	}
+	return nil
+}
+
+func (s *NotebookService) CreateNotebook(notebook *entities.Notebook) error {
+	notebook.NotebookID = 0
+	notebook.IsDefault = false
+	notebook.Name = strings.TrimSpace(notebook.Name)
+	if notebook.Name == "" {
+		return fmt.Errorf("notebook name is required")
+	}
+	notebook.Color = utils.NormalizeColor(notebook.Color)
+	validation := &utils.ValidationError{}
+	validation.ValidateColor("color", notebook.Color)
+	if err := validation.Err(); err != nil {
+		return err
+	}
+	if err := s.validateParent(0, notebook.UserID, notebook.ParentNotebookID); err != nil {
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	notebook.CreatedAt = now
+	notebook.UpdatedAt = now
+	return s.repo.CreateNotebook(notebook)
+}
+
+// GetNotebooks คืนสมุดโน้ตทั้งหมดของผู้ใช้ และสร้าง Inbox ให้บัญชีเก่าที่ยังไม่มี
+func (s *NotebookService) GetNotebooks(userID uint) ([]entities.Notebook, error) {
+	if _, err := s.repo.GetDefaultNotebook(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetNotebooksByUser(userID)
+}
+
+func (s *NotebookService) UpdateNotebook(notebookID uint, userID uint, input *entities.Notebook) error {
+	notebook, err := s.getOwnedNotebook(notebookID, userID)
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return fmt.Errorf("notebook name is required")
+	}
+	color := utils.NormalizeColor(input.Color)
+	validation := &utils.ValidationError{}
+	validation.ValidateColor("color", color)
+	if err := validation.Err(); err != nil {
+		return err
+	}
+	if err := s.validateParent(notebookID, userID, input.ParentNotebookID); err != nil {
+		return err
+	}
+
+	notebook.Name = name
+	notebook.Color = color
+	notebook.Position = input.Position
+	notebook.ParentNotebookID = input.ParentNotebookID
+	notebook.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+	return s.repo.UpdateNotebook(notebook)
+}
+
+// DeleteNotebook ลบสมุดโน้ต โน้ตที่อยู่ในสมุดจะถูกย้ายไปที่ Inbox (ลบ Inbox ไม่ได้)
+func (s *NotebookService) DeleteNotebook(notebookID uint, userID uint) error {
+	notebook, err := s.getOwnedNotebook(notebookID, userID)
+	if err != nil {
+		return err
+	}
+	if notebook.IsDefault {
+		return fmt.Errorf("cannot delete the default notebook")
+	}
+
+	inbox, err := s.repo.GetDefaultNotebook(userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteNotebook(notebookID, userID, inbox.NotebookID)
+}
+
+// MoveNotes ย้ายโน้ตหลายรายการไปยังสมุดโน้ต (nil = เอาออกจากสมุด) คืนจำนวนโน้ตที่ถูกย้าย
+func (s *NotebookService) MoveNotes(userID uint, noteIDs []uint, notebookID *uint) (int64, error) {
+	if len(noteIDs) == 0 {
+		return 0, fmt.Errorf("note IDs are required")
+	}
+	if notebookID != nil {
+		if _, err := s.getOwnedNotebook(*notebookID, userID); err != nil {
+			return 0, err
+		}
+	}
+	return s.repo.MoveNotes(userID, noteIDs, notebookID)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"testing"
+)
+
+// memoryNotebookRepo เก็บสมุดโน้ตในหน่วยความจำ (เฉพาะเมธอดที่ใช้ในเทสต์)
+type memoryNotebookRepo struct {
+	repository.NotebookRepository
+	notebooks map[uint]*entities.Notebook
+}
+
+func (r *memoryNotebookRepo) GetNotebookById(notebookID uint) (*entities.Notebook, error) {
+	notebook, ok := r.notebooks[notebookID]
+	if !ok {
+		return nil, fmt.Errorf("notebook not found")
+	}
+	copied := *notebook
+	return &copied, nil
+}
+
+func (r *memoryNotebookRepo) GetNotebooksByUser(userID uint) ([]entities.Notebook, error) {
+	var notebooks []entities.Notebook
+	for _, notebook := range r.notebooks {
+		if notebook.UserID == userID {
+			notebooks = append(notebooks, *notebook)
+		}
+	}
+	return notebooks, nil
+}
+
+func (r *memoryNotebookRepo) UpdateNotebook(notebook *entities.Notebook) error {
+	copied := *notebook
+	r.notebooks[notebook.NotebookID] = &copied
+	return nil
+}
+
+func TestUpdateNotebookRejectsCycles(t *testing.T) {
+	// 1 Inbox, 2 Work > 3 Projects > 4 Archive, 5 ของผู้ใช้อื่น
+	repo := &memoryNotebookRepo{notebooks: map[uint]*entities.Notebook{
+		1: {NotebookID: 1, UserID: 1, Name: "Inbox", IsDefault: true},
+		2: {NotebookID: 2, UserID: 1, Name: "Work"},
+		3: {NotebookID: 3, UserID: 1, Name: "Projects", ParentNotebookID: uintPtr(2)},
+		4: {NotebookID: 4, UserID: 1, Name: "Archive", ParentNotebookID: uintPtr(3)},
+		5: {NotebookID: 5, UserID: 2, Name: "Other"},
+	}}
+	s := NewNotebookService(repo)
+
+	tests := []struct {
+		name       string
+		notebookID uint
+		parentID   *uint
+		wantErr    string
+	}{
+		{"under itself", 2, uintPtr(2), "cannot move a notebook under itself or its descendants"},
+		{"under its child", 2, uintPtr(3), "cannot move a notebook under itself or its descendants"},
+		{"under its grandchild", 2, uintPtr(4), "cannot move a notebook under itself or its descendants"},
+		{"under another user's notebook", 2, uintPtr(5), "parent notebook not found"},
+		{"under a sibling branch", 4, uintPtr(1), ""},
+		{"to the top level", 3, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &entities.Notebook{Name: "Renamed", ParentNotebookID: tt.parentID}
+			err := s.UpdateNotebook(tt.notebookID, 1, input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("UpdateNotebook: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
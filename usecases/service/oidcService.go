@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+type OIDCUseCase interface {
+	BeginLogin(provider string) (authURL string, flowToken string, err error)
+	CompleteLogin(provider string, code string, state string, flowToken string) (string, error)
+}
+
+// OIDCProviderConfig ข้อมูลสำหรับเชื่อมต่อกับผู้ให้บริการ OpenID Connect
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+type oidcProvider struct {
+	config   OIDCProviderConfig
+	mu       sync.Mutex
+	provider *oidc.Provider // โหลด discovery document ครั้งแรกที่ใช้งาน
+}
+
+type OIDCService struct {
+	providers    map[string]*oidcProvider
+	userRepo     repository.UserRepository
+	identityRepo repository.IdentityRepository
+	userService  *UserService
+}
+
+func NewOIDCService(providers []OIDCProviderConfig, userRepo repository.UserRepository, identityRepo repository.IdentityRepository, userService *UserService) *OIDCService {
+	s := &OIDCService{
+		providers:    make(map[string]*oidcProvider),
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		userService:  userService,
+	}
+	for _, p := range providers {
+		s.providers[p.Name] = &oidcProvider{config: p}
+	}
+	return s
+}
+
+// BeginLogin สร้าง URL สำหรับ redirect ไปยังผู้ให้บริการ (authorization code + PKCE)
+// และ flowToken ที่เก็บ state/nonce/verifier ไว้ใน cookie ระหว่างรอ callback
+func (s *OIDCService) BeginLogin(providerName string) (string, string, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return "", "", fmt.Errorf("oidc provider not found")
+	}
+
+	oauthConfig, _, err := p.load()
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString(24)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString(24)
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	// เซ็น flow token ด้วย JWT_SECRET เพื่อป้องกันการแก้ไขค่าใน cookie
+	flow := jwt.New(jwt.SigningMethodHS256)
+	claims := flow.Claims.(jwt.MapClaims)
+	claims["purpose"] = "oidc"
+	claims["provider"] = providerName
+	claims["state"] = state
+	claims["nonce"] = nonce
+	claims["verifier"] = verifier
+	claims["exp"] = time.Now().Add(10 * time.Minute).Unix()
+
+	flowToken, err := flow.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL := oauthConfig.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	return authURL, flowToken, nil
+}
+
+// CompleteLogin แลก code เป็น ID token, ตรวจสอบ token แล้วเชื่อมกับบัญชีผู้ใช้ และคืน JWT ของระบบ
+func (s *OIDCService) CompleteLogin(providerName string, code string, state string, flowToken string) (string, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return "", fmt.Errorf("oidc provider not found")
+	}
+
+	// ตรวจสอบ flow token และ state
+	flow, err := jwt.Parse(flowToken, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil || !flow.Valid {
+		return "", fmt.Errorf("invalid or expired login session")
+	}
+	claims, ok := flow.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != "oidc" || claims["provider"] != providerName {
+		return "", fmt.Errorf("invalid login session")
+	}
+	if state == "" || claims["state"] != state {
+		return "", fmt.Errorf("state mismatch")
+	}
+	nonce, _ := claims["nonce"].(string)
+	verifier, _ := claims["verifier"].(string)
+
+	oauthConfig, idVerifier, err := p.load()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange authorization code: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return "", fmt.Errorf("id_token missing from token response")
+	}
+
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return "", fmt.Errorf("invalid id_token: %v", err)
+	}
+	if idToken.Nonce != nonce {
+		return "", fmt.Errorf("nonce mismatch")
+	}
+
+	var profile struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&profile); err != nil {
+		return "", fmt.Errorf("failed to read id_token claims: %v", err)
+	}
+
+	user, err := s.findOrLinkUser(providerName, idToken.Subject, profile.Email, profile.EmailVerified, profile.Name)
+	if err != nil {
+		return "", err
+	}
+
+	return s.userService.generateToken(user)
+}
+
+// findOrLinkUser หา user จาก identity ที่เคยเชื่อมไว้ ถ้าไม่มีจะเชื่อมกับ user ที่มี email ตรงกัน
+// (เฉพาะ email ที่ผู้ให้บริการยืนยันแล้ว) หรือสร้าง user ใหม่
+func (s *OIDCService) findOrLinkUser(provider, subject, email string, emailVerified bool, name string) (*entities.User, error) {
+	identity, err := s.identityRepo.GetIdentity(provider, subject)
+	if err == nil {
+		return s.userRepo.GetUserById(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load identity: %v", err)
+	}
+
+	if email == "" || !emailVerified {
+		return nil, fmt.Errorf("email not verified by identity provider")
+	}
+
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		// error อื่น (เช่น ฐานข้อมูลล่ม) ต้องไม่ถูกตีความว่าไม่มีบัญชี ไม่อย่างนั้นจะสร้างบัญชีซ้ำ
+		return nil, fmt.Errorf("failed to load user: %v", err)
+	}
+	if err != nil {
+		// ยังไม่มีบัญชี สร้างใหม่โดยไม่มีรหัสผ่าน (login ด้วยรหัสผ่านไม่ได้จนกว่าจะตั้งผ่าน forgot-password)
+		username := name
+		if username == "" {
+			username = strings.Split(email, "@")[0]
+		}
+		user = &entities.User{Username: username, Email: email}
+		if err := s.userRepo.CreateUser(user); err != nil {
+			return nil, fmt.Errorf("failed to create user: %v", err)
+		}
+	}
+
+	if err := s.identityRepo.CreateIdentity(&entities.UserIdentity{
+		UserID:    user.UserID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (p *oidcProvider) load() (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		provider, err := oidc.NewProvider(ctx, p.config.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load oidc provider: %v", err)
+		}
+		p.provider = provider
+	}
+
+	oauthConfig := &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     p.provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+	}
+	verifier := p.provider.Verifier(&oidc.Config{ClientID: p.config.ClientID})
+
+	return oauthConfig, verifier, nil
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("failed to generate random value")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"miw/entities"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// fakeOIDCProvider ผู้ให้บริการ OpenID Connect จำลอง (discovery, JWKS และ token endpoint)
+// code ที่ออกผ่าน authorize ผูกกับ PKCE challenge และ nonce ของ URL ที่ BeginLogin สร้าง
+type fakeOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	issued int
+	grants map[string]fakeOIDCGrant
+}
+
+type fakeOIDCGrant struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &fakeOIDCProvider{key: key, grants: map[string]fakeOIDCGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		p.mu.Lock()
+		grant, ok := p.grants[r.PostForm.Get("code")]
+		delete(p.grants, r.PostForm.Get("code"))
+		p.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   p.server.URL,
+			"aud":   "client-id",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": grant.nonce,
+		}
+		for name, value := range grant.claims {
+			claims[name] = value
+		}
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		idToken.Header["kid"] = "test"
+		signed, err := idToken.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     signed,
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize จำลองการที่ผู้ใช้ login สำเร็จที่ผู้ให้บริการ คืน code และ state ที่ส่งกลับมาที่ callback
+func (p *fakeOIDCProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims) (string, string) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if !strings.HasPrefix(authURL, p.server.URL+"/authorize?") {
+		t.Fatalf("unexpected authorization URL %s", authURL)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL has no PKCE challenge: %s", authURL)
+	}
+
+	p.mu.Lock()
+	p.issued++
+	code := fmt.Sprintf("code-%d", p.issued)
+	p.grants[code] = fakeOIDCGrant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), claims: claims}
+	p.mu.Unlock()
+	return code, query.Get("state")
+}
+
+type fakeIdentityRepo struct {
+	identities []entities.UserIdentity
+}
+
+func (r *fakeIdentityRepo) CreateIdentity(identity *entities.UserIdentity) error {
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) GetIdentity(provider string, subject string) (*entities.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func newTestOIDCService(t *testing.T) (*OIDCService, *fakeOIDCProvider, *fakeUserRepo, *fakeIdentityRepo) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	provider := newFakeOIDCProvider(t)
+	userRepo := &fakeUserRepo{users: map[uint]*entities.User{
+		1: {UserID: 1, Username: "somchai", Email: "somchai@example.com", Password: "hash"},
+	}}
+	identityRepo := &fakeIdentityRepo{}
+	s := NewOIDCService([]OIDCProviderConfig{{
+		Name:        "fake",
+		Issuer:      provider.server.URL,
+		ClientID:    "client-id",
+		RedirectURL: "http://localhost:8000/auth/oidc/fake/callback",
+	}}, userRepo, identityRepo, NewUserService(userRepo))
+	return s, provider, userRepo, identityRepo
+}
+
+// sessionUserID อ่าน user_id จาก JWT ของระบบที่ CompleteLogin คืนมา
+func sessionUserID(t *testing.T, token string) uint {
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := parsed.Claims.(jwt.MapClaims)
+	if claims["purpose"] != nil {
+		t.Fatalf("session token must not have a purpose: %v", claims)
+	}
+	return uint(claims["user_id"].(float64))
+}
+
+func TestOIDCLinksExistingUserByVerifiedEmail(t *testing.T) {
+	s, provider, _, identityRepo := newTestOIDCService(t)
+
+	authURL, flowToken, err := s.BeginLogin("fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := provider.authorize(t, authURL, jwt.MapClaims{"sub": "sub-1", "email": "somchai@example.com", "email_verified": true})
+
+	token, err := s.CompleteLogin("fake", code, state, flowToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID := sessionUserID(t, token); userID != 1 {
+		t.Fatalf("logged in as user %d, want 1", userID)
+	}
+	if len(identityRepo.identities) != 1 || identityRepo.identities[0].UserID != 1 || identityRepo.identities[0].Subject != "sub-1" {
+		t.Fatalf("identity not linked: %+v", identityRepo.identities)
+	}
+
+	// login ครั้งถัดไปใช้ identity ที่เชื่อมไว้ แม้ email ที่ผู้ให้บริการส่งมาจะเปลี่ยนไปแล้ว
+	authURL, flowToken, _ = s.BeginLogin("fake")
+	code, state = provider.authorize(t, authURL, jwt.MapClaims{"sub": "sub-1", "email": "other@example.com"})
+	token, err = s.CompleteLogin("fake", code, state, flowToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID := sessionUserID(t, token); userID != 1 {
+		t.Fatalf("logged in as user %d, want 1", userID)
+	}
+	if len(identityRepo.identities) != 1 {
+		t.Fatalf("identity linked twice: %+v", identityRepo.identities)
+	}
+}
+
+func TestOIDCCreatesNewUser(t *testing.T) {
+	s, provider, userRepo, identityRepo := newTestOIDCService(t)
+
+	authURL, flowToken, err := s.BeginLogin("fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := provider.authorize(t, authURL, jwt.MapClaims{"sub": "sub-2", "email": "malee@example.com", "email_verified": true, "name": "Malee"})
+
+	token, err := s.CompleteLogin("fake", code, state, flowToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := sessionUserID(t, token)
+	user, err := userRepo.GetUserById(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "malee@example.com" || user.Username != "Malee" || user.Password != "" {
+		t.Fatalf("unexpected user %+v", user)
+	}
+	if len(identityRepo.identities) != 1 || identityRepo.identities[0].UserID != userID {
+		t.Fatalf("identity not linked: %+v", identityRepo.identities)
+	}
+}
+
+func TestOIDCRejectsUnverifiedEmail(t *testing.T) {
+	s, provider, userRepo, identityRepo := newTestOIDCService(t)
+
+	authURL, flowToken, _ := s.BeginLogin("fake")
+	code, state := provider.authorize(t, authURL, jwt.MapClaims{"sub": "sub-3", "email": "somchai@example.com", "email_verified": false})
+
+	if _, err := s.CompleteLogin("fake", code, state, flowToken); err == nil || err.Error() != "email not verified by identity provider" {
+		t.Fatalf("CompleteLogin error = %v", err)
+	}
+	if len(identityRepo.identities) != 0 || len(userRepo.users) != 1 {
+		t.Fatal("an unverified email must not link or create accounts")
+	}
+}
+
+func TestOIDCRejectsStateMismatch(t *testing.T) {
+	s, provider, _, _ := newTestOIDCService(t)
+
+	authURL, flowToken, _ := s.BeginLogin("fake")
+	code, _ := provider.authorize(t, authURL, jwt.MapClaims{"sub": "sub-1", "email": "somchai@example.com", "email_verified": true})
+
+	if _, err := s.CompleteLogin("fake", code, "forged-state", flowToken); err == nil || err.Error() != "state mismatch" {
+		t.Fatalf("CompleteLogin error = %v", err)
+	}
+	if _, err := s.CompleteLogin("fake", code, "", flowToken); err == nil || err.Error() != "state mismatch" {
+		t.Fatalf("CompleteLogin error = %v", err)
+	}
+}
+
+func TestOIDCRejectsNonceMismatch(t *testing.T) {
+	s, provider, _, _ := newTestOIDCService(t)
+
+	authURL, flowToken, _ := s.BeginLogin("fake")
+	code, state := provider.authorize(t, authURL, jwt.MapClaims{"sub": "sub-1", "email": "somchai@example.com", "email_verified": true})
+	// ผู้ให้บริการส่ง ID token ที่ออกให้ flow อื่น (nonce ไม่ตรง)
+	provider.mu.Lock()
+	grant := provider.grants[code]
+	grant.nonce = "replayed-nonce"
+	provider.grants[code] = grant
+	provider.mu.Unlock()
+
+	if _, err := s.CompleteLogin("fake", code, state, flowToken); err == nil || err.Error() != "nonce mismatch" {
+		t.Fatalf("CompleteLogin error = %v", err)
+	}
+}
+
+func TestOIDCRequiresPKCEVerifierFromFlow(t *testing.T) {
+	s, provider, _, _ := newTestOIDCService(t)
+
+	// code ที่ออกให้ flow แรกใช้กับ flow token อื่นไม่ได้ เพราะ code_verifier ไม่ตรงกับ challenge
+	authURL, _, _ := s.BeginLogin("fake")
+	code, _ := provider.authorize(t, authURL, jwt.MapClaims{"sub": "sub-1", "email": "somchai@example.com", "email_verified": true})
+	otherURL, otherFlow, _ := s.BeginLogin("fake")
+	parsed, _ := url.Parse(otherURL)
+
+	_, err := s.CompleteLogin("fake", code, parsed.Query().Get("state"), otherFlow)
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to exchange authorization code") {
+		t.Fatalf("CompleteLogin error = %v", err)
+	}
+}
+
+func TestOIDCRejectsForeignFlowToken(t *testing.T) {
+	s, provider, _, _ := newTestOIDCService(t)
+
+	authURL, _, _ := s.BeginLogin("fake")
+	code, state := provider.authorize(t, authURL, jwt.MapClaims{"sub": "sub-1", "email": "somchai@example.com", "email_verified": true})
+	sessionToken, _ := s.userService.generateToken(&entities.User{UserID: 1})
+
+	if _, err := s.CompleteLogin("fake", code, state, sessionToken); err == nil || err.Error() != "invalid login session" {
+		t.Fatalf("CompleteLogin error = %v", err)
+	}
+	if _, _, err := s.BeginLogin("unknown"); err == nil || err.Error() != "oidc provider not found" {
+		t.Fatalf("BeginLogin error = %v", err)
+	}
+}
+
+// unavailableUserRepo จำลองฐานข้อมูลที่ค้นหาผู้ใช้ด้วยอีเมลไม่ได้
+type unavailableUserRepo struct {
+	*fakeUserRepo
+}
+
+func (unavailableUserRepo) GetUserByEmail(email string) (*entities.User, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func TestOIDCDoesNotCreateUserWhenLookupFails(t *testing.T) {
+	s, _, userRepo, identityRepo := newTestOIDCService(t)
+	s.userRepo = unavailableUserRepo{userRepo}
+
+	if _, err := s.findOrLinkUser("fake", "sub-1", "somchai@example.com", true, "Somchai"); err == nil {
+		t.Fatal("expected an error when the user lookup fails")
+	}
+	if len(userRepo.users) != 1 {
+		t.Fatalf("users = %d, want no new user", len(userRepo.users))
+	}
+	if len(identityRepo.identities) != 0 {
+		t.Fatalf("identities = %+v, want none", identityRepo.identities)
+	}
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/sfnt"
+)
+
+type PrintUseCase interface {
+	RenderHTML(userID uint, noteIDs []uint) (string, error)
+	RenderPDF(userID uint, noteIDs []uint) ([]byte, error)
+}
+
+// MaxPrintNotes จำนวนโน้ตสูงสุดที่พิมพ์ได้ในครั้งเดียว
+const MaxPrintNotes = 100
+
+type PrintService struct {
+	noteRepo repository.NoteRepository
+	fontData []byte // ฟอนต์ TTF ที่มีอักษรไทย (PRINT_FONT_FILE) ถ้าไม่มีจะสร้าง PDF ไม่ได้ แต่หน้า HTML สำหรับพิมพ์ยังใช้ได้
+}
+
+
+func NewPrintService(noteRepo repository.NoteRepository, fontData []byte) *PrintService {
+	return &PrintService{
+		noteRepo: noteRepo,
+		fontData: fontData,
+	}
+}
+
+// ValidatePrintFont ตรวจว่าเป็นไฟล์ TTF ที่มีทั้งอักษรไทยและละติน
+func ValidatePrintFont(fontData []byte) error {
+	font, err := sfnt.Parse(fontData)
+	if err != nil {
+		return fmt.Errorf("print font is not a valid TrueType font: %v", err)
+	}
+	var buf sfnt.Buffer
+	for _, r := range "กA" {
+		if index, err := font.GlyphIndex(&buf, r); err != nil || index == 0 {
+			return fmt.Errorf("print font has no glyph for %q", r)
+		}
+	}
+	return nil
+}
+
+// loadNotes โหลดโน้ตตามลำดับที่ขอ (id ซ้ำจะเหลือรายการเดียว) โน้ตทุกรายการต้องเป็นของผู้ใช้
+func (s *PrintService) loadNotes(userID uint, noteIDs []uint) ([]*entities.Note, error) {
+	validation := &utils.ValidationError{}
+	if len(noteIDs) == 0 {
+		validation.Add("note_ids", "must contain at least one note")
+	} else if len(noteIDs) > MaxPrintNotes {
+		validation.Add("note_ids", fmt.Sprintf("must contain at most %d notes", MaxPrintNotes))
+	}
+	if err := validation.Err(); err != nil {
+		return nil, err
+	}
+
+	var notes []*entities.Note
+	seen := map[uint]bool{}
+	for _, noteID := range noteIDs {
+		if seen[noteID] {
+			continue
+		}
+		seen[noteID] = true
+		note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("note not found or does not belong to the user")
+		}
+		sort.Slice(note.TodoItems, func(i, j int) bool { return note.TodoItems[i].ID < note.TodoItems[j].ID })
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// printMeta ข้อความแท็กและเวลา Event ที่แสดงใต้ชื่อโน้ต
+func printMeta(note *entities.Note) []string {
+	var meta []string
+	if len(note.Tags) > 0 {
+		names := make([]string, 0, len(note.Tags))
+		for _, tag := range note.Tags {
+			names = append(names, tag.TagName)
+		}
+		meta = append(meta, "Tags: "+strings.Join(names, ", "))
+	}
+	if note.Event.EventID != 0 {
+		event := "Event: " + note.Event.StartTime
+		if note.Event.EndTime != "" {
+			event += " – " + note.Event.EndTime
+		}
+		meta = append(meta, event)
+	}
+	return meta
+}
+
+const printStyles = `body { font-family: "Sarabun", "Noto Sans Thai", "Helvetica Neue", Arial, sans-serif; color: #222; max-width: 800px; margin: 24px auto; padding: 0 16px; line-height: 1.5; }
+.note { margin-bottom: 48px; }
+.note h1 { font-size: 1.6em; margin: 0 0 4px; }
+.meta { color: #666; font-size: 0.9em; margin: 0 0 16px; }
+.todos { list-style: none; padding: 0; }
+.todos li { margin: 6px 0; display: flex; align-items: flex-start; }
+.checkbox { display: inline-block; width: 1em; height: 1em; border: 1.5px solid #333; margin-right: 8px; flex-shrink: 0; text-align: center; line-height: 1em; font-size: 0.9em; }
+.done .text { color: #777; text-decoration: line-through; }
+pre { white-space: pre-wrap; }
+@media print {
+  body { margin: 0; max-width: none; }
+  .note { margin: 0; page-break-after: always; }
+  .note:last-child { page-break-after: auto; }
+  a { color: inherit; }
+}
+`
+
+// RenderHTML สร้างหน้า HTML สำหรับพิมพ์ โน้ตแต่ละรายการขึ้นหน้าใหม่ เนื้อหาแปลงตาม content_format (ผ่านการ sanitize แล้ว)
+func (s *PrintService) RenderHTML(userID uint, noteIDs []uint) (string, error) {
+	notes, err := s.loadNotes(userID, noteIDs)
+	if err != nil {
+		return "", err
+	}
+
+	title := "Notes"
+	if len(notes) == 1 {
+		title = firstNonEmpty(strings.TrimSpace(notes[0].Title), "Untitled")
+	}
+
+	var body strings.Builder
+	body.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	body.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	body.WriteString("<style>\n" + printStyles + "</style>\n</head>\n<body>\n")
+	for _, note := range notes {
+		body.WriteString("<article class=\"note\">\n")
+		body.WriteString("<h1>" + html.EscapeString(firstNonEmpty(strings.TrimSpace(note.Title), "Untitled")) + "</h1>\n")
+		if meta := printMeta(note); len(meta) > 0 {
+			body.WriteString("<p class=\"meta\">" + html.EscapeString(strings.Join(meta, " · ")) + "</p>\n")
+		}
+		if note.Content != "" {
+			body.WriteString("<div class=\"content\">\n" + utils.RenderContentHTML(note.Content, note.ContentFormat) + "</div>\n")
+		}
+		if len(note.TodoItems) > 0 {
+			body.WriteString("<ul class=\"todos\">\n")
+			for _, todo := range note.TodoItems {
+				class, mark := "", ""
+				if todo.IsDone {
+					class, mark = " class=\"done\"", "✓"
+				}
+				body.WriteString("<li" + class + "><span class=\"checkbox\">" + mark + "</span><span class=\"text\">" + html.EscapeString(todo.Content) + "</span></li>\n")
+			}
+			body.WriteString("</ul>\n")
+		}
+		body.WriteString("</article>\n")
+	}
+	body.WriteString("</body>\n</html>\n")
+	return body.String(), nil
+}
+
+// RenderPDF สร้าง PDF ขนาด A4 ด้วย fpdf (pure Go) โน้ตแต่ละรายการขึ้นหน้าใหม่
+// เนื้อหา Markdown แสดงเป็นข้อความธรรมดา และรายการ To-Do วาดเป็นช่องสี่เหลี่ยมเพื่อใช้ติ๊กบนกระดาษได้
+func (s *PrintService) RenderPDF(userID uint, noteIDs []uint) ([]byte, error) {
+	// ฟอนต์มาตรฐานของ PDF มีเฉพาะอักษรละติน ถ้าไม่ได้ตั้ง PRINT_FONT_FILE ภาษาไทยจะกลายเป็นตัวอักษรเพี้ยน จึงไม่สร้าง PDF เลย
+	if len(s.fontData) == 0 {
+		return nil, fmt.Errorf("PDF printing is not configured")
+	}
+	notes, err := s.loadNotes(userID, noteIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(18, 18, 18)
+	pdf.SetAutoPageBreak(true, 18)
+	pdf.SetCreator("MyNote", true)
+	pdf.SetTitle(firstNonEmpty(strings.TrimSpace(notes[0].Title), "Notes"), true)
+
+	const family = "NoteFont"
+	pdf.AddUTF8FontFromBytes(family, "", s.fontData)
+	pdf.AddUTF8FontFromBytes(family, "B", s.fontData)
+
+	pdf.AliasNbPages("{nb}")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont(family, "", 8)
+		pdf.SetTextColor(140, 140, 140)
+		pdf.CellFormat(0, 4, fmt.Sprintf("%d / {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottomMargin := pdf.GetMargins()
+	for _, note := range notes {
+		pdf.AddPage()
+
+		pdf.SetFont(family, "B", 18)
+		pdf.SetTextColor(20, 20, 20)
+		pdf.MultiCell(0, 8, firstNonEmpty(strings.TrimSpace(note.Title), "Untitled"), "", "L", false)
+
+		if meta := printMeta(note); len(meta) > 0 {
+			pdf.Ln(1)
+			pdf.SetFont(family, "", 10)
+			pdf.SetTextColor(110, 110, 110)
+			pdf.MultiCell(0, 5, strings.Join(meta, "   "), "", "L", false)
+		}
+		pdf.Ln(4)
+
+		pdf.SetTextColor(30, 30, 30)
+		pdf.SetFont(family, "", 11)
+		if note.Content != "" {
+			content := note.Content
+			if utils.NormalizeContentFormat(note.ContentFormat) == utils.ContentFormatMarkdown {
+				content = utils.MarkdownToPlainText(content)
+			}
+			pdf.MultiCell(0, 5.5, strings.TrimSpace(content), "", "L", false)
+			pdf.Ln(3)
+		}
+
+		const boxSize, lineHeight = 4.2, 7.0
+		for _, todo := range note.TodoItems {
+			// ให้ช่องติ๊กอยู่หน้าเดียวกับบรรทัดแรกของรายการ
+			if pdf.GetY()+lineHeight > pageHeight-bottomMargin {
+				pdf.AddPage()
+			}
+			x, y := pdf.GetX(), pdf.GetY()
+			boxY := y + (lineHeight-boxSize)/2
+			pdf.SetDrawColor(40, 40, 40)
+			pdf.SetLineWidth(0.3)
+			pdf.Rect(x, boxY, boxSize, boxSize, "D")
+			if todo.IsDone {
+				pdf.SetLineWidth(0.5)
+				pdf.Line(x+0.8, boxY+boxSize*0.55, x+boxSize*0.4, boxY+boxSize-0.8)
+				pdf.Line(x+boxSize*0.4, boxY+boxSize-0.8, x+boxSize-0.6, boxY+0.7)
+				pdf.SetTextColor(120, 120, 120)
+			} else {
+				pdf.SetTextColor(30, 30, 30)
+			}
+			pdf.SetXY(x+boxSize+3, y)
+			pdf.MultiCell(0, lineHeight, todo.Content, "", "L", false)
+			pdf.SetX(x)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// PrintFileName ชื่อไฟล์ PDF ที่ดาวน์โหลด
+func PrintFileName(noteIDs []uint) string {
+	if len(noteIDs) == 1 {
+		return fmt.Sprintf("note-%d.pdf", noteIDs[0])
+	}
+	return fmt.Sprintf("notes-%s.pdf", time.Now().Format("20060102"))
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"bytes"
+	"miw/entities"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestRenderPDFRequiresFont(t *testing.T) {
+	repo := newFakeNoteRepo(entities.Note{NoteID: 1, UserID: 1, Title: "สวัสดี"})
+	if _, err := NewPrintService(repo, nil).RenderPDF(1, []uint{1}); err == nil || err.Error() != "PDF printing is not configured" {
+		t.Fatalf("RenderPDF without a font error = %v", err)
+	}
+
+	// HTML สำหรับพิมพ์ไม่ต้องใช้ฟอนต์ของเซิร์ฟเวอร์
+	if _, err := NewPrintService(repo, nil).RenderHTML(1, []uint{1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderPDFEmbedsFont(t *testing.T) {
+	repo := newFakeNoteRepo(entities.Note{NoteID: 1, UserID: 1, Title: "Groceries", IsTodo: true,
+		TodoItems: []entities.ToDo{{ID: 1, Content: "milk", IsDone: true}, {ID: 2, Content: "eggs"}}})
+	document, err := NewPrintService(repo, goregular.TTF).RenderPDF(1, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(document, []byte("%PDF-")) || !bytes.Contains(document, []byte("/FontFile2")) {
+		t.Fatal("PDF does not embed the configured TrueType font")
+	}
+}
+
+func TestValidatePrintFont(t *testing.T) {
+	if err := ValidatePrintFont([]byte("not a font")); err == nil {
+		t.Fatal("expected an error for a file that is not a font")
+	}
+	// ฟอนต์ Go มีเฉพาะอักษรละติน
+	if err := ValidatePrintFont(goregular.TTF); err == nil || err.Error() != `print font has no glyph for 'ก'` {
+		t.Fatalf("ValidatePrintFont(Go Regular) error = %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"strings"
+	"time"
+)
+
+// scope ของ rate limiter ที่ใช้ในระบบ
+const (
+	RateLimitLogin          = "login"
+	RateLimitForgotPassword = "forgot-password"
+	RateLimitResetPassword  = "reset-password"
+)
+
+// RateLimitPolicy กำหนดจำนวนครั้งที่ยอมให้ภายในหน้าต่างเวลา และระยะเวลาล็อกเมื่อเกินกำหนด
+// ระยะเวลาล็อกจะเพิ่มเป็นสองเท่าทุกครั้งที่ถูกล็อกซ้ำ (progressive lockout) แต่ไม่เกิน MaxLockout
+// ถ้าไม่ถูกล็อกอีกภายใน LockoutDecay หลังการล็อกครั้งล่าสุดหมด ระยะเวลาล็อกจะกลับไปเริ่มที่ BaseLockout
+type RateLimitPolicy struct {
+	MaxAttempts  int
+	Window       time.Duration
+	BaseLockout  time.Duration
+	MaxLockout   time.Duration
+	LockoutDecay time.Duration
+}
+
+var DefaultRateLimitPolicies = map[string]RateLimitPolicy{
+	RateLimitLogin:          {MaxAttempts: 5, Window: 15 * time.Minute, BaseLockout: time.Minute, MaxLockout: time.Hour, LockoutDecay: 6 * time.Hour},
+	RateLimitForgotPassword: {MaxAttempts: 3, Window: time.Hour, BaseLockout: 15 * time.Minute, MaxLockout: 24 * time.Hour, LockoutDecay: 24 * time.Hour},
+	RateLimitResetPassword:  {MaxAttempts: 5, Window: 15 * time.Minute, BaseLockout: 5 * time.Minute, MaxLockout: time.Hour, LockoutDecay: 6 * time.Hour},
+}
+
+type RateLimitUseCase interface {
+	Check(scope string, keys ...string) (time.Duration, error)
+	Hit(scope string, keys ...string) (time.Duration, error)
+	Reset(scope string, keys ...string) error
+}
+
+type RateLimitService struct {
+	store    repository.RateLimitRepository
+	audit    repository.LockoutEventRepository
+	policies map[string]RateLimitPolicy
+}
+
+func NewRateLimitService(store repository.RateLimitRepository, audit repository.LockoutEventRepository) *RateLimitService {
+	return &RateLimitService{
+		store:    store,
+		audit:    audit,
+		policies: DefaultRateLimitPolicies,
+	}
+}
+
+// RateLimitKey สร้าง key สำหรับตัวนับ เช่น RateLimitKey("ip", "1.2.3.4") => "ip:1.2.3.4"
+func RateLimitKey(kind, value string) string {
+	return kind + ":" + strings.ToLower(strings.TrimSpace(value))
+}
+
+// Check ตรวจสอบว่า key ใดถูกล็อกอยู่หรือไม่ และคืนเวลาที่ต้องรอ (0 = ผ่าน)
+func (s *RateLimitService) Check(scope string, keys ...string) (time.Duration, error) {
+	var retryAfter time.Duration
+	now := time.Now()
+
+	for _, key := range keys {
+		counter, err := s.store.GetCounter(scope + ":" + key)
+		if err != nil {
+			return 0, err
+		}
+		if counter == nil || counter.LockedUntil == "" {
+			continue
+		}
+
+		lockedUntil, err := time.ParseInLocation("2006-01-02 15:04:05", counter.LockedUntil, time.Local)
+		if err != nil {
+			continue
+		}
+		if wait := lockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// Hit นับความพยายาม 1 ครั้งให้ทุก key และล็อก key ที่เกินกำหนด คืนเวลาที่ต้องรอถ้าถูกล็อก
+func (s *RateLimitService) Hit(scope string, keys ...string) (time.Duration, error) {
+	policy, ok := s.policies[scope]
+	if !ok {
+		return 0, fmt.Errorf("unknown rate limit scope: %s", scope)
+	}
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		counterKey := scope + ":" + key
+		counter, err := s.store.Increment(counterKey, policy.Window, policy.LockoutDecay)
+		if err != nil {
+			return 0, err
+		}
+		if counter.Count < policy.MaxAttempts {
+			continue
+		}
+
+		// คำนวณเวลาล็อก: BaseLockout * 2^LockoutCount แต่ไม่เกิน MaxLockout
+		lockout := policy.BaseLockout
+		for i := 0; i < counter.LockoutCount && lockout < policy.MaxLockout; i++ {
+			lockout *= 2
+		}
+		if lockout > policy.MaxLockout {
+			lockout = policy.MaxLockout
+		}
+
+		lockedUntil := time.Now().Add(lockout)
+		if err := s.store.Lock(counterKey, lockedUntil); err != nil {
+			return 0, err
+		}
+
+		// บันทึกเหตุการณ์ล็อกไว้ตรวจสอบย้อนหลัง (ไม่ทำให้ request ล้มเหลวถ้าบันทึกไม่สำเร็จ)
+		event := &entities.LockoutEvent{
+			Scope:       scope,
+			Key:         key,
+			Attempts:    counter.Count,
+			LockedUntil: lockedUntil.Format("2006-01-02 15:04:05"),
+			CreatedAt:   time.Now().Format("2006-01-02 15:04:05"),
+		}
+		if err := s.audit.CreateLockoutEvent(event); err != nil {
+			log.Printf("Failed to record lockout event for %s: %v", counterKey, err)
+		}
+		log.Printf("Rate limit lockout: %s locked until %s after %d attempts", counterKey, event.LockedUntil, counter.Count)
+
+		if lockout > retryAfter {
+			retryAfter = lockout
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// Reset ล้างตัวนับของ key (ใช้เมื่อ login สำเร็จ)
+func (s *RateLimitService) Reset(scope string, keys ...string) error {
+	for _, key := range keys {
+		if err := s.store.Reset(scope + ":" + key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
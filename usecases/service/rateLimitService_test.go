@@ -0,0 +1,91 @@
+package service
+
+import (
+	"miw/adapters/memoryRepository"
+	"miw/entities"
+	"testing"
+	"time"
+)
+
+type fakeLockoutEventRepo struct {
+	events []entities.LockoutEvent
+}
+
+func (r *fakeLockoutEventRepo) CreateLockoutEvent(event *entities.LockoutEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func TestRateLimitProgressiveLockout(t *testing.T) {
+	store := memoryRepository.NewMemoryRateLimitRepository()
+	audit := &fakeLockoutEventRepo{}
+	s := NewRateLimitService(store, audit)
+	ipKey := RateLimitKey("ip", " 10.0.0.1 ")
+
+	// เวลาล็อกเพิ่มเป็นสองเท่าทุกครั้งจนถึง MaxLockout
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 32 * time.Minute, time.Hour, time.Hour} {
+		for i := 1; i < 5; i++ {
+			if retryAfter, err := s.Hit(RateLimitLogin, ipKey); err != nil || retryAfter != 0 {
+				t.Fatalf("attempt %d before the limit: retryAfter = %v, %v", i, retryAfter, err)
+			}
+		}
+		retryAfter, err := s.Hit(RateLimitLogin, ipKey)
+		if err != nil || retryAfter != want {
+			t.Fatalf("lockout = %v, %v; want %v", retryAfter, err, want)
+		}
+		if wait, _ := s.Check(RateLimitLogin, ipKey); wait <= want-time.Second*2 || wait > want {
+			t.Fatalf("Check() = %v, want about %v", wait, want)
+		}
+	}
+	if len(audit.events) != 8 || audit.events[0].Key != "ip:10.0.0.1" || audit.events[0].Attempts != 5 {
+		t.Fatalf("lockout events = %+v", audit.events)
+	}
+
+	// key อื่นไม่ถูกล็อกตาม
+	if wait, _ := s.Check(RateLimitLogin, RateLimitKey("ip", "10.0.0.2")); wait != 0 {
+		t.Fatalf("unrelated key is locked for %v", wait)
+	}
+	if err := s.Reset(RateLimitLogin, ipKey); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := s.Check(RateLimitLogin, ipKey); wait != 0 {
+		t.Fatalf("key is still locked for %v after Reset", wait)
+	}
+}
+
+func TestRateLimitLockoutDecays(t *testing.T) {
+	store := memoryRepository.NewMemoryRateLimitRepository()
+	s := NewRateLimitService(store, &fakeLockoutEventRepo{})
+	key := RateLimitKey("email", "owner@example.com")
+
+	// เคยถูกล็อกหลายครั้ง แต่ครั้งล่าสุดหมดไปนานกว่า LockoutDecay แล้ว
+	store.Increment(RateLimitLogin+":"+key, time.Minute, time.Hour)
+	for i := 0; i < 6; i++ {
+		store.Lock(RateLimitLogin+":"+key, time.Now().Add(-7*time.Hour))
+	}
+	var retryAfter time.Duration
+	for i := 0; i < 5; i++ {
+		retryAfter, _ = s.Hit(RateLimitLogin, key)
+	}
+	if retryAfter != time.Minute {
+		t.Fatalf("lockout after a quiet period = %v, want %v", retryAfter, time.Minute)
+	}
+}
+
+func TestRateLimitCheckUsesLongestLock(t *testing.T) {
+	store := memoryRepository.NewMemoryRateLimitRepository()
+	s := NewRateLimitService(store, &fakeLockoutEventRepo{})
+	store.Lock(RateLimitLogin+":a", time.Now().Add(time.Minute))
+	store.Lock(RateLimitLogin+":b", time.Now().Add(time.Hour))
+	store.Lock(RateLimitLogin+":c", time.Now().Add(-time.Hour))
+
+	if wait, _ := s.Check(RateLimitLogin, "a", "b", "c"); wait < 59*time.Minute {
+		t.Fatalf("Check() = %v, want about an hour", wait)
+	}
+	if wait, _ := s.Check(RateLimitLogin, "c"); wait > 0 {
+		t.Fatalf("expired lock still waits %v", wait)
+	}
+	if _, err := s.Hit("unknown", "a"); err == nil {
+		t.Fatal("expected an error for an unknown scope")
+	}
+}
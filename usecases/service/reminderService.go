@@ -2,6 +2,8 @@ package service
 
 import (
 	"fmt"
+	"html"
+	"strings"
 	"miw/entities"
 	"miw/usecases/repository"
 	"gorm.io/gorm"
@@ -9,6 +11,7 @@ import (
 	"miw/utils"
 	"time"
 	"log"
+	"sync"
 )
 
 type ReminderUseCase interface {
@@ -16,12 +19,16 @@ type ReminderUseCase interface {
 	GetReminderByNoteID(userID uint, noteID uint) ([]entities.Reminder, error)
 	UpdateReminder(userID uint, reminderID uint, reminderTime *string, recurring *bool, frequency *string) error
 	DeleteReminder(userID uint, reminderID uint) error 
+	CancelScheduledReminders(reminderIDs []uint)
+	ScheduleReminders(note *entities.Note)
 }
 
 type ReminderService struct {
 	reminderRepo repository.ReminderRepository
 	noteRepo repository.NoteRepository
 	userRepo repository.UserRepository
+	mu sync.Mutex
+	timers map[uint]*time.Timer // timer ที่ตั้งไว้ของแต่ละ Reminder
 }
 
 func NewReminderService(reminderRepo repository.ReminderRepository,noteRepo repository.NoteRepository, userRepo repository.UserRepository) *ReminderService {
@@ -29,6 +36,7 @@ func NewReminderService(reminderRepo repository.ReminderRepository,noteRepo repo
 		reminderRepo: reminderRepo,
 		noteRepo: noteRepo,
 		userRepo: userRepo,
+		timers: make(map[uint]*time.Timer),
 	}
 }
 
@@ -149,36 +157,125 @@ func (s *ReminderService) UpdateReminder(userID uint, reminderID uint, reminderT
 
 
 func (s *ReminderService) scheduleReminder(note *entities.Note, reminder *entities.Reminder, reminderTime time.Time) {
-	go func() {
-		durationUntilReminder := time.Until(reminderTime)
-		time.AfterFunc(durationUntilReminder, func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// ยกเลิก timer เดิมของ Reminder นี้ก่อน เพื่อไม่ให้แจ้งเตือนซ้ำหลังการแก้ไข
+	if existing, ok := s.timers[reminder.ReminderID]; ok {
+		existing.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(reminderTime), func() {
+		s.mu.Lock()
+		if s.timers[reminder.ReminderID] != timer {
+			// timer นี้ถูกยกเลิกหรือถูกแทนที่แล้ว
+			s.mu.Unlock()
+			return
+		}
+		delete(s.timers, reminder.ReminderID)
+		s.mu.Unlock()
+
+		// ดึงโน้ตล่าสุดตอนถึงเวลา ถ้าโน้ตถูกเก็บเข้าคลังหรืออยู่ในถังขยะจะพักการแจ้งเตือนไว้
+		if current, err := s.noteRepo.GetNoteById(note.NoteID); err == nil {
+			note = current
+		}
+		if note.ArchivedAt != "" || note.DeletedAt != "" {
+			log.Printf("Reminder %d skipped: note %d is archived or deleted", reminder.ReminderID, note.NoteID)
+		} else {
 			s.sendReminder(note, reminder)
+		}
 
-			if reminder.Recurring {
-				s.scheduleRecurringReminder(note, reminder, reminderTime)
+		if reminder.Recurring {
+			s.scheduleRecurringReminder(note, reminder, reminderTime)
+		}
+	})
+	s.timers[reminder.ReminderID] = timer
+}
+
+// ScheduleReminders ตั้งเวลาแจ้งเตือนให้ Reminder ของโน้ตที่บันทึกลงฐานข้อมูลแล้ว (เช่น โน้ตที่คัดลอกมาหรือนำออกจากคลัง)
+// Reminder ที่เลยเวลาไปแล้วจะถูกข้าม ยกเว้น Reminder ที่ทำซ้ำซึ่งจะเลื่อนไปครั้งถัดไปที่ยังไม่ถึงเวลา
+func (s *ReminderService) ScheduleReminders(note *entities.Note) {
+	thLocation, _ := time.LoadLocation("Asia/Bangkok")
+	now := time.Now().In(thLocation)
+	for i := range note.Reminder {
+		reminder := &note.Reminder[i]
+		reminderTime, err := time.ParseInLocation("2006-01-02 15:04:05", reminder.ReminderTime, thLocation)
+		if err != nil || reminder.ReminderID == 0 {
+			continue
+		}
+		for reminder.Recurring && reminderTime.Before(now) {
+			next := nextReminderTime(reminderTime, reminder.Frequency)
+			if !next.After(reminderTime) {
+				break
 			}
-		})
-	}()
+			reminderTime = next
+		}
+		if reminderTime.Before(now) {
+			continue
+		}
+		s.scheduleReminder(note, reminder, reminderTime)
+	}
+}
+
+// CancelScheduledReminders ยกเลิกการแจ้งเตือนที่ตั้งเวลาไว้แล้ว (เช่น เมื่อลบ Reminder หรือลบบัญชี)
+func (s *ReminderService) CancelScheduledReminders(reminderIDs []uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range reminderIDs {
+		if timer, ok := s.timers[id]; ok {
+			timer.Stop()
+			delete(s.timers, id)
+		}
+	}
 }
 
 func (s *ReminderService) scheduleRecurringReminder(note *entities.Note, reminder *entities.Reminder, reminderTime time.Time) {
 	thLocation, _ := time.LoadLocation("Asia/Bangkok")
-	nextTime := reminderTime
+	nextTime := nextReminderTime(reminderTime, reminder.Frequency)
 
-	switch reminder.Frequency {
+	if nextTime.After(time.Now().In(thLocation)) {
+		s.scheduleReminder(note, reminder, nextTime)
+	}
+}
+
+// nextReminderTime เวลาแจ้งเตือนครั้งถัดไปของ Reminder ที่ทำซ้ำ (ความถี่ที่ไม่รู้จักคืนเวลาเดิม)
+func nextReminderTime(reminderTime time.Time, frequency string) time.Time {
+	switch frequency {
 	case "daily":
-		nextTime = reminderTime.AddDate(0, 0, 1)
+		return reminderTime.AddDate(0, 0, 1)
 	case "weekly":
-		nextTime = reminderTime.AddDate(0, 0, 7)
+		return reminderTime.AddDate(0, 0, 7)
 	case "monthly":
-		nextTime = reminderTime.AddDate(0, 1, 0)
+		return reminderTime.AddDate(0, 1, 0)
 	case "yearly":
-		nextTime = reminderTime.AddDate(1, 0, 0)
+		return reminderTime.AddDate(1, 0, 0)
 	}
+	return reminderTime
+}
 
-	if nextTime.After(time.Now().In(thLocation)) {
-		s.scheduleReminder(note, reminder, nextTime)
+// reminderEmailHTML สร้างเนื้อหาอีเมลแบบ HTML โดยแปลงเนื้อหาโน้ตตาม content_format (ผ่านการ sanitize แล้ว)
+func reminderEmailHTML(note *entities.Note, reminder *entities.Reminder) string {
+	var body strings.Builder
+	body.WriteString("<h2>Reminder</h2>\n")
+	body.WriteString("<h3>" + html.EscapeString(note.Title) + "</h3>\n")
+	if note.Content != "" {
+		body.WriteString(utils.RenderContentHTML(note.Content, note.ContentFormat))
+	}
+	if len(note.TodoItems) > 0 {
+		body.WriteString("<ul>\n")
+		for _, todo := range note.TodoItems {
+			checked := ""
+			if todo.IsDone {
+				checked = " checked"
+			}
+			body.WriteString("<li><input type=\"checkbox\" disabled" + checked + "> " + html.EscapeString(todo.Content) + "</li>\n")
+		}
+		body.WriteString("</ul>\n")
 	}
+	body.WriteString("<p>Reminder Time: " + html.EscapeString(reminder.ReminderTime) + "</p>\n")
+	return body.String()
 }
 
 func (s *ReminderService) sendReminder(note *entities.Note, reminder *entities.Reminder) {
@@ -208,7 +305,7 @@ func (s *ReminderService) sendReminder(note *entities.Note, reminder *entities.R
 
     emailBody += fmt.Sprintf("\nReminder Time: %s\n", reminder.ReminderTime)
 
-    err = utils.SendEmail(userEmail, "Reminder Notification", emailBody)
+    err = utils.SendHTMLEmail(userEmail, "Reminder Notification", emailBody, reminderEmailHTML(note, reminder))
     if err != nil {
         log.Printf("Failed to send reminder email: %v", err)
     } else {
@@ -234,5 +331,10 @@ func (s *ReminderService) DeleteReminder(userID uint, reminderID uint) error {
 	}
 
 	// ลบ Reminder
-	return s.reminderRepo.DeleteReminder(reminderID)
+	if err := s.reminderRepo.DeleteReminder(reminderID); err != nil {
+		return err
+	}
+
+	s.CancelScheduledReminders([]uint{reminderID})
+	return nil
 }
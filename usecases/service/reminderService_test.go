@@ -0,0 +1,52 @@
+package service
+
+import (
+	"miw/entities"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestScheduleReminders(t *testing.T) {
+	s := NewReminderService(nil, nil, nil)
+	thLocation, _ := time.LoadLocation("Asia/Bangkok")
+	past := time.Now().In(thLocation).Add(-36 * time.Hour).Format("2006-01-02 15:04:05")
+	note := &entities.Note{NoteID: 1, Reminder: []entities.Reminder{
+		{ReminderID: 1, ReminderTime: "2099-01-01 08:00:00"},
+		{ReminderID: 2, ReminderTime: past},
+		{ReminderID: 3, ReminderTime: past, Recurring: true, Frequency: "daily"},
+		{ReminderID: 4, ReminderTime: past, Recurring: true, Frequency: "unknown"},
+		{ReminderTime: "2099-01-01 08:00:00"},
+	}}
+
+	s.ScheduleReminders(note)
+	defer s.CancelScheduledReminders([]uint{1, 2, 3, 4})
+
+	s.mu.Lock()
+	var scheduled []int
+	for id := range s.timers {
+		scheduled = append(scheduled, int(id))
+	}
+	s.mu.Unlock()
+	sort.Ints(scheduled)
+	// Reminder ที่เลยเวลาแล้วถูกข้าม ส่วน Reminder รายวันเลื่อนไปครั้งถัดไป
+	if len(scheduled) != 2 || scheduled[0] != 1 || scheduled[1] != 3 {
+		t.Fatalf("scheduled reminders = %v, want [1 3]", scheduled)
+	}
+}
+
+func TestNextReminderTime(t *testing.T) {
+	base := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+	tests := map[string]string{
+		"daily":   "2024-02-01",
+		"weekly":  "2024-02-07",
+		"monthly": "2024-03-02",
+		"yearly":  "2025-01-31",
+		"":        "2024-01-31",
+	}
+	for frequency, want := range tests {
+		if got := nextReminderTime(base, frequency).Format("2006-01-02"); got != want {
+			t.Errorf("nextReminderTime(%q) = %s, want %s", frequency, got, want)
+		}
+	}
+}
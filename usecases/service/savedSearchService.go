@@ -0,0 +1,198 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+	"strings"
+	"time"
+)
+
+type SavedSearchUseCase interface {
+	CreateSavedSearch(search *entities.SavedSearch) error
+	GetSavedSearches(userID uint) ([]entities.SavedSearch, error)
+	UpdateSavedSearch(searchID uint, userID uint, input *entities.SavedSearch) error
+	DeleteSavedSearch(searchID uint, userID uint) error
+	RunSavedSearch(searchID uint, userID uint) ([]entities.Note, error)
+	GetSidebar(userID uint) (*Sidebar, error)
+}
+
+// Sidebar ข้อมูลสำหรับแสดงแถบด้านข้าง: สมุดโน้ต ต้นไม้แท็ก และการค้นหาที่บันทึกไว้
+type Sidebar struct {
+	Notebooks     []entities.Notebook
+	Tags          []*TagTreeNode
+	SavedSearches []entities.SavedSearch
+}
+
+type SavedSearchService struct {
+	searchRepo      repository.SavedSearchRepository
+	noteRepo        repository.NoteRepository
+	tagRepo         repository.TagRepository
+	tagUseCase      TagUseCase
+	notebookUseCase NotebookUseCase
+}
+
+func NewSavedSearchService(searchRepo repository.SavedSearchRepository, noteRepo repository.NoteRepository, tagRepo repository.TagRepository, tagUseCase TagUseCase, notebookUseCase NotebookUseCase) *SavedSearchService {
+	return &SavedSearchService{
+		searchRepo:      searchRepo,
+		noteRepo:        noteRepo,
+		tagRepo:         tagRepo,
+		tagUseCase:      tagUseCase,
+		notebookUseCase: notebookUseCase,
+	}
+}
+
+var savedSearchSortFields = map[string]bool{
+	"updated_at": true,
+	"created_at": true,
+	"title":      true,
+	"priority":   true,
+	"position":   true,
+}
+
+// validateSavedSearch ตรวจสอบชื่อ การเรียงลำดับ และเงื่อนไขใน filter พร้อมตั้งค่าเริ่มต้น
+func (s *SavedSearchService) validateSavedSearch(search *entities.SavedSearch) error {
+	search.Name = strings.TrimSpace(search.Name)
+	if search.Name == "" {
+		return fmt.Errorf("invalid saved search: name is required")
+	}
+
+	if search.SortBy == "" {
+		search.SortBy = "updated_at"
+	}
+	if !savedSearchSortFields[search.SortBy] {
+		return fmt.Errorf("invalid saved search: unknown sort field '%s'", search.SortBy)
+	}
+	if search.SortOrder == "" {
+		search.SortOrder = "desc"
+	}
+	if search.SortOrder != "asc" && search.SortOrder != "desc" {
+		return fmt.Errorf("invalid saved search: sort order must be 'asc' or 'desc'")
+	}
+
+	filter := &search.Filter
+	filter.Query = strings.TrimSpace(filter.Query)
+
+	dates := map[string]string{
+		"created_from": filter.CreatedFrom,
+		"created_to":   filter.CreatedTo,
+		"updated_from": filter.UpdatedFrom,
+		"updated_to":   filter.UpdatedTo,
+	}
+	for name, value := range dates {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fmt.Errorf("invalid saved search: %s must be in YYYY-MM-DD format", name)
+		}
+	}
+	if filter.CreatedFrom != "" && filter.CreatedTo != "" && filter.CreatedFrom > filter.CreatedTo {
+		return fmt.Errorf("invalid saved search: created_from must not be after created_to")
+	}
+	if filter.UpdatedFrom != "" && filter.UpdatedTo != "" && filter.UpdatedFrom > filter.UpdatedTo {
+		return fmt.Errorf("invalid saved search: updated_from must not be after updated_to")
+	}
+	filter.Color = utils.NormalizeColor(filter.Color)
+	if !utils.IsValidColor(filter.Color) {
+		return fmt.Errorf("invalid saved search: color must be a named color or a hex color")
+	}
+	if (filter.MinPriority != nil && !utils.IsValidPriority(*filter.MinPriority)) ||
+		(filter.MaxPriority != nil && !utils.IsValidPriority(*filter.MaxPriority)) {
+		return fmt.Errorf("invalid saved search: priority must be between %d and %d", utils.PriorityNone, utils.PriorityUrgent)
+	}
+	if filter.MinPriority != nil && filter.MaxPriority != nil && *filter.MinPriority > *filter.MaxPriority {
+		return fmt.Errorf("invalid saved search: min_priority must not be greater than max_priority")
+	}
+
+	for _, tagID := range filter.TagIDs {
+		tag, err := s.tagRepo.GetTagById(tagID)
+		if err != nil || tag.UserID != search.UserID {
+			return fmt.Errorf("tag not found or does not belong to this user")
+		}
+	}
+
+	return nil
+}
+
+func (s *SavedSearchService) CreateSavedSearch(search *entities.SavedSearch) error {
+	search.SearchID = 0
+	if err := s.validateSavedSearch(search); err != nil {
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	search.CreatedAt = now
+	search.UpdatedAt = now
+	return s.searchRepo.CreateSavedSearch(search)
+}
+
+func (s *SavedSearchService) GetSavedSearches(userID uint) ([]entities.SavedSearch, error) {
+	return s.searchRepo.GetSavedSearchesByUser(userID)
+}
+
+func (s *SavedSearchService) getOwnedSavedSearch(searchID uint, userID uint) (*entities.SavedSearch, error) {
+	search, err := s.searchRepo.GetSavedSearchById(searchID)
+	if err != nil {
+		return nil, err
+	}
+	if search.UserID != userID {
+		return nil, fmt.Errorf("saved search not found")
+	}
+	return search, nil
+}
+
+func (s *SavedSearchService) UpdateSavedSearch(searchID uint, userID uint, input *entities.SavedSearch) error {
+	search, err := s.getOwnedSavedSearch(searchID, userID)
+	if err != nil {
+		return err
+	}
+
+	search.Name = input.Name
+	search.Filter = input.Filter
+	search.SortBy = input.SortBy
+	search.SortOrder = input.SortOrder
+	search.IsPinned = input.IsPinned
+	if err := s.validateSavedSearch(search); err != nil {
+		return err
+	}
+
+	search.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+	return s.searchRepo.UpdateSavedSearch(search)
+}
+
+func (s *SavedSearchService) DeleteSavedSearch(searchID uint, userID uint) error {
+	if _, err := s.getOwnedSavedSearch(searchID, userID); err != nil {
+		return err
+	}
+	return s.searchRepo.DeleteSavedSearch(searchID)
+}
+
+// RunSavedSearch ประเมินเงื่อนไขของการค้นหาที่บันทึกไว้ใหม่ทุกครั้ง จึงได้รายการโน้ตล่าสุดเสมอ
+func (s *SavedSearchService) RunSavedSearch(searchID uint, userID uint) ([]entities.Note, error) {
+	search, err := s.getOwnedSavedSearch(searchID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.noteRepo.SearchNotes(userID, search.Filter, search.SortBy, search.SortOrder)
+}
+
+func (s *SavedSearchService) GetSidebar(userID uint) (*Sidebar, error) {
+	notebooks, err := s.notebookUseCase.GetNotebooks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagUseCase.GetTagTree(userID, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	searches, err := s.searchRepo.GetSavedSearchesByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sidebar{Notebooks: notebooks, Tags: tags, SavedSearches: searches}, nil
+}
@@ -3,16 +3,32 @@ package service
 import (
 	"miw/entities"
 	"miw/usecases/repository"
+	"miw/utils"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 type TagUseCase interface {
 	CreateTag(tag *entities.Tag) error
 	GetTagById(tagID, userID uint) (*entities.Tag, error)
+	GetTagsByUser(userID uint, sortBy string) ([]entities.Tag, error)
+	GetTagTree(userID uint, sortBy string) ([]*TagTreeNode, error)
+	MoveTag(tagID, userID uint, parentTagID *uint) error
+	UpdateTagAppearance(tagID, userID uint, color *string, icon *string) error
+	MergeTags(userID uint, targetTagID uint, sourceTagIDs []uint) error
+	RenameTagsByPrefix(userID uint, oldPrefix string, newPrefix string) (int, error)
 	UpdateTagName(tagID, userID uint, newName string) error
 	DeleteTag(tagID, userID uint) error
 }
 
+// TagTreeNode แท็กหนึ่งตัวพร้อมแท็กลูก Path คือชื่อเต็มตามลำดับชั้น เช่น "work/clients/acme"
+type TagTreeNode struct {
+	Tag      entities.Tag
+	Path     string
+	Children []*TagTreeNode
+}
+
 type TagService struct {
 	repo repository.TagRepository
 }
@@ -23,6 +39,19 @@ func NewTagService(repo repository.TagRepository) *TagService {
 
 // CreateTag: สร้าง Tag พร้อมตรวจสอบว่า User เป็นเจ้าของ
 func (s *TagService) CreateTag(tag *entities.Tag) error {
+	tag.Color = utils.NormalizeColor(tag.Color)
+	validation := &utils.ValidationError{}
+	validation.ValidateColor("color", tag.Color)
+	if err := validation.Err(); err != nil {
+		return err
+	}
+
+	// ถ้าระบุแท็กแม่ ต้องเป็นแท็กของ User คนเดียวกัน
+	if tag.ParentTagID != nil {
+		if _, err := s.GetTagById(*tag.ParentTagID, tag.UserID); err != nil {
+			return fmt.Errorf("parent tag not found or does not belong to this user")
+		}
+	}
 	return s.repo.CreateTag(tag)
 }
 
@@ -41,6 +70,103 @@ func (s *TagService) GetTagById(tagID, userID uint) (*entities.Tag, error) {
 	return tag, nil
 }
 
+// GetTagsByUser: ดึง Tag ทั้งหมดของ User พร้อมจำนวนโน้ต เรียงตามชื่อ ("name") หรือจำนวนการใช้งาน ("usage")
+func (s *TagService) GetTagsByUser(userID uint, sortBy string) ([]entities.Tag, error) {
+	tags, err := s.repo.GetTagsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch sortBy {
+	case "", "name":
+		sort.SliceStable(tags, func(i, j int) bool {
+			return strings.ToLower(tags[i].TagName) < strings.ToLower(tags[j].TagName)
+		})
+	case "usage":
+		sort.SliceStable(tags, func(i, j int) bool {
+			if tags[i].NoteCount != tags[j].NoteCount {
+				return tags[i].NoteCount > tags[j].NoteCount
+			}
+			return strings.ToLower(tags[i].TagName) < strings.ToLower(tags[j].TagName)
+		})
+	default:
+		return nil, fmt.Errorf("invalid sort option")
+	}
+
+	return tags, nil
+}
+
+// GetTagTree: ดึง Tag ทั้งหมดในรูปแบบต้นไม้ แท็กพี่น้องเรียงตาม sortBy เหมือน GetTagsByUser
+func (s *TagService) GetTagTree(userID uint, sortBy string) ([]*TagTreeNode, error) {
+	tags, err := s.GetTagsByUser(userID, sortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*TagTreeNode, len(tags))
+	for _, tag := range tags {
+		nodes[tag.TagID] = &TagTreeNode{Tag: tag}
+	}
+
+	// tags เรียงไว้แล้ว การ append ตามลำดับจึงทำให้ลูกของแต่ละโหนดเรียงตามไปด้วย
+	roots := []*TagTreeNode{}
+	for _, tag := range tags {
+		node := nodes[tag.TagID]
+		if tag.ParentTagID != nil {
+			if parent, ok := nodes[*tag.ParentTagID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	var setPath func(node *TagTreeNode, prefix string)
+	setPath = func(node *TagTreeNode, prefix string) {
+		node.Path = prefix + node.Tag.TagName
+		for _, child := range node.Children {
+			setPath(child, node.Path+"/")
+		}
+	}
+	for _, root := range roots {
+		setPath(root, "")
+	}
+
+	return roots, nil
+}
+
+// MoveTag: ย้าย Tag ไปอยู่ใต้แท็กแม่ใหม่ (nil = ระดับบนสุด) โดยป้องกันการเกิดวงวน
+func (s *TagService) MoveTag(tagID, userID uint, parentTagID *uint) error {
+	if _, err := s.GetTagById(tagID, userID); err != nil {
+		return err
+	}
+
+	if parentTagID != nil {
+		tags, err := s.repo.GetTagsByUser(userID)
+		if err != nil {
+			return err
+		}
+		parents := make(map[uint]*uint, len(tags))
+		for _, tag := range tags {
+			parents[tag.TagID] = tag.ParentTagID
+		}
+		if _, ok := parents[*parentTagID]; !ok {
+			return fmt.Errorf("parent tag not found or does not belong to this user")
+		}
+
+		// ไล่ขึ้นจากแท็กแม่ใหม่ไปจนถึงราก ถ้าเจอแท็กที่กำลังย้ายแสดงว่าจะเกิดวงวน
+		visited := make(map[uint]bool)
+		for current := parentTagID; current != nil && !visited[*current]; current = parents[*current] {
+			if *current == tagID {
+				return fmt.Errorf("cannot move a tag under itself or its descendants")
+			}
+			visited[*current] = true
+		}
+	}
+
+	return s.repo.UpdateTagParent(tagID, userID, parentTagID)
+}
+
 // UpdateTagName: แก้ไขชื่อ Tag โดยต้องเป็นเจ้าของเท่านั้น
 func (s *TagService) UpdateTagName(tagID, userID uint, newName string) error {
 	// ตรวจสอบว่าผู้ใช้เป็นเจ้าของแท็กก่อนอัปเดต
@@ -62,3 +188,88 @@ func (s *TagService) DeleteTag(tagID, userID uint) error {
 
 	return s.repo.DeleteTag(tag.TagID, userID)
 }
+
+// UpdateTagAppearance: แก้ไขสีและไอคอนของ Tag
+func (s *TagService) UpdateTagAppearance(tagID, userID uint, color *string, icon *string) error {
+	if color != nil {
+		normalized := utils.NormalizeColor(*color)
+		validation := &utils.ValidationError{}
+		validation.ValidateColor("color", normalized)
+		if err := validation.Err(); err != nil {
+			return err
+		}
+		color = &normalized
+	}
+	return s.repo.UpdateTagAppearance(tagID, userID, color, icon)
+}
+
+// MergeTags: รวมแท็กต้นทางหลายตัวเข้าเป็นแท็กปลายทาง เช่น "meetings" -> "meeting"
+func (s *TagService) MergeTags(userID uint, targetTagID uint, sourceTagIDs []uint) error {
+	if len(sourceTagIDs) == 0 {
+		return fmt.Errorf("source tags are required")
+	}
+
+	seen := make(map[uint]bool)
+	unique := make([]uint, 0, len(sourceTagIDs))
+	for _, id := range sourceTagIDs {
+		if id == targetTagID {
+			return fmt.Errorf("target tag cannot be one of the source tags")
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return s.repo.MergeTags(userID, targetTagID, unique)
+}
+
+// RenameTagsByPrefix: เปลี่ยน prefix ของชื่อแท็กทั้งหมด เช่น "meeting/" -> "meetings/"
+// คืนจำนวนแท็กที่ถูกเปลี่ยนชื่อ
+func (s *TagService) RenameTagsByPrefix(userID uint, oldPrefix string, newPrefix string) (int, error) {
+	if oldPrefix == "" {
+		return 0, fmt.Errorf("old prefix is required")
+	}
+
+	tags, err := s.repo.GetTagsByUser(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	newNames := make(map[uint]string)
+	for _, tag := range tags {
+		if strings.HasPrefix(tag.TagName, oldPrefix) {
+			newNames[tag.TagID] = newPrefix + strings.TrimPrefix(tag.TagName, oldPrefix)
+		}
+	}
+	if len(newNames) == 0 {
+		return 0, nil
+	}
+
+	// ตรวจสอบว่าชื่อใหม่ไม่ชนกับแท็กอื่นที่ไม่ได้ถูกเปลี่ยนชื่อ และไม่ชนกันเอง
+	taken := make(map[string]bool)
+	for _, tag := range tags {
+		if _, renamed := newNames[tag.TagID]; !renamed {
+			taken[tag.TagName] = true
+		}
+	}
+	var conflicts []string
+	for _, name := range newNames {
+		if name == "" {
+			return 0, fmt.Errorf("tag name cannot be empty")
+		}
+		if taken[name] {
+			conflicts = append(conflicts, name)
+		}
+		taken[name] = true
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return 0, fmt.Errorf("tag name already exists: %s", strings.Join(conflicts, ", "))
+	}
+
+	if err := s.repo.RenameTags(userID, newNames); err != nil {
+		return 0, err
+	}
+	return len(newNames), nil
+}
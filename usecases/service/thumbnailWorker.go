@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"miw/utils"
+)
+
+// ThumbnailQueue คิวงานสร้างรูปย่อ AttachmentService ใช้ส่งไฟล์แนบที่อัปโหลดใหม่เข้าคิว
+type ThumbnailQueue interface {
+	Enqueue(attachmentID uint)
+}
+
+// ThumbnailWorker สร้างรูปย่อของไฟล์แนบรูปภาพแบบ background ด้วย goroutine หลายตัว
+type ThumbnailWorker struct {
+	attachmentRepo repository.AttachmentRepository
+	blobStore      repository.BlobStore
+	workers        int
+	jobs           chan uint
+}
+
+func NewThumbnailWorker(attachmentRepo repository.AttachmentRepository, blobStore repository.BlobStore, workers int) *ThumbnailWorker {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &ThumbnailWorker{
+		attachmentRepo: attachmentRepo,
+		blobStore:      blobStore,
+		workers:        workers,
+		jobs:           make(chan uint, 256),
+	}
+}
+
+// Start เริ่ม worker และนำไฟล์แนบที่ยังค้างสถานะ pending (เช่น ตอนเซิร์ฟเวอร์ปิด) กลับเข้าคิว
+func (w *ThumbnailWorker) Start() {
+	for i := 0; i < w.workers; i++ {
+		go func() {
+			for attachmentID := range w.jobs {
+				w.process(attachmentID)
+			}
+		}()
+	}
+
+	pending, err := w.attachmentRepo.GetPendingThumbnails()
+	if err != nil {
+		log.Printf("Failed to load pending thumbnails: %v", err)
+		return
+	}
+	go func() {
+		for _, attachment := range pending {
+			w.jobs <- attachment.AttachmentID
+		}
+	}()
+}
+
+// Enqueue ส่งไฟล์แนบเข้าคิวโดยไม่บล็อก request ถ้าคิวเต็มไฟล์จะยังเป็น pending และถูกสร้างตอนเริ่มเซิร์ฟเวอร์ครั้งถัดไป
+func (w *ThumbnailWorker) Enqueue(attachmentID uint) {
+	select {
+	case w.jobs <- attachmentID:
+	default:
+		log.Printf("Thumbnail queue is full, attachment %d left pending", attachmentID)
+	}
+}
+
+func (w *ThumbnailWorker) process(attachmentID uint) {
+	attachment, err := w.attachmentRepo.GetAttachmentById(attachmentID)
+	if err != nil {
+		// ไฟล์แนบถูกลบไปก่อนถึงคิว
+		return
+	}
+	if attachment.ThumbnailStatus != entities.ThumbnailStatusPending {
+		return
+	}
+
+	thumbnails, err := w.generate(attachment)
+	status := entities.ThumbnailStatusReady
+	if err != nil {
+		log.Printf("Failed to generate thumbnails for attachment %d: %v", attachmentID, err)
+		status = entities.ThumbnailStatusFailed
+	}
+
+	if err := w.attachmentRepo.UpdateThumbnails(attachmentID, status, thumbnails); err != nil {
+		// ไฟล์แนบถูกลบระหว่างสร้างรูปย่อ ต้องลบรูปย่อที่เพิ่งเก็บเองเพราะไม่มีข้อมูลชี้ถึงแล้ว
+		log.Printf("Failed to save thumbnails for attachment %d: %v", attachmentID, err)
+		for _, thumbnail := range thumbnails {
+			if err := w.blobStore.Delete(attachment.ThumbnailKey(thumbnail.Size)); err != nil {
+				log.Printf("Failed to delete blob %s: %v", attachment.ThumbnailKey(thumbnail.Size), err)
+			}
+		}
+	}
+}
+
+// generate สร้างรูปย่อทุกขนาดใน entities.ThumbnailSizes และเก็บลง BlobStore
+func (w *ThumbnailWorker) generate(attachment *entities.Attachment) ([]entities.AttachmentThumbnail, error) {
+	reader, err := w.blobStore.Get(attachment.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return nil, err
+	}
+	src, err := utils.DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var thumbnails []entities.AttachmentThumbnail
+	for _, size := range entities.ThumbnailSizes {
+		body, contentType, err := utils.MakeThumbnail(src, size.MaxSide, attachment.ContentType)
+		if err == nil {
+			err = w.blobStore.Put(attachment.ThumbnailKey(size.Name), bytes.NewReader(body), int64(len(body)), contentType)
+		}
+		if err != nil {
+			// ลบรูปย่อขนาดที่สร้างไปแล้วเพื่อไม่ให้เหลือไฟล์กำพร้า
+			for _, thumbnail := range thumbnails {
+				w.blobStore.Delete(attachment.ThumbnailKey(thumbnail.Size))
+			}
+			return nil, err
+		}
+
+		width, height, _ := utils.ImageDimensions(body)
+		thumbnails = append(thumbnails, entities.AttachmentThumbnail{
+			Size:        size.Name,
+			Width:       width,
+			Height:      height,
+			ContentType: contentType,
+		})
+	}
+	return thumbnails, nil
+}
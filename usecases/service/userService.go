@@ -2,9 +2,14 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"miw/entities"
 	"miw/usecases/repository"
+	"miw/utils"
+	"net/mail"
 	"os"
+	"strings"
 	"time"
 	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
@@ -18,6 +23,10 @@ type UserUseCase interface {
 	SendResetPasswordEmail(email string) error
 	ResetPassword(token string, newPassword string) error
 	GetUser(userID uint) (*entities.User, error)
+	GetUserStats(userID uint) (*entities.UserStats, error)
+	ChangePassword(userID uint, currentPassword string, newPassword string) (string, error)
+	RequestEmailChange(userID uint, newEmail string, password string) error
+	ConfirmEmailChange(token string) error
 }
 
 type UserService struct {
@@ -56,15 +65,16 @@ func (s *UserService) Login(email, password string) (string, error) {
 		return "", errors.New("invalid credentials")
 	}
 
-	return s.generateToken(user.UserID)
+	return s.generateToken(user)
 }
 
 // Generate JWT token for user
-func (s *UserService) generateToken(userID uint) (string, error) {
+func (s *UserService) generateToken(user *entities.User) (string, error) {
 	jwtSecret := os.Getenv("JWT_SECRET")
 	token := jwt.New(jwt.SigningMethodHS256)
 	claims := token.Claims.(jwt.MapClaims)
-	claims["user_id"] = userID
+	claims["user_id"] = user.UserID
+	claims["ver"] = user.TokenVersion // ใช้เพิกถอน session เก่าเมื่อเปลี่ยนรหัสผ่าน
 	claims["exp"] = time.Now().Add(time.Hour * 72).Unix()
 
 	return token.SignedString([]byte(jwtSecret))
@@ -77,15 +87,28 @@ func (s *UserService) SendResetPasswordEmail(email string) error {
 		return errors.New("user not found")
 	}
 
-	resetToken, err := s.generateToken(user.UserID)
+	resetToken, err := generateResetPasswordToken(user)
 	if err != nil {
 		return err
 	}
 
-	resetURL := "http://localhost:8000/reset-password?token=" + resetToken
+	resetURL := appBaseURL() + "/reset-password?token=" + resetToken
 	return s.sendEmail(user.Email, resetURL)
 }
 
+// generateResetPasswordToken token สำหรับรีเซ็ตรหัสผ่านเท่านั้น ผูกกับ TokenVersion ปัจจุบัน
+// จึงใช้ได้ครั้งเดียวและใช้ไม่ได้หลังเปลี่ยนรหัสผ่าน
+func generateResetPasswordToken(user *entities.User) (string, error) {
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["purpose"] = "reset_password"
+	claims["user_id"] = user.UserID
+	claims["ver"] = user.TokenVersion
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
 func (s *UserService) sendEmail(email, resetURL string) error {
 	mailer := gomail.NewMessage()
 	mailer.SetHeader("From", "your-email@example.com")
@@ -119,7 +142,7 @@ func (s *UserService) ResetPassword(tokenString string, newPassword string) erro
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || claims["user_id"] == nil {
+	if !ok || claims["user_id"] == nil || claims["purpose"] != "reset_password" {
 		return errors.New("invalid token data")
 	}
 
@@ -136,14 +159,21 @@ func (s *UserService) ResetPassword(tokenString string, newPassword string) erro
 		return errors.New("user not found")
 	}
 
+	// token ที่ออกก่อนเปลี่ยนรหัสผ่านหรือถูกใช้ไปแล้วจะมี ver ไม่ตรงกับปัจจุบัน
+	tokenVersion, ok := claims["ver"].(float64)
+	if !ok || int(tokenVersion) != user.TokenVersion {
+		return errors.New("invalid or expired token")
+	}
+
 	// Hash the new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
-	// Update user's password
+	// Update user's password และเพิกถอน session ทั้งหมดรวมถึงลิงก์รีเซ็ตนี้
 	user.Password = string(hashedPassword)
+	user.TokenVersion++
 	return s.repo.UpdateUser(user)
 }
 
@@ -151,3 +181,140 @@ func (s *UserService) GetUser(userID uint) (*entities.User, error) {
 	return s.repo.GetUserById(userID)
 }
 
+func (s *UserService) GetUserStats(userID uint) (*entities.UserStats, error) {
+	return s.repo.GetUserStats(userID)
+}
+
+// ChangePassword เปลี่ยนรหัสผ่านโดยต้องยืนยันรหัสผ่านปัจจุบัน
+// และเพิ่ม TokenVersion เพื่อเพิกถอน session อื่นทั้งหมด คืน token ใหม่สำหรับ session ปัจจุบัน
+func (s *UserService) ChangePassword(userID uint, currentPassword string, newPassword string) (string, error) {
+	user, err := s.repo.GetUserById(userID)
+	if err != nil {
+		return "", errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return "", errors.New("invalid credentials")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	user.Password = string(hashedPassword)
+	user.TokenVersion++
+	if err := s.repo.UpdateUser(user); err != nil {
+		return "", err
+	}
+
+	return s.generateToken(user)
+}
+
+// generateChangeEmailToken สร้าง token ยืนยันอีเมลใหม่ (อายุ 24 ชั่วโมง)
+// ผูกกับอีเมลและ TokenVersion ปัจจุบัน ลิงก์เก่าจึงใช้ไม่ได้หลังเปลี่ยนอีเมล เปลี่ยน/รีเซ็ตรหัสผ่าน หรือยืนยันไปแล้ว
+func generateChangeEmailToken(user *entities.User, newEmail string) (string, error) {
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["purpose"] = "change_email"
+	claims["user_id"] = user.UserID
+	claims["new_email"] = newEmail
+	claims["email"] = user.Email
+	claims["ver"] = user.TokenVersion
+	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
+
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+// RequestEmailChange ส่งลิงก์ยืนยันไปยังอีเมลใหม่ และแจ้งเตือนไปยังอีเมลเดิม
+// Email จะถูกเปลี่ยนหลังจากกดยืนยันลิงก์แล้วเท่านั้น
+func (s *UserService) RequestEmailChange(userID uint, newEmail string, password string) error {
+	user, err := s.repo.GetUserById(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return errors.New("invalid credentials")
+	}
+
+	if _, err := mail.ParseAddress(newEmail); err != nil {
+		return errors.New("invalid email")
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		return errors.New("new email is the same as current email")
+	}
+	if _, err := s.repo.GetUserByEmail(newEmail); err == nil {
+		return errors.New("email already in use")
+	}
+
+	confirmToken, err := generateChangeEmailToken(user, newEmail)
+	if err != nil {
+		return err
+	}
+
+	confirmURL := appBaseURL() + "/confirm-email?token=" + confirmToken
+	if err := utils.SendEmail(newEmail, "Confirm your new email address",
+		"Click here to confirm your new email address: "+confirmURL); err != nil {
+		return fmt.Errorf("failed to send confirmation email: %v", err)
+	}
+
+	// แจ้งเจ้าของอีเมลเดิมว่ามีการขอเปลี่ยนอีเมล
+	if err := utils.SendEmail(user.Email, "Email change requested",
+		fmt.Sprintf("A request was made to change the email of your account to %s.\nIf this was not you, please change your password immediately.", newEmail)); err != nil {
+		log.Printf("Failed to send email change notice to %s: %v", user.Email, err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange ตรวจสอบลิงก์ยืนยันและเปลี่ยน Email ของผู้ใช้
+func (s *UserService) ConfirmEmailChange(tokenString string) error {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil || !token.Valid {
+		return errors.New("invalid or expired token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != "change_email" {
+		return errors.New("invalid token data")
+	}
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return errors.New("invalid token data")
+	}
+	newEmail, ok := claims["new_email"].(string)
+	if !ok || newEmail == "" {
+		return errors.New("invalid token data")
+	}
+
+	user, err := s.repo.GetUserById(uint(userIDFloat))
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	tokenVersion, ok := claims["ver"].(float64)
+	if !ok || int(tokenVersion) != user.TokenVersion || claims["email"] != user.Email {
+		return errors.New("invalid or expired token")
+	}
+
+	// ตรวจสอบอีกครั้ง เผื่อมีผู้ใช้อื่นใช้อีเมลนี้ระหว่างรอยืนยัน
+	if existing, err := s.repo.GetUserByEmail(newEmail); err == nil && existing.UserID != user.UserID {
+		return errors.New("email already in use")
+	}
+
+	// เพิกถอน session ทั้งหมดและทำให้ลิงก์นี้ใช้ได้ครั้งเดียว
+	user.Email = newEmail
+	user.TokenVersion++
+	return s.repo.UpdateUser(user)
+}
+
+// appBaseURL URL หลักของ API ที่ใช้สร้างลิงก์ในอีเมล
+func appBaseURL() string {
+	if baseURL := os.Getenv("APP_BASE_URL"); baseURL != "" {
+		return strings.TrimRight(baseURL, "/")
+	}
+	return "http://localhost:8000"
+}
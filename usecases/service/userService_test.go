@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeUserRepo เก็บผู้ใช้ในหน่วยความจำ (เฉพาะเมธอดที่ใช้ในเทสต์)
+type fakeUserRepo struct {
+	repository.UserRepository
+	users map[uint]*entities.User
+}
+
+func (r *fakeUserRepo) GetUserById(userID uint) (*entities.User, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) GetUserByEmail(email string) (*entities.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) CreateUser(user *entities.User) error {
+	user.UserID = uint(len(r.users) + 1)
+	copied := *user
+	r.users[user.UserID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepo) GetUserData(userID uint) (*entities.UserData, error) {
+	user, err := r.GetUserById(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.UserData{User: *user}, nil
+}
+
+func (r *fakeUserRepo) DeleteUser(userID uint) error {
+	delete(r.users, userID)
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateUser(user *entities.User) error {
+	copied := *user
+	r.users[user.UserID] = &copied
+	return nil
+}
+
+func TestResetPasswordToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	repo := &fakeUserRepo{users: map[uint]*entities.User{1: {UserID: 1, Email: "a@example.com", TokenVersion: 3}}}
+	s := NewUserService(repo)
+
+	sessionToken, err := s.generateToken(repo.users[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResetPassword(sessionToken, "new-password"); err == nil {
+		t.Fatal("a session token must not reset the password")
+	}
+
+	resetToken, err := generateResetPasswordToken(repo.users[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResetPassword(resetToken, "new-password"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.users[1].TokenVersion != 4 {
+		t.Fatalf("TokenVersion = %d, want 4", repo.users[1].TokenVersion)
+	}
+	if err := s.ResetPassword(resetToken, "another-password"); err == nil {
+		t.Fatal("a reset token must work only once")
+	}
+
+	// token ที่ออกก่อนเปลี่ยนรหัสผ่านใช้ไม่ได้
+	staleToken, _ := generateResetPasswordToken(&entities.User{UserID: 1, TokenVersion: 3})
+	if err := s.ResetPassword(staleToken, "another-password"); err == nil {
+		t.Fatal("a reset token issued before a password change must be rejected")
+	}
+}
+
+func TestConfirmEmailChangeToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	repo := &fakeUserRepo{users: map[uint]*entities.User{1: {UserID: 1, Email: "a@example.com", TokenVersion: 3}}}
+	s := NewUserService(repo)
+
+	first, _ := generateChangeEmailToken(repo.users[1], "b@example.com")
+	second, _ := generateChangeEmailToken(repo.users[1], "c@example.com")
+	if err := s.ConfirmEmailChange(first); err != nil {
+		t.Fatal(err)
+	}
+	if repo.users[1].Email != "b@example.com" || repo.users[1].TokenVersion != 4 {
+		t.Fatalf("user = %+v, want b@example.com with TokenVersion 4", repo.users[1])
+	}
+
+	// ลิงก์เดิมใช้ซ้ำไม่ได้ และลิงก์ที่ค้างอยู่ย้อนการเปลี่ยนอีเมลไม่ได้
+	for name, token := range map[string]string{"replayed": first, "older pending": second} {
+		if err := s.ConfirmEmailChange(token); err == nil || err.Error() != "invalid or expired token" {
+			t.Fatalf("%s link error = %v", name, err)
+		}
+	}
+	if repo.users[1].Email != "b@example.com" {
+		t.Fatalf("email = %s, want b@example.com", repo.users[1].Email)
+	}
+
+	// ลิงก์ที่ออกก่อนรีเซ็ตรหัสผ่านใช้ไม่ได้
+	pending, _ := generateChangeEmailToken(repo.users[1], "d@example.com")
+	resetToken, _ := generateResetPasswordToken(repo.users[1])
+	if err := s.ResetPassword(resetToken, "new-password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ConfirmEmailChange(pending); err == nil {
+		t.Fatal("an email change link must not survive a password reset")
+	}
+}
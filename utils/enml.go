@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	enmlSpacePattern     = regexp.MustCompile(`[ \t\r\n\f]+`)
+	enmlBlankLinePattern = regexp.MustCompile(`\n{3,}`)
+	// parser ของ HTML ไม่รู้จัก <en-todo/> แบบปิดในตัว จึงต้องเปลี่ยนเป็นแท็กเปิด-ปิดก่อน ไม่เช่นนั้นข้อความถัดไปจะกลายเป็นลูกของมัน
+	enmlSelfClosingPattern = regexp.MustCompile(`<(en-todo|en-media)\b([^>]*?)\s*/>`)
+)
+
+// ENMLToMarkdown แปลงเนื้อหา ENML ของ Evernote (XHTML ใน <en-note>) เป็น Markdown
+// checkbox <en-todo> เป็น task list "- [ ]"/"- [x]" ส่วน <en-media> (ไฟล์แนบ) ถูกตัดออกเพราะนำเข้าเป็นไฟล์แนบแยก
+func ENMLToMarkdown(enml string) (string, error) {
+	root, err := html.Parse(strings.NewReader(enmlSelfClosingPattern.ReplaceAllString(enml, "<$1$2></$1>")))
+	if err != nil {
+		return "", fmt.Errorf("invalid ENML: %v", err)
+	}
+	w := &enmlWriter{}
+	w.walk(root)
+
+	lines := strings.Split(w.buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	text := enmlBlankLinePattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.Trim(text, "\n"), nil
+}
+
+type enmlList struct {
+	ordered bool
+	index   int
+	indent  string // ช่องว่างหน้า marker ของรายการ
+	// itemIndent ช่องว่างหน้ารายการย่อยของรายการปัจจุบัน ต้องยาวเท่า marker ของรายการแม่ ("- " หรือ "10. ") ไม่เช่นนั้นจะไม่ซ้อนกัน
+	itemIndent string
+}
+
+type enmlWriter struct {
+	buf   strings.Builder
+	lists []enmlList
+	pre   int
+	// itemStart เป็น true หลังเขียน "- " ของ <li> เพื่อให้ <en-todo> ต่อท้ายได้โดยไม่ขึ้นรายการใหม่
+	itemStart bool
+}
+
+func (w *enmlWriter) atLineStart() bool {
+	text := w.buf.String()
+	return text == "" || strings.HasSuffix(text, "\n")
+}
+
+func (w *enmlWriter) newline() {
+	if !w.atLineStart() {
+		w.buf.WriteString("\n")
+	}
+}
+
+func (w *enmlWriter) blankLine() {
+	w.newline()
+	if text := w.buf.String(); text != "" && !strings.HasSuffix(text, "\n\n") {
+		w.buf.WriteString("\n")
+	}
+}
+
+func (w *enmlWriter) write(text string) {
+	if text == "" {
+		return
+	}
+	w.buf.WriteString(text)
+	w.itemStart = false
+}
+
+func (w *enmlWriter) walkChildren(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		w.walk(child)
+	}
+}
+
+// inline เขียนเนื้อหาของ element แบบ inline ครอบด้วย marker เช่น ** หรือ _ (ไม่ครอบถ้าเนื้อหาว่าง)
+func (w *enmlWriter) inline(n *html.Node, marker string) {
+	inner := &enmlWriter{lists: w.lists, pre: w.pre}
+	inner.walkChildren(n)
+	text := inner.buf.String()
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		w.write(text)
+		return
+	}
+	// ช่องว่างต้องอยู่นอก marker ไม่เช่นนั้น Markdown จะไม่ถือว่าเป็นตัวหนา/ตัวเอียง
+	if strings.HasPrefix(text, " ") {
+		w.write(" ")
+	}
+	w.write(marker + trimmed + marker)
+	if strings.HasSuffix(text, " ") {
+		w.write(" ")
+	}
+}
+
+func (w *enmlWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		w.walkChildren(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Title:
+		return
+	case atom.Br:
+		w.buf.WriteString("\n")
+		w.itemStart = false
+	case atom.Div, atom.Tr:
+		w.newline()
+		w.walkChildren(n)
+		w.newline()
+	case atom.P, atom.Blockquote, atom.Table:
+		w.blankLine()
+		w.walkChildren(n)
+		w.blankLine()
+	case atom.Td, atom.Th:
+		if !w.atLineStart() {
+			w.write(" | ")
+		}
+		w.walkChildren(n)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.blankLine()
+		w.write(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		w.walkChildren(n)
+		w.blankLine()
+	case atom.Hr:
+		w.blankLine()
+		w.write("---")
+		w.blankLine()
+	case atom.Pre:
+		w.blankLine()
+		w.write("```\n")
+		w.pre++
+		w.walkChildren(n)
+		w.pre--
+		w.newline()
+		w.write("```")
+		w.blankLine()
+	case atom.Ul, atom.Ol:
+		indent := ""
+		if len(w.lists) == 0 {
+			w.blankLine()
+		} else {
+			w.newline()
+			indent = w.lists[len(w.lists)-1].itemIndent
+		}
+		w.lists = append(w.lists, enmlList{ordered: n.DataAtom == atom.Ol, indent: indent})
+		w.walkChildren(n)
+		w.lists = w.lists[:len(w.lists)-1]
+		if len(w.lists) == 0 {
+			w.blankLine()
+		}
+	case atom.Li:
+		w.newline()
+		marker := "- "
+		if len(w.lists) > 0 {
+			list := &w.lists[len(w.lists)-1]
+			list.index++
+			if list.ordered {
+				marker = fmt.Sprintf("%d. ", list.index)
+			}
+			w.write(list.indent)
+			list.itemIndent = list.indent + strings.Repeat(" ", len(marker))
+		}
+		w.write(marker)
+		w.itemStart = true
+		w.walkChildren(n)
+		w.newline()
+	case atom.B, atom.Strong:
+		w.inline(n, "**")
+	case atom.I, atom.Em:
+		w.inline(n, "_")
+	case atom.S, atom.Strike, atom.Del:
+		w.inline(n, "~~")
+	case atom.Code:
+		if w.pre > 0 {
+			w.walkChildren(n)
+		} else {
+			w.inline(n, "`")
+		}
+	case atom.A:
+		w.link(n)
+	case atom.Img:
+		if src := enmlAttr(n, "src"); strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+			w.write(fmt.Sprintf("![%s](%s)", enmlAttr(n, "alt"), src))
+		}
+	default:
+		switch n.Data {
+		case "en-todo":
+			w.todo(enmlAttr(n, "checked") == "true")
+			w.walkChildren(n)
+		case "en-media":
+			// ไฟล์แนบถูกนำเข้าแยกจาก <resource> ของโน้ต
+		case "en-crypt":
+			w.write("[encrypted content]")
+		default:
+			w.walkChildren(n)
+		}
+	}
+}
+
+func (w *enmlWriter) text(data string) {
+	if w.pre > 0 {
+		w.write(data)
+		return
+	}
+	data = strings.ReplaceAll(data, "\u00a0", " ")
+	data = enmlSpacePattern.ReplaceAllString(data, " ")
+	if w.atLineStart() || w.itemStart {
+		data = strings.TrimLeft(data, " ")
+	}
+	w.write(data)
+}
+
+func (w *enmlWriter) link(n *html.Node) {
+	href := enmlAttr(n, "href")
+	inner := &enmlWriter{lists: w.lists}
+	inner.walkChildren(n)
+	label := strings.TrimSpace(inner.buf.String())
+	switch {
+	case href == "":
+		w.write(label)
+	case label == "" || label == href:
+		w.write(href)
+	default:
+		w.write(fmt.Sprintf("[%s](%s)", label, href))
+	}
+}
+
+// todo เขียน checkbox เป็น task list ถ้าอยู่ใน <li> จะใช้ "- " ของรายการนั้นแทนการขึ้นรายการใหม่
+func (w *enmlWriter) todo(checked bool) {
+	mark := "[ ] "
+	if checked {
+		mark = "[x] "
+	}
+	if !w.itemStart {
+		w.newline()
+		mark = "- " + mark
+	}
+	w.write(mark)
+	w.itemStart = true
+}
+
+func enmlAttr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package utils
+
+import "testing"
+
+func TestENMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		enml string
+		want string
+	}{
+		{
+			name: "document with doctype and entities",
+			enml: `<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">` +
+				`<en-note><p>Hello&nbsp;<i> world </i></p><p>สวัสดี <b>ครับ</b></p></en-note>`,
+			want: "Hello _world_\n\nสวัสดี **ครับ**",
+		},
+		{
+			name: "nested bullet list",
+			enml: `<en-note><ul><li>one<ul><li>nested <b>bold</b></li><li>two</li></ul></li><li>three</li></ul></en-note>`,
+			want: "- one\n  - nested **bold**\n  - two\n- three",
+		},
+		{
+			// รายการย่อยต้องเยื้องเท่าความยาวของ "2. " จึงจะซ้อนอยู่ใต้รายการแม่
+			name: "nested ordered list",
+			enml: `<en-note><ol><li>a</li><li>b<ol><li>b1</li><li>b2<ul><li>deep</li></ul></li></ol></li></ol></en-note>`,
+			want: "1. a\n2. b\n   1. b1\n   2. b2\n      - deep",
+		},
+		{
+			name: "en-todo inside list items",
+			enml: `<en-note><ul><li><en-todo checked="true"/>milk</li><li><en-todo/> eggs</li></ul></en-note>`,
+			want: "- [x] milk\n- [ ] eggs",
+		},
+		{
+			name: "en-todo in divs",
+			enml: `<en-note><div><en-todo checked="true"/>milk</div><div><en-todo checked="false"/>eggs</div></en-note>`,
+			want: "- [x] milk\n- [ ] eggs",
+		},
+		{
+			name: "en-todo after text",
+			enml: `<en-note><div>Intro</div><div><en-todo/>task</div></en-note>`,
+			want: "Intro\n- [ ] task",
+		},
+		{
+			name: "media and encrypted content",
+			enml: `<en-note><div>before</div><en-media type="image/png" hash="abc"/><div>after</div><en-crypt cipher="AES">xyz</en-crypt></en-note>`,
+			want: "before\nafter\n[encrypted content]",
+		},
+		{
+			name: "links and images",
+			enml: `<en-note><a href="https://example.com">site</a> <a href="https://example.com/x">https://example.com/x</a> <img src="https://example.com/a.png" alt="pic"/><img src="data:image/png;base64,AAAA"/></en-note>`,
+			want: "[site](https://example.com) https://example.com/x ![pic](https://example.com/a.png)",
+		},
+		{
+			name: "headings, rules and code",
+			enml: `<en-note><h2>Title</h2><div>text <code>x := 1</code></div><hr/><pre>line 1
+  line 2</pre></en-note>`,
+			want: "## Title\n\ntext `x := 1`\n\n---\n\n```\nline 1\n  line 2\n```",
+		},
+		{
+			name: "tables",
+			enml: `<en-note><table><tr><td>a</td><td>b</td></tr><tr><td>c</td><td>d</td></tr></table></en-note>`,
+			want: "a | b\nc | d",
+		},
+		{
+			name: "whitespace and blank lines are collapsed",
+			enml: "<en-note><div>  a \n\t b  </div><div><br/></div><div><br/></div><div><br/></div><div>c</div></en-note>",
+			want: "a b\n\nc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ENMLToMarkdown(tt.enml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("ENMLToMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
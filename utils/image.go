@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // ลงทะเบียน decoder สำหรับ image.Decode
+	"image/jpeg"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// IsImageType ชนิดไฟล์รูปภาพที่สร้าง thumbnail ได้
+func IsImageType(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// ImageDimensions อ่านความกว้างและความสูงจาก header ของรูปโดยไม่ต้อง decode ทั้งรูป
+func ImageDimensions(data []byte) (int, int, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, err
+	}
+	return config.Width, config.Height, nil
+}
+
+// MaxImagePixels จำนวน pixel สูงสุดของรูปที่ยอม decode ทั้งรูป (40 ล้าน pixel ใช้หน่วยความจำราว 160 MB)
+// กันรูปขนาดไฟล์เล็กที่ประกาศขนาดภาพใหญ่มาก (decompression bomb)
+const MaxImagePixels = 40_000_000
+
+// IsImageTooLarge รูปมีจำนวน pixel เกิน MaxImagePixels
+func IsImageTooLarge(width, height int) bool {
+	return int64(width)*int64(height) > MaxImagePixels
+}
+
+// DecodeImage decode ทั้งรูปหลังจากตรวจขนาดจาก header แล้วว่าไม่เกิน MaxImagePixels
+func DecodeImage(data []byte) (image.Image, error) {
+	width, height, err := ImageDimensions(data)
+	if err != nil {
+		return nil, err
+	}
+	if IsImageTooLarge(width, height) {
+		return nil, fmt.Errorf("image is too large: maximum is %d pixels", MaxImagePixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// StripImageMetadata ลบ EXIF/XMP และ metadata ข้อความอื่น ๆ ออกจากรูป (เช่น ตำแหน่ง GPS และรุ่นกล้อง)
+// JPEG ที่มี EXIF orientation จะถูกหมุนให้ถูกทิศก่อน เพราะค่า orientation จะหายไปพร้อม EXIF
+func StripImageMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		stripped, orientation, err := stripJPEGMetadata(data)
+		if err != nil || orientation <= 1 {
+			return stripped, err
+		}
+		img, err := DecodeImage(stripped)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, applyOrientation(img, orientation), &jpeg.Options{Quality: 92}); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "image/png":
+		return stripPNGMetadata(data)
+	case "image/webp":
+		return stripWebPMetadata(data)
+	}
+	// GIF ไม่มี EXIF
+	return data, nil
+}
+
+// stripJPEGMetadata เก็บเฉพาะ segment ที่จำเป็นต่อการแสดงผล (APP0/JFIF, APP2/ICC, APP14/Adobe)
+// และคืนค่า orientation จาก EXIF (0 = ไม่มี)
+func stripJPEGMetadata(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, fmt.Errorf("invalid JPEG")
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	orientation := 0
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return nil, 0, fmt.Errorf("invalid JPEG segment")
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		// SOS: ข้อมูลภาพที่เหลือทั้งหมดคัดลอกตามเดิม
+		if marker == 0xDA {
+			out.Write(data[i:])
+			return out.Bytes(), orientation, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, 0, fmt.Errorf("invalid JPEG segment length")
+		}
+		segment := data[i:end]
+
+		switch {
+		case marker == 0xE1:
+			if o := exifOrientation(segment[4:]); o > 0 {
+				orientation = o
+			}
+		case marker == 0xFE, marker >= 0xE3 && marker <= 0xED, marker == 0xEF:
+			// comment และ APPn อื่น ๆ (IPTC, maker notes ฯลฯ)
+		default:
+			out.Write(segment)
+		}
+		i = end
+	}
+	return nil, 0, fmt.Errorf("invalid JPEG: missing image data")
+}
+
+// exifOrientation อ่าน tag 0x0112 จาก IFD0 ของ APP1 EXIF
+func exifOrientation(payload []byte) int {
+	if len(payload) < 14 || string(payload[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	tiff := payload[6:]
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for n := 0; n < count; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// applyOrientation หมุน/กลับด้านรูปตามค่า EXIF orientation (2-8)
+func applyOrientation(src image.Image, orientation int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if orientation >= 5 {
+		w, h = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			dx, dy := x, y
+			sw, sh := bounds.Dx(), bounds.Dy()
+			switch orientation {
+			case 2:
+				dx = sw - 1 - x
+			case 3:
+				dx, dy = sw-1-x, sh-1-y
+			case 4:
+				dy = sh - 1 - y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = sh-1-y, x
+			case 7:
+				dx, dy = sh-1-y, sw-1-x
+			case 8:
+				dx, dy = y, sw-1-x
+			}
+			dst.Set(dx, dy, src.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// stripPNGMetadata ลบ chunk eXIf, tEXt, zTXt, iTXt และ tIME
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if len(data) < len(signature) || string(data[:len(signature)]) != signature {
+		return nil, fmt.Errorf("invalid PNG")
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.WriteString(signature)
+	for i := len(signature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("invalid PNG chunk")
+		}
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("invalid PNG chunk length")
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+// stripWebPMetadata ลบ chunk EXIF และ XMP และล้าง flag ที่เกี่ยวข้องใน VP8X
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("invalid WebP")
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("invalid WebP chunk")
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		end := i + 8 + size + size%2 // chunk มี padding ให้ยาวเป็นเลขคู่
+		if size < 0 || end > len(data) {
+			return nil, fmt.Errorf("invalid WebP chunk length")
+		}
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			// VP8X มีข้อมูลอย่างน้อย 10 byte (flags 4 byte + ขนาด canvas 6 byte)
+			if size < 10 {
+				return nil, fmt.Errorf("invalid WebP VP8X chunk")
+			}
+			chunk := append([]byte(nil), data[i:end]...)
+			chunk[8] &^= 0x08 | 0x04 // flag EXIF และ XMP
+			out.Write(chunk)
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+
+	result := out.Bytes()
+	binary.LittleEndian.PutUint32(result[4:8], uint32(len(result)-8))
+	return result, nil
+}
+
+// MakeThumbnail ย่อรูปให้ด้านยาวสุดไม่เกิน maxSize (ไม่ขยายรูปเล็ก) คืนเป็น JPEG ถ้าต้นฉบับเป็น JPEG นอกนั้นเป็น PNG เพื่อเก็บพื้นโปร่งใส
+func MakeThumbnail(src image.Image, maxSize int, sourceType string) ([]byte, string, error) {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > maxSize || h > maxSize {
+		if w >= h {
+			w, h = maxSize, max(1, h*maxSize/w)
+		} else {
+			w, h = max(1, w*maxSize/h), maxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if sourceType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestStripWebPMetadataRejectsShortVP8X(t *testing.T) {
+	data := []byte("RIFF\x0c\x00\x00\x00WEBPVP8X\x00\x00\x00\x00")
+	if _, err := StripImageMetadata(data, "image/webp"); err == nil {
+		t.Fatal("expected an error for a VP8X chunk without flags")
+	}
+}
+
+func TestStripWebPMetadataRemovesExif(t *testing.T) {
+	chunk := func(name string, body []byte) []byte {
+		out := append([]byte(name), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(out[4:], uint32(len(body)))
+		out = append(out, body...)
+		if len(body)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 | 0x04
+	var body []byte
+	body = append(body, chunk("VP8X", vp8x)...)
+	body = append(body, chunk("EXIF", []byte("gps"))...)
+	body = append(body, chunk("XMP ", []byte("<x/>"))...)
+	data := append([]byte("RIFF\x00\x00\x00\x00WEBP"), body...)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+
+	stripped, err := StripImageMetadata(data, "image/webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stripped, []byte("EXIF")) || bytes.Contains(stripped, []byte("XMP ")) {
+		t.Fatalf("metadata chunks were not removed: %q", stripped)
+	}
+	if flags := stripped[20]; flags&(0x08|0x04) != 0 {
+		t.Fatalf("VP8X flags not cleared: %#x", flags)
+	}
+	if size := binary.LittleEndian.Uint32(stripped[4:8]); int(size) != len(stripped)-8 {
+		t.Fatalf("RIFF size = %d, want %d", size, len(stripped)-8)
+	}
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// แทรก chunk tEXt หลัง IHDR (8 + 25 byte)
+	text := []byte("\x00\x00\x00\x04tEXtabcd\x00\x00\x00\x00")
+	withText := append(append(append([]byte(nil), data[:33]...), text...), data[33:]...)
+
+	stripped, err := StripImageMetadata(withText, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stripped, data) {
+		t.Fatal("tEXt chunk was not removed")
+	}
+}
+
+func TestDecodeImageRejectsDecompressionBomb(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// ปลอมขนาดใน IHDR เป็น 60000x60000 แล้วคำนวณ CRC ใหม่
+	binary.BigEndian.PutUint32(data[16:20], 60000)
+	binary.BigEndian.PutUint32(data[20:24], 60000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, err := DecodeImage(data); err == nil || err.Error() != "image is too large: maximum is 40000000 pixels" {
+		t.Fatalf("DecodeImage error = %v", err)
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := DecodeImage(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 3 || bounds.Dy() != 2 {
+		t.Fatalf("bounds = %v", bounds)
+	}
+}
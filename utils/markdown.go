@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// รูปแบบเนื้อหาของโน้ต
+const (
+	ContentFormatPlain    = "plain"
+	ContentFormatMarkdown = "markdown"
+)
+
+// ContentFormats รูปแบบเนื้อหาที่รองรับ
+var ContentFormats = []string{ContentFormatPlain, ContentFormatMarkdown}
+
+// Markdown แบบ GFM: ตาราง task list ขีดฆ่า และแปลง URL เป็นลิงก์อัตโนมัติ
+// ไม่เปิด html.WithUnsafe ดังนั้น HTML ดิบในเนื้อหาจะถูกตัดทิ้งตั้งแต่ขั้นแปลง
+var markdownRenderer = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+)
+
+// htmlPolicy ตัด HTML ที่อาจเป็น XSS ออกหลังแปลง Markdown เช่น script, event handler และลิงก์ javascript:
+var htmlPolicy = newHTMLPolicy()
+
+func newHTMLPolicy() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	// checkbox ของ task list (goldmark สร้างเป็น disabled เสมอ)
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
+	// ระบุภาษาของ code block เช่น language-go
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9_+-]+$`)).OnElements("code")
+	// ลิงก์ภายนอกเปิดแท็บใหม่และไม่ส่ง referrer
+	policy.RequireNoReferrerOnLinks(true)
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+	return policy
+}
+
+// NormalizeContentFormat ค่าว่างถือเป็น plain
+func NormalizeContentFormat(format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		return ContentFormatPlain
+	}
+	return format
+}
+
+func IsValidContentFormat(format string) bool {
+	for _, name := range ContentFormats {
+		if format == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateContentFormat เพิ่มข้อผิดพลาดถ้ารูปแบบเนื้อหาไม่รองรับ (ต้อง normalize ก่อน)
+func (e *ValidationError) ValidateContentFormat(field, format string) {
+	if !IsValidContentFormat(format) {
+		e.Add(field, "must be one of "+strings.Join(ContentFormats, ", "))
+	}
+}
+
+// RenderMarkdown แปลง Markdown เป็น HTML ที่ผ่านการ sanitize แล้ว
+func RenderMarkdown(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return htmlPolicy.Sanitize(buf.String()), nil
+}
+
+// RenderPlainText แปลงข้อความธรรมดาเป็น HTML โดย escape ทุกอักขระและแบ่งย่อหน้าตามบรรทัดว่าง
+func RenderPlainText(text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return ""
+	}
+
+	var buf strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		buf.WriteString("<p>")
+		buf.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		buf.WriteString("</p>\n")
+	}
+	return buf.String()
+}
+
+// RenderContentHTML แปลงเนื้อหาโน้ตเป็น HTML ตามรูปแบบ ถ้าแปลง Markdown ไม่ได้จะแสดงเป็นข้อความธรรมดา
+func RenderContentHTML(content string, format string) string {
+	if NormalizeContentFormat(format) == ContentFormatMarkdown {
+		if rendered, err := RenderMarkdown(content); err == nil {
+			return rendered
+		}
+	}
+	return RenderPlainText(content)
+}
+
+var blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+
+// MarkdownToPlainText แปลง Markdown เป็นข้อความธรรมดาสำหรับที่แสดง HTML ไม่ได้ เช่น PDF
+// รายการใช้ "•" หรือเลขลำดับ task list ใช้ "[ ]"/"[x]" และตัด HTML ดิบทิ้งเหมือน RenderMarkdown
+func MarkdownToPlainText(source string) string {
+	src := []byte(source)
+	doc := markdownRenderer.Parser().Parse(text.NewReader(src))
+
+	var buf strings.Builder
+	newline := func() {
+		if buf.Len() > 0 && !strings.HasSuffix(buf.String(), "\n") {
+			buf.WriteString("\n")
+		}
+	}
+	blankLine := func() {
+		newline()
+		if buf.Len() > 0 && !strings.HasSuffix(buf.String(), "\n\n") {
+			buf.WriteString("\n")
+		}
+	}
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch node := n.(type) {
+		case *ast.Text:
+			if entering {
+				buf.Write(node.Segment.Value(src))
+				if node.SoftLineBreak() || node.HardLineBreak() {
+					buf.WriteString("\n")
+				}
+			}
+		case *ast.String:
+			if entering {
+				buf.Write(node.Value)
+			}
+		case *ast.AutoLink:
+			if entering {
+				buf.Write(node.URL(src))
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.RawHTML, *ast.HTMLBlock:
+			return ast.WalkSkipChildren, nil
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			if entering {
+				blankLine()
+				lines := n.Lines()
+				for i := 0; i < lines.Len(); i++ {
+					segment := lines.At(i)
+					buf.Write(segment.Value(src))
+				}
+				blankLine()
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.ThematicBreak:
+			if entering {
+				blankLine()
+				buf.WriteString("----")
+				blankLine()
+			}
+		case *ast.ListItem:
+			if !entering {
+				newline()
+				break
+			}
+			newline()
+			depth := 0
+			for parent := n.Parent(); parent != nil; parent = parent.Parent() {
+				if _, ok := parent.(*ast.List); ok {
+					depth++
+				}
+			}
+			buf.WriteString(strings.Repeat("  ", depth-1))
+			if list, ok := n.Parent().(*ast.List); ok && list.IsOrdered() {
+				index := list.Start
+				for sibling := n.PreviousSibling(); sibling != nil; sibling = sibling.PreviousSibling() {
+					index++
+				}
+				buf.WriteString(fmt.Sprintf("%d. ", index))
+			} else {
+				buf.WriteString("• ")
+			}
+		case *extast.TaskCheckBox:
+			if entering {
+				if node.IsChecked {
+					buf.WriteString("[x] ")
+				} else {
+					buf.WriteString("[ ] ")
+				}
+			}
+		case *extast.TableRow, *extast.TableHeader:
+			newline()
+		case *extast.TableCell:
+			if entering && n.PreviousSibling() != nil {
+				buf.WriteString(" | ")
+			}
+		case *ast.Paragraph, *ast.Heading, *ast.Blockquote, *ast.List, *extast.Table:
+			// บล็อกในรายการขึ้นบรรทัดใหม่เฉยๆ เพื่อไม่ให้เว้นบรรทัดระหว่างเครื่องหมายรายการกับข้อความ
+			if _, inItem := n.Parent().(*ast.ListItem); inItem {
+				if !entering || n.PreviousSibling() != nil {
+					newline()
+				}
+				break
+			}
+			blankLine()
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(buf.String(), "\n\n"))
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownSanitizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		contains []string
+		excludes []string
+	}{
+		{
+			// แท็ก inline ถูกตัดทิ้ง ข้อความระหว่างแท็กเหลือเป็นข้อความธรรมดา
+			name:     "script tag",
+			source:   "hello <script>alert(1)</script> world",
+			contains: []string{"<p>hello alert(1) world</p>"},
+			excludes: []string{"<script"},
+		},
+		{
+			name:     "script block",
+			source:   "<script>\nalert(1)\n</script>\n\ntext",
+			contains: []string{"<p>text</p>"},
+			excludes: []string{"<script", "alert(1)"},
+		},
+		{
+			name:     "javascript link",
+			source:   "[click](javascript:alert(1))",
+			contains: []string{"click"},
+			excludes: []string{"javascript:", "href"},
+		},
+		{
+			name:     "javascript autolink with entity",
+			source:   `<a href="jav&#x09;ascript:alert(1)">x</a> [y](JaVaScRiPt:alert(1))`,
+			excludes: []string{"ascript:", "href"},
+		},
+		{
+			name:     "data link",
+			source:   "[img](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)",
+			excludes: []string{"data:", "href"},
+		},
+		{
+			name:     "onerror attribute",
+			source:   `<img src="x" onerror="alert(1)">` + "\n\n![pic](https://example.com/a.png)",
+			contains: []string{`<img src="https://example.com/a.png" alt="pic">`},
+			excludes: []string{"onerror", "alert(1)"},
+		},
+		{
+			name:     "raw html block",
+			source:   "<div style=\"position:fixed\"><iframe src=\"https://evil.example\"></iframe></div>\n\nsafe",
+			contains: []string{"<p>safe</p>"},
+			excludes: []string{"<div", "<iframe", "style="},
+		},
+		{
+			name:     "external link",
+			source:   "[site](https://example.com)",
+			contains: []string{`href="https://example.com"`, `rel="nofollow noreferrer noopener"`, `target="_blank"`},
+		},
+		{
+			name:     "task list",
+			source:   "- [x] done\n- [ ] todo",
+			contains: []string{`<input checked="" disabled="" type="checkbox">`, `<input disabled="" type="checkbox">`, "done", "todo"},
+		},
+		{
+			name:     "table",
+			source:   "| a | b |\n|---|---|\n| 1 | <b onclick=\"x\">2</b> |",
+			contains: []string{"<table>", "<th>a</th>", "<td>1</td>"},
+			excludes: []string{"onclick"},
+		},
+		{
+			name:     "code block language",
+			source:   "```go\nfmt.Println(\"<b>\")\n```",
+			contains: []string{`<code class="language-go">`, "&lt;b&gt;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := RenderMarkdown(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(rendered, want) {
+					t.Errorf("output does not contain %q:\n%s", want, rendered)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(strings.ToLower(rendered), strings.ToLower(unwanted)) {
+					t.Errorf("output contains %q:\n%s", unwanted, rendered)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderContentHTML(t *testing.T) {
+	tests := []struct {
+		content, format, want string
+	}{
+		{"**bold**", "markdown", "<p><strong>bold</strong></p>\n"},
+		{"**bold**", "", "<p>**bold**</p>\n"},
+		{"<script>x</script>\nline", "plain", "<p>&lt;script&gt;x&lt;/script&gt;<br>\nline</p>\n"},
+		{"a\n\n\nb", "plain", "<p>a</p>\n<p>b</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := RenderContentHTML(tt.content, tt.format); got != tt.want {
+			t.Errorf("RenderContentHTML(%q, %q) = %q, want %q", tt.content, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownToPlainText(t *testing.T) {
+	source := "# Title\n\nSome **bold** text <b>raw</b>.\n\n- one\n- [x] done\n\n1. first\n2. second\n\n| a | b |\n|---|---|\n| 1 | 2 |\n"
+	want := "Title\n\nSome bold text raw.\n\n• one\n• [x] done\n\n1. first\n2. second\n\na | b\n1 | 2"
+	if got := strings.TrimSpace(MarkdownToPlainText(source)); got != want {
+		t.Fatalf("MarkdownToPlainText =\n%q\nwant\n%q", got, want)
+	}
+}
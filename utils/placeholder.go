@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// TemplatePlaceholders ชื่อ placeholder ที่แทนค่าได้ในแม่แบบโน้ต
+var TemplatePlaceholders = []string{"date", "time", "datetime", "weekday", "day", "month", "year"}
+
+// ExpandPlaceholders แทนค่า placeholder ด้วยวันเวลา now (ควรอยู่ในเขตเวลาของผู้ใช้แล้ว)
+// placeholder ที่ไม่รู้จักจะคงไว้ตามเดิม
+func ExpandPlaceholders(text string, now time.Time) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		switch placeholderPattern.FindStringSubmatch(match)[1] {
+		case "date":
+			return now.Format("2006-01-02")
+		case "time":
+			return now.Format("15:04")
+		case "datetime":
+			return now.Format("2006-01-02 15:04")
+		case "weekday":
+			return now.Weekday().String()
+		case "day":
+			return now.Format("2")
+		case "month":
+			return now.Month().String()
+		case "year":
+			return now.Format("2006")
+		}
+		return match
+	})
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ตัวอักษรที่ใช้ในคีย์ตำแหน่ง เรียงตามลำดับ ASCII เพื่อให้เปรียบเทียบคีย์แบบ string ได้ตรงกับลำดับจริง
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// MaxPositionKeyLength ความยาวคีย์สูงสุดก่อนต้องจัดเรียงคีย์ใหม่ทั้งหมด (rebalance)
+const MaxPositionKeyLength = 32
+
+// PositionKeyBetween สร้างคีย์ที่อยู่ระหว่าง before และ after ตามลำดับ lexicographic
+// before = "" หมายถึงไม่มีขอบล่าง (ต้นรายการ) และ after = "" หมายถึงไม่มีขอบบน (ท้ายรายการ)
+// คีย์ที่สร้างจะไม่ลงท้ายด้วย '0' จึงแทรกคีย์ก่อนหน้าได้เสมอ
+func PositionKeyBetween(before, after string) (string, error) {
+	if !validPositionKey(before) || !validPositionKey(after) {
+		return "", fmt.Errorf("invalid position key")
+	}
+	if after != "" && before >= after {
+		return "", fmt.Errorf("position keys out of order: %q >= %q", before, after)
+	}
+	return positionMidpoint(before, after), nil
+}
+
+func positionMidpoint(a, b string) string {
+	// ตัด prefix ที่เหมือนกัน (a ที่สั้นกว่าถือว่าเติม '0' ต่อท้าย)
+	if b != "" {
+		n := 0
+		for n < len(b) {
+			digit := byte('0')
+			if n < len(a) {
+				digit = a[n]
+			}
+			if digit != b[n] {
+				break
+			}
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + positionMidpoint(rest, b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(positionDigits, a[0])
+	}
+	digitB := len(positionDigits)
+	if b != "" {
+		digitB = strings.IndexByte(positionDigits, b[0])
+	}
+
+	if digitB-digitA > 1 {
+		return string(positionDigits[(digitA+digitB)/2])
+	}
+
+	// ตัวเลขติดกัน: ถ้า b ยาวกว่าหนึ่งหลัก ใช้หลักแรกของ b ได้เลย ไม่เช่นนั้นต่อหลักถัดไปของ a
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(positionDigits[digitA]) + positionMidpoint(rest, "")
+}
+
+// EvenPositionKeys สร้างคีย์ n ตัวที่เรียงกันและเว้นระยะเท่า ๆ กัน ใช้ตอน rebalance
+func EvenPositionKeys(n int) []string {
+	base := len(positionDigits)
+
+	// หาจำนวนหลักที่พอให้มีช่องว่างระหว่างคีย์อย่างน้อยหนึ่งช่อง
+	length, capacity := 1, base
+	for capacity <= 2*n {
+		length++
+		capacity *= base
+	}
+
+	keys := make([]string, n)
+	step := capacity / (n + 1)
+	for i := 0; i < n; i++ {
+		value := step * (i + 1)
+		digits := make([]byte, length)
+		for d := length - 1; d >= 0; d-- {
+			digits[d] = positionDigits[value%base]
+			value /= base
+		}
+		keys[i] = strings.TrimRight(string(digits), "0")
+	}
+	return keys
+}
+
+func validPositionKey(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(positionDigits, key[i]) < 0 {
+			return false
+		}
+	}
+	return !strings.HasSuffix(key, "0")
+}
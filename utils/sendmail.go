@@ -7,6 +7,11 @@ import (
 )
 
 func SendEmail(to, subject, body string) error {
+	return SendHTMLEmail(to, subject, body, "")
+}
+
+// SendHTMLEmail ส่งอีเมลที่มีทั้งข้อความธรรมดาและ HTML (htmlBody ว่าง = ส่งเฉพาะข้อความธรรมดา)
+func SendHTMLEmail(to, subject, body, htmlBody string) error {
 	message := gomail.NewMessage()
 	fromEmail := os.Getenv("MAIL_EMAIL")
 	fromPassword := os.Getenv("MAIL_PASSWORD")
@@ -15,6 +20,9 @@ func SendEmail(to, subject, body string) error {
 	message.SetHeader("To", to)
 	message.SetHeader("Subject", subject)
 	message.SetBody("text/plain", body)
+	if htmlBody != "" {
+		message.AddAlternative("text/html", htmlBody)
+	}
 
 	d := gomail.NewDialer("smtp.gmail.com", 587, fromEmail, fromPassword)
 
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FieldError ข้อผิดพลาดของฟิลด์เดียว
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError รวบรวมข้อผิดพลาดทุกฟิลด์ที่ไม่ผ่านการตรวจสอบ (handler ตอบกลับเป็น 422)
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Add(field, message string) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: message})
+}
+
+// Err คืน nil ถ้าไม่มีข้อผิดพลาด เพื่อใช้ return ต่อได้ทันที
+func (e *ValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// NoteColors ชื่อสีที่อนุญาต (นอกจากนี้ใช้รหัส hex เช่น #ff8800 หรือ #f80 ได้)
+var NoteColors = []string{"default", "red", "orange", "yellow", "green", "teal", "blue", "purple", "pink", "brown", "gray"}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+
+// ระดับความสำคัญของโน้ต
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+// PriorityNames ชื่อของแต่ละระดับ เรียงตามค่า 0-4
+var PriorityNames = []string{"none", "low", "medium", "high", "urgent"}
+
+// NormalizeColor ตัดช่องว่างและแปลงเป็นตัวพิมพ์เล็ก
+func NormalizeColor(color string) string {
+	return strings.ToLower(strings.TrimSpace(color))
+}
+
+// IsValidColor ตรวจสอบสีที่ normalize แล้ว ค่าว่างถือว่าเป็นสีเริ่มต้น
+func IsValidColor(color string) bool {
+	if color == "" || hexColorPattern.MatchString(color) {
+		return true
+	}
+	for _, name := range NoteColors {
+		if color == name {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidPriority(priority int) bool {
+	return priority >= PriorityNone && priority <= PriorityUrgent
+}
+
+// ValidateColor เพิ่มข้อผิดพลาดถ้าสีไม่อยู่ใน palette และไม่ใช่รหัส hex
+func (e *ValidationError) ValidateColor(field, color string) {
+	if !IsValidColor(color) {
+		e.Add(field, "must be one of "+strings.Join(NoteColors, ", ")+" or a hex color such as #ff8800")
+	}
+}
+
+// ValidatePriority เพิ่มข้อผิดพลาดถ้าระดับความสำคัญอยู่นอกช่วง 0-4
+func (e *ValidationError) ValidatePriority(field string, priority int) {
+	if !IsValidPriority(priority) {
+		levels := make([]string, 0, len(PriorityNames))
+		for value, name := range PriorityNames {
+			levels = append(levels, fmt.Sprintf("%d=%s", value, name))
+		}
+		e.Add(field, fmt.Sprintf("must be between %d and %d (%s)", PriorityNone, PriorityUrgent, strings.Join(levels, ", ")))
+	}
+}
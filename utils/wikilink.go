@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// WikiLink ลิงก์ [[Note Title]] หรือ [[note:123]] ในเนื้อหาโน้ต (NoteID = 0 คือลิงก์ด้วยชื่อ)
+type WikiLink struct {
+	Title  string
+	NoteID uint
+}
+
+// ParseWikiLinks ดึงลิงก์ทั้งหมดจากเนื้อหาโน้ต ลิงก์ซ้ำ (ชื่อไม่สนตัวพิมพ์เล็ก/ใหญ่) จะเหลือรายการเดียว
+func ParseWikiLinks(content string) []WikiLink {
+	if !strings.Contains(content, "[[") {
+		return nil
+	}
+
+	var links []WikiLink
+	seen := map[string]bool{}
+	for _, match := range wikiLinkPattern.FindAllStringSubmatch(content, -1) {
+		link, ok := parseWikiLink(match[1])
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(link.Title)
+		if link.NoteID != 0 {
+			key = fmt.Sprintf("note:%d", link.NoteID)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		links = append(links, link)
+	}
+	return links
+}
+
+func parseWikiLink(text string) (WikiLink, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return WikiLink{}, false
+	}
+	if len(text) > 5 && strings.EqualFold(text[:5], "note:") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(text[5:]), 10, 64); err == nil && id > 0 {
+			return WikiLink{NoteID: uint(id)}, true
+		}
+	}
+	return WikiLink{Title: text}, true
+}
+
+// IsLinkableTitle ตรวจว่าชื่อโน้ตเขียนเป็น [[ชื่อ]] ได้หรือไม่
+func IsLinkableTitle(title string) bool {
+	title = strings.TrimSpace(title)
+	if title == "" || strings.ContainsAny(title, "[]\n") {
+		return false
+	}
+	// ชื่อแบบ "note:123" จะถูกอ่านเป็นลิงก์ด้วย id
+	link, ok := parseWikiLink(title)
+	return ok && link.NoteID == 0
+}
+
+// RenameWikiLinks เปลี่ยนลิงก์ [[oldTitle]] (ไม่สนตัวพิมพ์เล็ก/ใหญ่) เป็น replacement เช่น "[[New Title]]" หรือ "[[note:123]]"
+func RenameWikiLinks(content string, oldTitle string, replacement string) string {
+	oldTitle = strings.TrimSpace(oldTitle)
+	return wikiLinkPattern.ReplaceAllStringFunc(content, func(match string) string {
+		link, ok := parseWikiLink(match[2 : len(match)-2])
+		if !ok || link.NoteID != 0 || !strings.EqualFold(link.Title, oldTitle) {
+			return match
+		}
+		return replacement
+	})
+}